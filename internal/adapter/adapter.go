@@ -0,0 +1,280 @@
+package adapter
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	"ai-manager/internal/config"
+	"ai-manager/internal/models"
+	"ai-manager/internal/utils"
+)
+
+// ErrUnsupported is returned by ApplyModel for tools whose model can't be
+// switched
+var ErrUnsupported = errors.New("model switching not supported")
+
+// versionTimeout bounds how long a tool's --version may take
+const versionTimeout = 5 * time.Second
+
+// ToolAdapter knows how one AI tool is installed, configured and cleaned
+// up. Methods take the tool's configuration, since its paths can be
+// changed in the config file.
+type ToolAdapter interface {
+	// Key is the tool's key under tools: in the config
+	Key() string
+
+	// Binary is the name of the executable that launches the tool
+	Binary() string
+
+	// Detect reports whether the tool is installed
+	Detect(tool config.Tool) bool
+
+	// Version returns the installed version as reported by the tool
+	Version(ctx context.Context, tool config.Tool) (string, error)
+
+	// SettingsPath returns the full path of the tool's settings file, or
+	// "" if it has none
+	SettingsPath(tool config.Tool) string
+
+	// ReadSettings parses the settings file; a missing file is empty
+	ReadSettings(tool config.Tool) (map[string]interface{}, error)
+
+	// ApplyModel writes a model into the settings file, removing the
+	// managed environment variables the model doesn't set. It returns
+	// ErrUnsupported if the tool has no model setting.
+	ApplyModel(tool config.Tool, model config.Model, managed []string) error
+
+	// TempLocations returns the temporary directories cleanup empties
+	// when the tool has no rules
+	TempLocations(tool config.Tool) []TempLocation
+
+	// Sessions returns the conversations the tool has recorded
+	Sessions(tool config.Tool) ([]models.Session, error)
+
+	// Health checks the tool's installation and settings
+	Health(tool config.Tool) []models.HealthCheck
+}
+
+// Launcher is implemented by the adapters of tools that read their model
+// from their own environment variables or command line flags, on top of
+// the Anthropic variables of ModelEnv, when ai-mgr run launches them
+type Launcher interface {
+	// LaunchModel returns the environment variables and the arguments to
+	// put before the user's that make the tool use model
+	LaunchModel(model config.Model) (env map[string]string, args []string)
+}
+
+// WorkspaceStore is implemented by the adapters of editors that keep state
+// for each opened folder in a workspaceStorage directory
+type WorkspaceStore interface {
+	// WorkspaceStorage returns the directory holding one folder per
+	// workspace, or "" if the tool has none
+	WorkspaceStorage(tool config.Tool) string
+}
+
+// ProjectMatcher is implemented by the adapters of tools that record a
+// project under an ID derived from its path, which can't be turned back
+// into the path. Sessions report the ID as their project.
+type ProjectMatcher interface {
+	// ProjectID returns the ID the tool records the project at path under
+	ProjectID(path string) string
+}
+
+// TempLocation is a temporary directory of a tool and the retention
+// setting that decides how long its files are kept
+type TempLocation struct {
+	Path      string // relative to the tool path
+	Retention string // e.g. "temp_files_days"
+}
+
+var registry = make(map[string]ToolAdapter)
+
+// Register makes an adapter available and adds the tool to the built-in
+// configuration with the given defaults. Tools added after Claude Code,
+// Gemini CLI and OpenCode are registered disabled, so upgrading ai-mgr
+// doesn't make switch and cleanup start touching them.
+func Register(a ToolAdapter, defaults config.Tool) {
+	if _, dup := registry[a.Key()]; dup {
+		panic("adapter: Register called twice for " + a.Key())
+	}
+	registry[a.Key()] = a
+	config.RegisterTool(a.Key(), defaults)
+}
+
+// For returns the adapter of a tool key. Tools added only in the config
+// file get a generic adapter that works from their configuration alone.
+func For(key string) ToolAdapter {
+	if a, ok := registry[key]; ok {
+		return a
+	}
+	return &base{key: key, binary: key}
+}
+
+// Keys returns the keys of the registered adapters in order
+func Keys() []string {
+	keys := make([]string, 0, len(registry))
+	for k := range registry {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+// base implements ToolAdapter from the tool's configuration. Adapters
+// embed it and override what their tool does differently; one that
+// overrides SettingsPath must also override ReadSettings and Health, which
+// base can't dispatch back to it.
+type base struct {
+	key    string
+	binary string
+}
+
+func (b *base) Key() string {
+	return b.key
+}
+
+func (b *base) Binary() string {
+	return b.binary
+}
+
+// Detect reports whether the tool directory exists
+func (b *base) Detect(tool config.Tool) bool {
+	_, err := os.Stat(utils.ExpandPath(tool.Path))
+	return err == nil
+}
+
+// Version runs the tool's binary with --version and returns the first
+// line of its output
+func (b *base) Version(ctx context.Context, tool config.Tool) (string, error) {
+	path, err := exec.LookPath(b.binary)
+	if err != nil {
+		return "", err
+	}
+	return VersionOf(ctx, path)
+}
+
+// VersionOf runs an executable with --version and returns the first line
+// of its output, giving up after a few seconds
+func VersionOf(ctx context.Context, path string) (string, error) {
+	ctx, cancel := context.WithTimeout(ctx, versionTimeout)
+	defer cancel()
+
+	cmd := exec.CommandContext(ctx, path, "--version")
+	// Don't wait for children that keep the output open after a timeout
+	cmd.WaitDelay = time.Second
+	out, err := cmd.Output()
+	if ctx.Err() == context.DeadlineExceeded {
+		return "", fmt.Errorf("%s --version: timed out after %s", path, versionTimeout)
+	}
+	if err != nil {
+		return "", fmt.Errorf("%s --version: %w", path, err)
+	}
+	line, _, _ := strings.Cut(strings.TrimSpace(string(out)), "\n")
+	return strings.TrimSpace(line), nil
+}
+
+// SettingsPath resolves config_path, which may be relative to the tool path
+func (b *base) SettingsPath(tool config.Tool) string {
+	if tool.ConfigPath == "" {
+		return ""
+	}
+	configPath := utils.ExpandPath(tool.ConfigPath)
+	if filepath.IsAbs(configPath) {
+		return configPath
+	}
+	return filepath.Join(utils.ExpandPath(tool.Path), configPath)
+}
+
+// dataDir resolves data_path, which may be relative to the tool path, or
+// def when it isn't set
+func dataDir(tool config.Tool, def string) string {
+	dataPath := tool.DataPath
+	if dataPath == "" {
+		dataPath = def
+	}
+	if dataPath == "" {
+		return ""
+	}
+	dataPath = utils.ExpandPath(dataPath)
+	if filepath.IsAbs(dataPath) {
+		return dataPath
+	}
+	return filepath.Join(utils.ExpandPath(tool.Path), dataPath)
+}
+
+// ReadSettings parses the settings file according to its extension
+func (b *base) ReadSettings(tool config.Tool) (map[string]interface{}, error) {
+	return readSettingsFile(b.SettingsPath(tool))
+}
+
+func (b *base) ApplyModel(tool config.Tool, model config.Model, managed []string) error {
+	return ErrUnsupported
+}
+
+// TempLocations returns temp_paths, kept for retention.temp_files_days
+func (b *base) TempLocations(tool config.Tool) []TempLocation {
+	locations := make([]TempLocation, 0, len(tool.TempPaths))
+	for _, p := range tool.TempPaths {
+		locations = append(locations, TempLocation{Path: p, Retention: "temp_files_days"})
+	}
+	return locations
+}
+
+func (b *base) Sessions(tool config.Tool) ([]models.Session, error) {
+	return nil, nil
+}
+
+// Health checks that the tool directory exists and that the settings file
+// exists and can be parsed
+func (b *base) Health(tool config.Tool) []models.HealthCheck {
+	return checkHealth(b, tool)
+}
+
+// checkHealth runs the checks every tool shares, through a's own settings
+// path and parser
+func checkHealth(a ToolAdapter, tool config.Tool) []models.HealthCheck {
+	toolPath := utils.ExpandPath(tool.Path)
+	if _, err := os.Stat(toolPath); err != nil {
+		return []models.HealthCheck{{
+			Check:   "path",
+			Status:  models.StatusError,
+			Message: "Tool path not found: " + toolPath,
+		}}
+	}
+	checks := []models.HealthCheck{{
+		Check:   "path",
+		Status:  models.StatusOK,
+		Message: "Path exists: " + toolPath,
+	}}
+
+	settingsPath := a.SettingsPath(tool)
+	if settingsPath == "" {
+		return checks
+	}
+	if _, err := os.Stat(settingsPath); os.IsNotExist(err) {
+		return append(checks, models.HealthCheck{
+			Check:   "settings",
+			Status:  models.StatusWarning,
+			Message: "Config file missing: " + settingsPath,
+		})
+	}
+	if _, err := a.ReadSettings(tool); err != nil {
+		return append(checks, models.HealthCheck{
+			Check:   "settings",
+			Status:  models.StatusError,
+			Message: err.Error(),
+		})
+	}
+	return append(checks, models.HealthCheck{
+		Check:   "settings",
+		Status:  models.StatusOK,
+		Message: "Config file found: " + settingsPath,
+	})
+}
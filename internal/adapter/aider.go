@@ -0,0 +1,69 @@
+package adapter
+
+import (
+	"fmt"
+	"os"
+	"strings"
+
+	"ai-manager/internal/config"
+	"ai-manager/internal/models"
+	"ai-manager/internal/utils"
+)
+
+func init() {
+	Register(&aider{base{key: "aider", binary: "aider"}}, config.Tool{
+		Name:       "Aider",
+		Path:       "~/.aider",
+		ConfigPath: "~/.aider.conf.yml",
+		DataPath:   "caches",
+		TempPaths:  []string{"caches"},
+		Enabled:    false,
+	})
+}
+
+// aider is the adapter for Aider. Its settings live next to its data
+// directory in ~/.aider.conf.yml; chat histories are kept in each project.
+type aider struct {
+	base
+}
+
+// Detect also finds an install that has only written its config file
+func (a *aider) Detect(tool config.Tool) bool {
+	if a.base.Detect(tool) {
+		return true
+	}
+	_, err := os.Stat(a.SettingsPath(tool))
+	return tool.ConfigPath != "" && err == nil
+}
+
+// anthropicEndpoint is the API Aider reaches for anthropic/ models without
+// further settings
+const anthropicEndpoint = "https://api.anthropic.com"
+
+// ApplyModel sets model in .aider.conf.yml to the provider/model_id name
+// Aider looks models up by. Aider takes the endpoint of a provider from its
+// own settings, so a model served from another endpoint can't be switched
+// to from here.
+func (a *aider) ApplyModel(tool config.Tool, model config.Model, managed []string) error {
+	if model.ModelID == "" {
+		return nil
+	}
+	if model.Provider == "" {
+		return fmt.Errorf("%w: Aider needs the model's provider", ErrUnsupported)
+	}
+	if endpoint := strings.TrimSuffix(model.APIEndpoint, "/"); endpoint != "" && endpoint != anthropicEndpoint {
+		return fmt.Errorf("%w: Aider can't be pointed at %s", ErrUnsupported, model.APIEndpoint)
+	}
+	return setYAMLString(a.SettingsPath(tool), "model", model.Provider+"/"+model.ModelID)
+}
+
+// Health reports a missing data directory as a warning when the config file
+// shows Aider is installed
+func (a *aider) Health(tool config.Tool) []models.HealthCheck {
+	checks := checkHealth(a, tool)
+	if len(checks) == 1 && checks[0].Status == models.StatusError && a.Detect(tool) {
+		checks[0].Status = models.StatusWarning
+		checks[0].Message = "Data directory not created yet: " + utils.ExpandPath(tool.Path)
+	}
+	return checks
+}
@@ -0,0 +1,112 @@
+package adapter
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"ai-manager/internal/config"
+)
+
+func aiderTool(root string) config.Tool {
+	return config.Tool{
+		Path:       filepath.Join(root, ".aider"),
+		ConfigPath: filepath.Join(root, ".aider.conf.yml"),
+		DataPath:   "caches",
+		TempPaths:  []string{"caches"},
+	}
+}
+
+func TestAiderDetect(t *testing.T) {
+	root := t.TempDir()
+	tool := aiderTool(root)
+	a := For("aider")
+
+	if a.Detect(tool) {
+		t.Error("Detect found Aider in an empty home")
+	}
+	// Aider writes its data directory only once it has run in a project
+	writeTestFile(t, tool.ConfigPath, "dark-mode: true\n")
+	if !a.Detect(tool) {
+		t.Error("Detect missed an install with only .aider.conf.yml")
+	}
+	if err := os.Remove(tool.ConfigPath); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.Mkdir(tool.Path, 0755); err != nil {
+		t.Fatal(err)
+	}
+	if !a.Detect(tool) {
+		t.Error("Detect missed an install with only ~/.aider")
+	}
+}
+
+func TestAiderApplyModel(t *testing.T) {
+	root := t.TempDir()
+	tool := aiderTool(root)
+	a := For("aider")
+
+	writeTestFile(t, tool.ConfigPath, "# my settings\nmodel: gpt-4o # old\ndark-mode: true\n")
+	model := config.Model{Provider: "anthropic", APIEndpoint: "https://api.anthropic.com/", ModelID: "claude-sonnet-4-20250514"}
+	if err := a.ApplyModel(tool, model, nil); err != nil {
+		t.Fatal(err)
+	}
+	settings, err := a.ReadSettings(tool)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if settings["model"] != "anthropic/claude-sonnet-4-20250514" || settings["dark-mode"] != true {
+		t.Errorf("settings = %v", settings)
+	}
+	data, err := os.ReadFile(tool.ConfigPath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(string(data), "# my settings") || !strings.Contains(string(data), "# old") {
+		t.Errorf("comments lost:\n%s", data)
+	}
+
+	// Models Aider can't reach from its config leave the file alone
+	for name, model := range map[string]config.Model{
+		"endpoint":    {Provider: "zhipu", APIEndpoint: "https://open.bigmodel.cn/api/anthropic", ModelID: "glm-4.7"},
+		"no provider": {ModelID: "glm-4.7"},
+	} {
+		if err := a.ApplyModel(tool, model, nil); !errors.Is(err, ErrUnsupported) {
+			t.Errorf("%s: ApplyModel = %v, want ErrUnsupported", name, err)
+		}
+	}
+	after, err := os.ReadFile(tool.ConfigPath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(after) != string(data) {
+		t.Errorf("unsupported model changed the file:\n%s", after)
+	}
+
+	// A missing config file is created
+	tool = aiderTool(t.TempDir())
+	if err := a.ApplyModel(tool, model, nil); err != nil {
+		t.Fatal(err)
+	}
+	if settings, err := a.ReadSettings(tool); err != nil || settings["model"] != "anthropic/claude-sonnet-4-20250514" {
+		t.Errorf("settings = %v, %v", settings, err)
+	}
+}
+
+func TestAiderTempAndSessions(t *testing.T) {
+	root := t.TempDir()
+	tool := aiderTool(root)
+	a := For("aider")
+
+	locations := a.TempLocations(tool)
+	if len(locations) != 1 || locations[0] != (TempLocation{Path: "caches", Retention: "temp_files_days"}) {
+		t.Errorf("TempLocations = %+v", locations)
+	}
+	// Chat histories live in each project, not under ~/.aider
+	writeTestFile(t, filepath.Join(tool.Path, "caches", "x.json"), "{}")
+	if sessions, err := a.Sessions(tool); err != nil || len(sessions) != 0 {
+		t.Errorf("Sessions = %+v, %v, want none", sessions, err)
+	}
+}
@@ -0,0 +1,199 @@
+package adapter
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"ai-manager/internal/config"
+	"ai-manager/internal/models"
+	"ai-manager/internal/utils"
+)
+
+func init() {
+	Register(&claude{base{key: "claude", binary: "claude"}}, config.Tool{
+		Name:       "Claude Code",
+		Path:       "~/.claude",
+		ConfigPath: "settings.json",
+		DataPath:   "projects",
+		TempPaths:  []string{"debug", "shell-snapshots"},
+		Enabled:    true,
+	})
+}
+
+// claude is the adapter for Claude Code
+type claude struct {
+	base
+}
+
+// ApplyModel sets the model environment in the env block of settings.json
+// and removes variables left over from other models
+func (c *claude) ApplyModel(tool config.Tool, model config.Model, managed []string) error {
+	return updateJSONSettings(c.SettingsPath(tool), func(settings *jsonObject) error {
+		env := settings.Object("env")
+		desired := ModelEnv(model)
+
+		for _, k := range managed {
+			if _, ok := desired[k]; !ok {
+				env.Delete(k)
+			}
+		}
+		for _, k := range sortedStrings(desired) {
+			if err := env.Set(k, desired[k]); err != nil {
+				return err
+			}
+		}
+
+		return settings.Set("env", env)
+	})
+}
+
+// TempLocations keeps debug logs and shell snapshots for their own
+// retention settings
+func (c *claude) TempLocations(tool config.Tool) []TempLocation {
+	locations := c.base.TempLocations(tool)
+	for i, l := range locations {
+		switch filepath.Base(l.Path) {
+		case "debug":
+			locations[i].Retention = "debug_logs_days"
+		case "shell-snapshots":
+			locations[i].Retention = "shell_snapshots_days"
+		}
+	}
+	return locations
+}
+
+// Sessions lists the transcripts in projects/<project>/<session>.jsonl.
+// The project is the working directory the newest transcript recorded,
+// since the directory name replaces every "/" and "." in it with "-".
+// Projects whose transcripts are gone, which Claude Code removes after
+// cleanupPeriodDays, are taken from history.jsonl.
+func (c *claude) Sessions(tool config.Tool) ([]models.Session, error) {
+	dir := dataDir(tool, "projects")
+	projects, err := os.ReadDir(dir)
+	if err != nil && !os.IsNotExist(err) {
+		return nil, err
+	}
+
+	sessions := make([]models.Session, 0)
+	seen := make(map[string]bool)
+	for _, p := range projects {
+		if !p.IsDir() {
+			continue
+		}
+		files, err := os.ReadDir(filepath.Join(dir, p.Name()))
+		if err != nil {
+			continue
+		}
+		start := len(sessions)
+		for _, f := range files {
+			id, ok := strings.CutSuffix(f.Name(), ".jsonl")
+			if !ok || f.IsDir() {
+				continue
+			}
+			info, err := f.Info()
+			if err != nil {
+				continue
+			}
+			sessions = append(sessions, models.Session{
+				Tool:    c.key,
+				ID:      id,
+				Project: p.Name(),
+				Path:    filepath.Join(dir, p.Name(), f.Name()),
+				Size:    info.Size(),
+				Updated: info.ModTime(),
+			})
+		}
+
+		project := sessions[start:]
+		newest := -1
+		for i := range project {
+			if newest < 0 || project[i].Updated.After(project[newest].Updated) {
+				newest = i
+			}
+		}
+		if newest < 0 {
+			continue
+		}
+		seen[p.Name()] = true
+		if cwd := transcriptCwd(project[newest].Path); cwd != "" {
+			for i := range project {
+				project[i].Project = cwd
+			}
+		}
+	}
+
+	return append(sessions, c.historySessions(tool, seen, len(sessions) == 0)...), nil
+}
+
+// claudeHistory is a prompt recorded in history.jsonl
+type claudeHistory struct {
+	Project   string `json:"project"`
+	Timestamp int64  `json:"timestamp"` // milliseconds
+	SessionID string `json:"sessionId"`
+}
+
+// historySessions returns the sessions history.jsonl records for projects
+// whose transcript directory isn't in seen. Prompts are grouped by the
+// session they name, or else by project. When nothing else is known about
+// the tool's use, an unreadable history still gives its last use through
+// the file's modification time.
+func (c *claude) historySessions(tool config.Tool, seen map[string]bool, fallback bool) []models.Session {
+	path := filepath.Join(utils.ExpandPath(tool.Path), "history.jsonl")
+	f, err := os.Open(path)
+	if err != nil {
+		return nil
+	}
+	defer f.Close()
+
+	type group struct{ project, id string }
+	groups := make(map[group]*models.Session)
+	order := make([]group, 0)
+
+	// Long pasted prompts make lines too long for a bufio.Scanner
+	dec := json.NewDecoder(f)
+	for {
+		var h claudeHistory
+		if err := dec.Decode(&h); err != nil {
+			break
+		}
+		if h.Project == "" || h.Timestamp <= 0 || seen[claudeProjectDir(h.Project)] {
+			continue
+		}
+
+		g := group{h.Project, h.SessionID}
+		s := groups[g]
+		if s == nil {
+			id := h.SessionID
+			if id == "" {
+				id = "history"
+			}
+			s = &models.Session{Tool: c.key, ID: id, Project: h.Project, Path: path}
+			groups[g] = s
+			order = append(order, g)
+		}
+		if t := time.UnixMilli(h.Timestamp); t.After(s.Updated) {
+			s.Updated = t
+		}
+	}
+
+	sessions := make([]models.Session, 0, len(order))
+	for _, g := range order {
+		sessions = append(sessions, *groups[g])
+	}
+
+	if len(sessions) == 0 && fallback {
+		if info, err := f.Stat(); err == nil && info.Size() > 0 {
+			sessions = append(sessions, models.Session{Tool: c.key, ID: "history", Path: path, Updated: info.ModTime()})
+		}
+	}
+	return sessions
+}
+
+// claudeProjectDir returns the name of the directory Claude Code keeps a
+// project's transcripts in
+func claudeProjectDir(project string) string {
+	return strings.NewReplacer("/", "-", ".", "-").Replace(project)
+}
@@ -0,0 +1,111 @@
+package adapter
+
+import (
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"ai-manager/internal/config"
+	"ai-manager/internal/models"
+	"ai-manager/internal/safefile"
+)
+
+func init() {
+	Register(&codex{base{key: "codex", binary: "codex"}}, config.Tool{
+		Name:       "Codex CLI",
+		Path:       "~/.codex",
+		ConfigPath: "config.toml",
+		DataPath:   "sessions",
+		TempPaths:  []string{"log"},
+		Enabled:    false,
+	})
+}
+
+// codex is the adapter for OpenAI's Codex CLI
+type codex struct {
+	base
+}
+
+// ApplyModel sets model in config.toml. A model with an endpoint is also
+// registered under [model_providers.<provider>] and selected with
+// model_provider.
+func (c *codex) ApplyModel(tool config.Tool, model config.Model, managed []string) error {
+	if model.ModelID == "" {
+		return nil
+	}
+
+	path := c.SettingsPath(tool)
+	data, err := os.ReadFile(path)
+	if err != nil && !os.IsNotExist(err) {
+		return err
+	}
+
+	type setting struct {
+		table      []string
+		key, value string
+	}
+	settings := []setting{{nil, "model", tomlString(model.ModelID)}}
+	if model.APIEndpoint != "" && model.Provider != "" {
+		table := []string{"model_providers", model.Provider}
+		settings = append(settings,
+			setting{nil, "model_provider", tomlString(model.Provider)},
+			setting{table, "name", tomlString(model.Name)},
+			setting{table, "base_url", tomlString(model.APIEndpoint)},
+		)
+		if model.APIKeyEnv != "" {
+			settings = append(settings, setting{table, "env_key", tomlString(model.APIKeyEnv)})
+		}
+	}
+
+	for _, s := range settings {
+		if data, err = setTOML(data, s.table, s.key, s.value); err != nil {
+			return fmt.Errorf("failed to parse %s: %w", path, err)
+		}
+	}
+	return safefile.WriteFile(path, data, 0644)
+}
+
+// LaunchModel points Codex at the model's endpoint with OPENAI_BASE_URL and
+// selects it with --model
+func (c *codex) LaunchModel(model config.Model) (map[string]string, []string) {
+	env := make(map[string]string)
+	if model.APIEndpoint != "" {
+		env["OPENAI_BASE_URL"] = model.APIEndpoint
+	}
+	var args []string
+	if model.ModelID != "" {
+		args = []string{"--model", model.ModelID}
+	}
+	return env, args
+}
+
+// Sessions lists the rollouts in sessions/YYYY/MM/DD/rollout-*.jsonl
+func (c *codex) Sessions(tool config.Tool) ([]models.Session, error) {
+	dir := dataDir(tool, "sessions")
+	sessions := make([]models.Session, 0)
+	err := filepath.WalkDir(dir, func(path string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return nil // Skip unreadable directories
+		}
+		name := d.Name()
+		if d.IsDir() || !strings.HasPrefix(name, "rollout-") || !strings.HasSuffix(name, ".jsonl") {
+			return nil
+		}
+		info, err := d.Info()
+		if err != nil {
+			return nil
+		}
+		sessions = append(sessions, models.Session{
+			Tool:    c.key,
+			ID:      strings.TrimSuffix(strings.TrimPrefix(name, "rollout-"), ".jsonl"),
+			Project: transcriptCwd(path),
+			Path:    path,
+			Size:    info.Size(),
+			Updated: info.ModTime(),
+		})
+		return nil
+	})
+	return sessions, err
+}
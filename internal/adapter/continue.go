@@ -0,0 +1,83 @@
+package adapter
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+
+	"ai-manager/internal/config"
+	"ai-manager/internal/models"
+)
+
+func init() {
+	Register(&continueDev{base{key: "continue", binary: "cn"}}, config.Tool{
+		Name:       "Continue",
+		Path:       "~/.continue",
+		ConfigPath: "config.yaml",
+		DataPath:   "sessions",
+		TempPaths:  []string{"logs"},
+		Enabled:    false,
+	})
+}
+
+// continueDev is the adapter for Continue and its cn CLI
+type continueDev struct {
+	base
+}
+
+// SettingsPath falls back to the older config.json when config.yaml
+// doesn't exist
+func (c *continueDev) SettingsPath(tool config.Tool) string {
+	path := c.base.SettingsPath(tool)
+	if filepath.Base(path) != "config.yaml" {
+		return path
+	}
+	if _, err := os.Stat(path); os.IsNotExist(err) {
+		legacy := filepath.Join(filepath.Dir(path), "config.json")
+		if _, err := os.Stat(legacy); err == nil {
+			return legacy
+		}
+	}
+	return path
+}
+
+func (c *continueDev) ReadSettings(tool config.Tool) (map[string]interface{}, error) {
+	return readSettingsFile(c.SettingsPath(tool))
+}
+
+func (c *continueDev) Health(tool config.Tool) []models.HealthCheck {
+	return checkHealth(c, tool)
+}
+
+// Sessions lists the chats in sessions/<id>.json
+func (c *continueDev) Sessions(tool config.Tool) ([]models.Session, error) {
+	dir := dataDir(tool, "sessions")
+	files, err := os.ReadDir(dir)
+	if os.IsNotExist(err) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	sessions := make([]models.Session, 0)
+	for _, f := range files {
+		// sessions.json is the index of all sessions
+		id, ok := strings.CutSuffix(f.Name(), ".json")
+		if !ok || f.IsDir() || id == "sessions" {
+			continue
+		}
+		info, err := f.Info()
+		if err != nil {
+			continue
+		}
+		sessions = append(sessions, models.Session{
+			Tool:    c.key,
+			ID:      id,
+			Path:    filepath.Join(dir, f.Name()),
+			Size:    info.Size(),
+			Updated: info.ModTime(),
+		})
+	}
+	return sessions, nil
+}
@@ -0,0 +1,112 @@
+package adapter
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"ai-manager/internal/config"
+	"ai-manager/internal/models"
+)
+
+func continueTool(root string) config.Tool {
+	return config.Tool{
+		Path:       root,
+		ConfigPath: "config.yaml",
+		DataPath:   "sessions",
+		TempPaths:  []string{"logs"},
+	}
+}
+
+func TestContinueDetect(t *testing.T) {
+	root := filepath.Join(t.TempDir(), ".continue")
+	a := For("continue")
+	if a.Detect(continueTool(root)) {
+		t.Error("Detect found Continue without ~/.continue")
+	}
+	if err := os.Mkdir(root, 0755); err != nil {
+		t.Fatal(err)
+	}
+	if !a.Detect(continueTool(root)) {
+		t.Error("Detect missed ~/.continue")
+	}
+}
+
+func TestContinueSettings(t *testing.T) {
+	root := t.TempDir()
+	tool := continueTool(root)
+	a := For("continue")
+
+	yamlPath := filepath.Join(root, "config.yaml")
+	jsonPath := filepath.Join(root, "config.json")
+	if got := a.SettingsPath(tool); got != yamlPath {
+		t.Errorf("SettingsPath = %q, want %q when neither exists", got, yamlPath)
+	}
+
+	// Older installs only have config.json
+	writeTestFile(t, jsonPath, `{"models": [{"title": "GPT-4"}], "allowAnonymousTelemetry": false}`)
+	if got := a.SettingsPath(tool); got != jsonPath {
+		t.Errorf("SettingsPath = %q, want %q", got, jsonPath)
+	}
+	settings, err := a.ReadSettings(tool)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if settings["allowAnonymousTelemetry"] != false {
+		t.Errorf("settings = %v", settings)
+	}
+
+	writeTestFile(t, yamlPath, "name: Local\nversion: 1.0.0\n")
+	if got := a.SettingsPath(tool); got != yamlPath {
+		t.Errorf("SettingsPath = %q, want %q once config.yaml exists", got, yamlPath)
+	}
+	if settings, err = a.ReadSettings(tool); err != nil || settings["name"] != "Local" {
+		t.Errorf("settings = %v, %v", settings, err)
+	}
+
+	model := config.Model{Provider: "anthropic", ModelID: "claude-sonnet-4-20250514"}
+	if err := a.ApplyModel(tool, model, nil); !errors.Is(err, ErrUnsupported) {
+		t.Errorf("ApplyModel = %v, want ErrUnsupported", err)
+	}
+
+	writeTestFile(t, yamlPath, "name: [\n")
+	if checks := a.Health(tool); len(checks) != 2 || checks[1].Status != models.StatusError {
+		t.Errorf("Health = %+v, want a settings error", checks)
+	}
+}
+
+func TestContinueTempAndSessions(t *testing.T) {
+	root := t.TempDir()
+	tool := continueTool(root)
+	a := For("continue")
+
+	locations := a.TempLocations(tool)
+	if len(locations) != 1 || locations[0] != (TempLocation{Path: "logs", Retention: "temp_files_days"}) {
+		t.Errorf("TempLocations = %+v", locations)
+	}
+
+	if sessions, err := a.Sessions(tool); err != nil || len(sessions) != 0 {
+		t.Errorf("Sessions = %+v, %v, want none without sessions/", sessions, err)
+	}
+
+	sessionsDir := filepath.Join(root, "sessions")
+	writeTestFile(t, filepath.Join(sessionsDir, "sessions.json"), `[{"sessionId":"a1"}]`)
+	writeTestFile(t, filepath.Join(sessionsDir, "a1.json"), `{"history":[]}`)
+	writeTestFile(t, filepath.Join(sessionsDir, "notes.txt"), "x")
+	if err := os.Mkdir(filepath.Join(sessionsDir, "dir.json"), 0755); err != nil {
+		t.Fatal(err)
+	}
+
+	sessions, err := a.Sessions(tool)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(sessions) != 1 {
+		t.Fatalf("sessions = %+v, want only a1", sessions)
+	}
+	s := sessions[0]
+	if s.Tool != "continue" || s.ID != "a1" || s.Path != filepath.Join(sessionsDir, "a1.json") || s.Size != 14 {
+		t.Errorf("session = %+v", s)
+	}
+}
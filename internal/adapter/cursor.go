@@ -0,0 +1,8 @@
+package adapter
+
+// Cursor keeps its settings in the usual VS Code places and its extensions
+// and MCP config in ~/.cursor
+func init() {
+	Register(&editor{base: base{key: "cursor", binary: "cursor"}, dotDirs: []string{"~/.cursor"}},
+		editorTool("Cursor", "Cursor"))
+}
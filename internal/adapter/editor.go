@@ -0,0 +1,64 @@
+package adapter
+
+import (
+	"os"
+	"runtime"
+
+	"ai-manager/internal/config"
+	"ai-manager/internal/utils"
+)
+
+// editorTempPaths are what a VS Code based editor can lose while running:
+// its logs and downloaded extension packages. Chromium's caches (Cache,
+// Code Cache, GPUCache, CachedData) are in use whenever the editor is open,
+// including files no process has open at the moment, so they are left
+// alone.
+var editorTempPaths = []string{"CachedExtensionVSIXs", "logs"}
+
+// editor is the adapter for a VS Code based editor. Its settings and caches
+// live in the editor's application data directory; the AI features keep
+// their own state in dot directories in the home directory.
+type editor struct {
+	base
+	dotDirs []string
+}
+
+// editorTool returns the default config of an editor whose application
+// data directory is named app
+func editorTool(name, app string) config.Tool {
+	return config.Tool{
+		Name:       name,
+		Path:       editorDataDir(app),
+		ConfigPath: "User/settings.json",
+		DataPath:   "User/workspaceStorage",
+		TempPaths:  editorTempPaths,
+		Enabled:    false,
+	}
+}
+
+// editorDataDir returns where an Electron app named app keeps its data
+func editorDataDir(app string) string {
+	if runtime.GOOS == "darwin" {
+		return "~/Library/Application Support/" + app
+	}
+	return "~/.config/" + app
+}
+
+// Detect finds the editor by its data directory or its dot directories
+func (e *editor) Detect(tool config.Tool) bool {
+	if e.base.Detect(tool) {
+		return true
+	}
+	for _, dir := range e.dotDirs {
+		if _, err := os.Stat(utils.ExpandPath(dir)); err == nil {
+			return true
+		}
+	}
+	return false
+}
+
+// WorkspaceStorage returns the editor's workspaceStorage directory, its
+// data_path
+func (e *editor) WorkspaceStorage(tool config.Tool) string {
+	return dataDir(tool, "")
+}
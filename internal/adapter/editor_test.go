@@ -0,0 +1,122 @@
+package adapter
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"ai-manager/internal/config"
+)
+
+// editorFixture points HOME at a temp dir and returns the default config
+// of an editor with its data directory under it
+func editorFixture(t *testing.T, key, app string) (string, config.Tool) {
+	t.Helper()
+	home := t.TempDir()
+	t.Setenv("HOME", home)
+	tool := editorTool(key, app)
+	tool.Path = filepath.Join(home, ".config", app)
+	return home, tool
+}
+
+func TestEditorDetect(t *testing.T) {
+	tests := []struct {
+		key, app string
+		dotDirs  []string
+	}{
+		{"cursor", "Cursor", []string{".cursor"}},
+		{"windsurf", "Windsurf", []string{".windsurf", ".codeium/windsurf"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.key, func(t *testing.T) {
+			a := For(tt.key)
+			home, tool := editorFixture(t, tt.key, tt.app)
+			if a.Detect(tool) {
+				t.Fatal("Detect found the editor in an empty home")
+			}
+			if err := os.MkdirAll(tool.Path, 0755); err != nil {
+				t.Fatal(err)
+			}
+			if !a.Detect(tool) {
+				t.Error("Detect missed the data directory")
+			}
+			if err := os.RemoveAll(tool.Path); err != nil {
+				t.Fatal(err)
+			}
+			for _, dir := range tt.dotDirs {
+				path := filepath.Join(home, dir)
+				if err := os.MkdirAll(path, 0755); err != nil {
+					t.Fatal(err)
+				}
+				if !a.Detect(tool) {
+					t.Errorf("Detect missed ~/%s", dir)
+				}
+				if err := os.RemoveAll(path); err != nil {
+					t.Fatal(err)
+				}
+			}
+		})
+	}
+}
+
+func TestEditorSettings(t *testing.T) {
+	for _, key := range []string{"cursor", "windsurf"} {
+		t.Run(key, func(t *testing.T) {
+			a := For(key)
+			_, tool := editorFixture(t, key, key)
+			settingsPath := filepath.Join(tool.Path, "User", "settings.json")
+			if got := a.SettingsPath(tool); got != settingsPath {
+				t.Errorf("SettingsPath = %q, want %q", got, settingsPath)
+			}
+
+			// VS Code settings allow comments and trailing commas
+			data := "{\n  // theme\n  \"workbench.colorTheme\": \"Dark\",\n  \"editor.tabSize\": 2,\n}\n"
+			writeTestFile(t, settingsPath, data)
+			settings, err := a.ReadSettings(tool)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if settings["workbench.colorTheme"] != "Dark" || settings["editor.tabSize"] != float64(2) {
+				t.Errorf("settings = %v", settings)
+			}
+
+			model := config.Model{Provider: "anthropic", ModelID: "claude-sonnet-4-20250514"}
+			if err := a.ApplyModel(tool, model, nil); !errors.Is(err, ErrUnsupported) {
+				t.Errorf("ApplyModel = %v, want ErrUnsupported", err)
+			}
+			if after, err := os.ReadFile(settingsPath); err != nil || string(after) != data {
+				t.Errorf("settings changed to %q, %v", after, err)
+			}
+		})
+	}
+}
+
+func TestEditorTempAndSessions(t *testing.T) {
+	for _, key := range []string{"cursor", "windsurf"} {
+		t.Run(key, func(t *testing.T) {
+			a := For(key)
+			_, tool := editorFixture(t, key, key)
+
+			var paths []string
+			for _, l := range a.TempLocations(tool) {
+				if l.Retention != "temp_files_days" {
+					t.Errorf("%s retention = %q", l.Path, l.Retention)
+				}
+				paths = append(paths, l.Path)
+			}
+			if len(paths) != 2 || paths[0] != "CachedExtensionVSIXs" || paths[1] != "logs" {
+				t.Errorf("TempLocations = %v", paths)
+			}
+
+			storage := filepath.Join(tool.Path, "User", "workspaceStorage")
+			if got := a.(WorkspaceStore).WorkspaceStorage(tool); got != storage {
+				t.Errorf("WorkspaceStorage = %q, want %q", got, storage)
+			}
+			writeTestFile(t, filepath.Join(storage, "abc", "workspace.json"), `{"folder":"file:///work/app"}`)
+			if sessions, err := a.Sessions(tool); err != nil || len(sessions) != 0 {
+				t.Errorf("Sessions = %+v, %v, want none", sessions, err)
+			}
+		})
+	}
+}
@@ -0,0 +1,104 @@
+package adapter
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"ai-manager/internal/config"
+	"ai-manager/internal/models"
+)
+
+func init() {
+	Register(&gemini{base{key: "gemini", binary: "gemini"}}, config.Tool{
+		Name:       "Gemini CLI",
+		Path:       "~/.gemini",
+		ConfigPath: "settings.json",
+		DataPath:   "tmp",
+		TempPaths:  []string{"tmp"},
+		Enabled:    true,
+	})
+}
+
+// gemini is the adapter for Gemini CLI
+type gemini struct {
+	base
+}
+
+// ApplyModel sets the model in settings.json, supporting both the flat
+// "model" string and the nested {"model": {"name": ...}} layout
+func (g *gemini) ApplyModel(tool config.Tool, model config.Model, managed []string) error {
+	if model.ModelID == "" {
+		return nil
+	}
+
+	return updateJSONSettings(g.SettingsPath(tool), func(settings *jsonObject) error {
+		if settings.IsObject("model") {
+			m := settings.Object("model")
+			if err := m.Set("name", model.ModelID); err != nil {
+				return err
+			}
+			return settings.Set("model", m)
+		}
+		return settings.Set("model", model.ModelID)
+	})
+}
+
+// LaunchModel selects the model with GEMINI_MODEL
+func (g *gemini) LaunchModel(model config.Model) (map[string]string, []string) {
+	if model.ModelID == "" {
+		return nil, nil
+	}
+	return map[string]string{"GEMINI_MODEL": model.ModelID}, nil
+}
+
+// ProjectID returns the hash Gemini CLI names a project's tmp directory by
+func (g *gemini) ProjectID(path string) string {
+	sum := sha256.Sum256([]byte(path))
+	return hex.EncodeToString(sum[:])
+}
+
+// Sessions lists the chats in tmp/<project hash>/chats/*.json
+func (g *gemini) Sessions(tool config.Tool) ([]models.Session, error) {
+	dir := dataDir(tool, "tmp")
+	projects, err := os.ReadDir(dir)
+	if os.IsNotExist(err) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	sessions := make([]models.Session, 0)
+	for _, p := range projects {
+		if !p.IsDir() {
+			continue
+		}
+		chats := filepath.Join(dir, p.Name(), "chats")
+		files, err := os.ReadDir(chats)
+		if err != nil {
+			continue
+		}
+		for _, f := range files {
+			id, ok := strings.CutSuffix(f.Name(), ".json")
+			if !ok || f.IsDir() {
+				continue
+			}
+			info, err := f.Info()
+			if err != nil {
+				continue
+			}
+			sessions = append(sessions, models.Session{
+				Tool:    g.key,
+				ID:      id,
+				Project: p.Name(),
+				Path:    filepath.Join(chats, f.Name()),
+				Size:    info.Size(),
+				Updated: info.ModTime(),
+			})
+		}
+	}
+	return sessions, nil
+}
@@ -0,0 +1,175 @@
+package adapter
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+)
+
+// jsonObject is a JSON object that keeps its keys in file order so that
+// settings we don't manage are written back exactly where they were
+type jsonObject struct {
+	keys   []string
+	values map[string]json.RawMessage
+}
+
+// newObject returns an empty JSON object
+func newObject() *jsonObject {
+	return &jsonObject{values: make(map[string]json.RawMessage)}
+}
+
+// parseObject parses a JSON object, treating empty input as an empty object
+func parseObject(data []byte) (*jsonObject, error) {
+	obj := newObject()
+	if len(bytes.TrimSpace(data)) == 0 {
+		return obj, nil
+	}
+
+	dec := json.NewDecoder(bytes.NewReader(data))
+	tok, err := dec.Token()
+	if err != nil {
+		return nil, err
+	}
+	if delim, ok := tok.(json.Delim); !ok || delim != '{' {
+		return nil, fmt.Errorf("expected a JSON object")
+	}
+
+	for dec.More() {
+		tok, err := dec.Token()
+		if err != nil {
+			return nil, err
+		}
+		key, ok := tok.(string)
+		if !ok {
+			return nil, fmt.Errorf("expected an object key")
+		}
+
+		var raw json.RawMessage
+		if err := dec.Decode(&raw); err != nil {
+			return nil, err
+		}
+		obj.setRaw(key, raw)
+	}
+
+	if _, err := dec.Token(); err != nil {
+		return nil, err
+	}
+	return obj, nil
+}
+
+// Has reports whether key is present
+func (o *jsonObject) Has(key string) bool {
+	_, ok := o.values[key]
+	return ok
+}
+
+// Get decodes the value stored under key into v
+func (o *jsonObject) Get(key string, v interface{}) (bool, error) {
+	raw, ok := o.values[key]
+	if !ok {
+		return false, nil
+	}
+	return true, json.Unmarshal(raw, v)
+}
+
+// Set stores v under key, keeping the key's position if it already exists
+func (o *jsonObject) Set(key string, v interface{}) error {
+	raw, err := marshalValue(v)
+	if err != nil {
+		return err
+	}
+	o.setRaw(key, raw)
+	return nil
+}
+
+// Delete removes key from the object
+func (o *jsonObject) Delete(key string) {
+	if _, ok := o.values[key]; !ok {
+		return
+	}
+	delete(o.values, key)
+	for i, k := range o.keys {
+		if k == key {
+			o.keys = append(o.keys[:i], o.keys[i+1:]...)
+			break
+		}
+	}
+}
+
+// Object returns the nested object stored under key, or an empty one if the
+// key is missing or does not hold an object
+func (o *jsonObject) Object(key string) *jsonObject {
+	raw, ok := o.values[key]
+	if !ok {
+		return newObject()
+	}
+	child, err := parseObject(raw)
+	if err != nil {
+		return newObject()
+	}
+	return child
+}
+
+// IsObject reports whether key holds a JSON object
+func (o *jsonObject) IsObject(key string) bool {
+	raw, ok := o.values[key]
+	if !ok {
+		return false
+	}
+	raw = bytes.TrimSpace(raw)
+	return len(raw) > 0 && raw[0] == '{'
+}
+
+// MarshalJSON encodes the object with its keys in order
+func (o *jsonObject) MarshalJSON() ([]byte, error) {
+	var buf bytes.Buffer
+	buf.WriteByte('{')
+	for i, key := range o.keys {
+		if i > 0 {
+			buf.WriteByte(',')
+		}
+		k, err := marshalValue(key)
+		if err != nil {
+			return nil, err
+		}
+		buf.Write(k)
+		buf.WriteByte(':')
+		buf.Write(o.values[key])
+	}
+	buf.WriteByte('}')
+	return buf.Bytes(), nil
+}
+
+// Bytes returns the indented file content for the object
+func (o *jsonObject) Bytes() ([]byte, error) {
+	data, err := o.MarshalJSON()
+	if err != nil {
+		return nil, err
+	}
+
+	var out bytes.Buffer
+	if err := json.Indent(&out, data, "", "  "); err != nil {
+		return nil, err
+	}
+	out.WriteByte('\n')
+	return out.Bytes(), nil
+}
+
+func (o *jsonObject) setRaw(key string, raw json.RawMessage) {
+	if _, ok := o.values[key]; !ok {
+		o.keys = append(o.keys, key)
+	}
+	o.values[key] = raw
+}
+
+// marshalValue encodes v without escaping HTML characters, which would
+// otherwise mangle URLs containing '&'
+func marshalValue(v interface{}) (json.RawMessage, error) {
+	var buf bytes.Buffer
+	enc := json.NewEncoder(&buf)
+	enc.SetEscapeHTML(false)
+	if err := enc.Encode(v); err != nil {
+		return nil, err
+	}
+	return json.RawMessage(bytes.TrimRight(buf.Bytes(), "\n")), nil
+}
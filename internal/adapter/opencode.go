@@ -0,0 +1,73 @@
+package adapter
+
+import (
+	"fmt"
+
+	"ai-manager/internal/config"
+)
+
+func init() {
+	Register(&opencode{base{key: "opencode", binary: "opencode"}}, config.Tool{
+		Name:       "OpenCode",
+		Path:       "~/.config/opencode",
+		ConfigPath: "settings.json",
+		DataPath:   "projects",
+		TempPaths:  []string{"node_modules", ".cache"},
+		Enabled:    true,
+	})
+}
+
+// opencode is the adapter for OpenCode
+type opencode struct {
+	base
+}
+
+// ApplyModel registers the model under its provider in the provider
+// config and selects it as the active model
+func (o *opencode) ApplyModel(tool config.Tool, model config.Model, managed []string) error {
+	if model.Provider == "" || model.ModelID == "" {
+		return fmt.Errorf("model needs a provider and model_id for OpenCode")
+	}
+
+	return updateJSONSettings(o.SettingsPath(tool), func(settings *jsonObject) error {
+		providers := settings.Object("provider")
+		provider := providers.Object(model.Provider)
+
+		options := provider.Object("options")
+		if model.APIEndpoint != "" {
+			if err := options.Set("baseURL", model.APIEndpoint); err != nil {
+				return err
+			}
+		}
+		if err := provider.Set("options", options); err != nil {
+			return err
+		}
+
+		modelList := provider.Object("models")
+		if !modelList.Has(model.ModelID) {
+			if err := modelList.Set(model.ModelID, map[string]string{"name": model.Name}); err != nil {
+				return err
+			}
+		}
+		if err := provider.Set("models", modelList); err != nil {
+			return err
+		}
+
+		if err := providers.Set(model.Provider, provider); err != nil {
+			return err
+		}
+		if err := settings.Set("provider", providers); err != nil {
+			return err
+		}
+
+		return settings.Set("model", model.Provider+"/"+model.ModelID)
+	})
+}
+
+// LaunchModel selects the model with --model provider/model_id
+func (o *opencode) LaunchModel(model config.Model) (map[string]string, []string) {
+	if model.Provider == "" || model.ModelID == "" {
+		return nil, nil
+	}
+	return nil, []string{"--model", model.Provider + "/" + model.ModelID}
+}
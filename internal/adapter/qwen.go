@@ -0,0 +1,33 @@
+package adapter
+
+import "ai-manager/internal/config"
+
+func init() {
+	Register(&qwen{gemini{base{key: "qwen", binary: "qwen"}}}, config.Tool{
+		Name:       "Qwen Code",
+		Path:       "~/.qwen",
+		ConfigPath: "settings.json",
+		DataPath:   "tmp",
+		TempPaths:  []string{"tmp"},
+		Enabled:    false,
+	})
+}
+
+// qwen is the adapter for Qwen Code. It is a fork of Gemini CLI and keeps
+// the same settings and chat layout.
+type qwen struct {
+	gemini
+}
+
+// LaunchModel selects the model and its OpenAI-compatible endpoint with
+// Qwen Code's OPENAI_* variables
+func (q *qwen) LaunchModel(model config.Model) (map[string]string, []string) {
+	env := make(map[string]string)
+	if model.ModelID != "" {
+		env["OPENAI_MODEL"] = model.ModelID
+	}
+	if model.APIEndpoint != "" {
+		env["OPENAI_BASE_URL"] = model.APIEndpoint
+	}
+	return env, nil
+}
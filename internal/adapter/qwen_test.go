@@ -0,0 +1,106 @@
+package adapter
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"ai-manager/internal/config"
+)
+
+func qwenTool(root string) config.Tool {
+	return config.Tool{
+		Path:       root,
+		ConfigPath: "settings.json",
+		DataPath:   "tmp",
+		TempPaths:  []string{"tmp"},
+	}
+}
+
+func TestQwenDetect(t *testing.T) {
+	root := filepath.Join(t.TempDir(), ".qwen")
+	a := For("qwen")
+	if a.Detect(qwenTool(root)) {
+		t.Error("Detect found Qwen Code without ~/.qwen")
+	}
+	if err := os.Mkdir(root, 0755); err != nil {
+		t.Fatal(err)
+	}
+	if !a.Detect(qwenTool(root)) {
+		t.Error("Detect missed ~/.qwen")
+	}
+}
+
+func TestQwenApplyModel(t *testing.T) {
+	model := config.Model{Provider: "zhipu", APIEndpoint: "https://open.bigmodel.cn/api/anthropic", ModelID: "glm-4.7"}
+	tests := map[string]struct {
+		settings string
+		want     func(map[string]interface{}) bool
+	}{
+		"missing": {"", func(s map[string]interface{}) bool {
+			return s["model"] == "glm-4.7"
+		}},
+		"flat": {`{"model": "qwen3-coder-plus", "theme": "Default"}`, func(s map[string]interface{}) bool {
+			return s["model"] == "glm-4.7" && s["theme"] == "Default"
+		}},
+		"nested": {`{"model": {"name": "qwen3-coder-plus", "maxSessionTurns": 10}}`, func(s map[string]interface{}) bool {
+			m, _ := s["model"].(map[string]interface{})
+			return m["name"] == "glm-4.7" && m["maxSessionTurns"] == float64(10)
+		}},
+	}
+	for name, tt := range tests {
+		t.Run(name, func(t *testing.T) {
+			root := t.TempDir()
+			tool := qwenTool(root)
+			a := For("qwen")
+			if tt.settings != "" {
+				writeTestFile(t, filepath.Join(root, "settings.json"), tt.settings)
+			}
+			if err := a.ApplyModel(tool, model, nil); err != nil {
+				t.Fatal(err)
+			}
+			settings, err := a.ReadSettings(tool)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !tt.want(settings) {
+				t.Errorf("settings = %v", settings)
+			}
+		})
+	}
+
+	env, args := For("qwen").(Launcher).LaunchModel(model)
+	if env["OPENAI_MODEL"] != "glm-4.7" || env["OPENAI_BASE_URL"] != model.APIEndpoint || len(args) != 0 {
+		t.Errorf("LaunchModel = %v, %v", env, args)
+	}
+}
+
+func TestQwenTempAndSessions(t *testing.T) {
+	root := t.TempDir()
+	tool := qwenTool(root)
+	a := For("qwen")
+
+	locations := a.TempLocations(tool)
+	if len(locations) != 1 || locations[0] != (TempLocation{Path: "tmp", Retention: "temp_files_days"}) {
+		t.Errorf("TempLocations = %+v", locations)
+	}
+
+	project := a.(ProjectMatcher).ProjectID("/work/app")
+	chats := filepath.Join(root, "tmp", project, "chats")
+	writeTestFile(t, filepath.Join(chats, "session-1.json"), `{"messages":[]}`)
+	writeTestFile(t, filepath.Join(chats, "notes.txt"), "x")
+	writeTestFile(t, filepath.Join(root, "tmp", "other", "logs.json"), "[]")
+	writeTestFile(t, filepath.Join(root, "tmp", "stray.json"), "{}")
+
+	sessions, err := a.Sessions(tool)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(sessions) != 1 {
+		t.Fatalf("sessions = %+v, want only session-1", sessions)
+	}
+	s := sessions[0]
+	if s.Tool != "qwen" || s.ID != "session-1" || s.Project != project || s.Path != filepath.Join(chats, "session-1.json") {
+		t.Errorf("session = %+v", s)
+	}
+}
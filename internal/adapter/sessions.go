@@ -0,0 +1,45 @@
+package adapter
+
+import (
+	"encoding/json"
+	"os"
+)
+
+// cwdRecords is how many records of a transcript are read looking for the
+// working directory before giving up
+const cwdRecords = 10
+
+// transcriptRecord holds the working directory a transcript record may
+// carry, at the top level as in Claude Code or in the payload of Codex's
+// session_meta record
+type transcriptRecord struct {
+	Cwd     string `json:"cwd"`
+	Payload struct {
+		Cwd string `json:"cwd"`
+	} `json:"payload"`
+}
+
+// transcriptCwd returns the working directory recorded near the start of
+// a JSON lines transcript, or "" if none is found
+func transcriptCwd(path string) string {
+	f, err := os.Open(path)
+	if err != nil {
+		return ""
+	}
+	defer f.Close()
+
+	dec := json.NewDecoder(f)
+	for i := 0; i < cwdRecords; i++ {
+		var r transcriptRecord
+		if err := dec.Decode(&r); err != nil {
+			return ""
+		}
+		if r.Cwd != "" {
+			return r.Cwd
+		}
+		if r.Payload.Cwd != "" {
+			return r.Payload.Cwd
+		}
+	}
+	return ""
+}
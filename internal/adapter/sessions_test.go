@@ -0,0 +1,121 @@
+package adapter
+
+import (
+	"os"
+	"path/filepath"
+	"sort"
+	"testing"
+	"time"
+
+	"ai-manager/internal/config"
+	"ai-manager/internal/models"
+)
+
+func writeTestFile(t *testing.T, path, data string) {
+	t.Helper()
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(path, []byte(data), 0644); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestClaudeSessions(t *testing.T) {
+	root := t.TempDir()
+	tool := config.Tool{Path: root, DataPath: "transcripts"}
+
+	// A transcript under data_path, not the default projects
+	writeTestFile(t, filepath.Join(root, "transcripts", "-work-app", "s1.jsonl"),
+		`{"type":"summary"}`+"\n"+`{"cwd":"/work/app","type":"user"}`+"\n")
+	writeTestFile(t, filepath.Join(root, "projects", "-work-ignored", "s0.jsonl"), `{"cwd":"/work/ignored"}`+"\n")
+
+	// history.jsonl covers /work/app through its transcript already, and
+	// /work/old.site only through history
+	writeTestFile(t, filepath.Join(root, "history.jsonl"), ``+
+		`{"display":"fix it","timestamp":1760000000000,"project":"/work/app","sessionId":"s1"}`+"\n"+
+		`{"display":"a","timestamp":1750000000000,"project":"/work/old.site","sessionId":"a"}`+"\n"+
+		`{"display":"b","timestamp":1750000100000,"project":"/work/old.site","sessionId":"a"}`+"\n"+
+		`{"display":"c","timestamp":1740000000000,"project":"/work/old.site","sessionId":"b"}`+"\n"+
+		`{"display":"d","timestamp":1730000000000,"project":"/work/older"}`+"\n"+
+		`{"display":"no project","timestamp":1730000000000}`+"\n")
+
+	sessions, err := For("claude").Sessions(tool)
+	if err != nil {
+		t.Fatal(err)
+	}
+	sort.Slice(sessions, func(i, j int) bool { return sessions[i].Updated.After(sessions[j].Updated) })
+
+	type session struct {
+		id, project string
+		updated     time.Time
+	}
+	got := make([]session, 0, len(sessions))
+	for _, s := range sessions {
+		got = append(got, session{s.ID, s.Project, s.Updated})
+	}
+	info, err := os.Stat(filepath.Join(root, "transcripts", "-work-app", "s1.jsonl"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []session{
+		{"s1", "/work/app", info.ModTime()},
+		{"a", "/work/old.site", time.UnixMilli(1750000100000)},
+		{"b", "/work/old.site", time.UnixMilli(1740000000000)},
+		{"history", "/work/older", time.UnixMilli(1730000000000)},
+	}
+	if len(got) != len(want) {
+		t.Fatalf("sessions = %+v, want %+v", got, want)
+	}
+	for i := range want {
+		if got[i].id != want[i].id || got[i].project != want[i].project || !got[i].updated.Equal(want[i].updated) {
+			t.Errorf("session %d = %+v, want %+v", i, got[i], want[i])
+		}
+	}
+}
+
+func TestClaudeSessionsHistoryFallback(t *testing.T) {
+	root := t.TempDir()
+	history := filepath.Join(root, "history.jsonl")
+	writeTestFile(t, history, "not json\n")
+	mtime := time.Now().Add(-48 * time.Hour).Truncate(time.Second)
+	if err := os.Chtimes(history, mtime, mtime); err != nil {
+		t.Fatal(err)
+	}
+
+	sessions, err := For("claude").Sessions(config.Tool{Path: root})
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []models.Session{{Tool: "claude", ID: "history", Path: history, Updated: mtime}}
+	if len(sessions) != 1 || sessions[0] != want[0] {
+		t.Errorf("sessions = %+v, want %+v", sessions, want)
+	}
+
+	// No transcripts and no history: nothing is known
+	if err := os.Remove(history); err != nil {
+		t.Fatal(err)
+	}
+	if sessions, err = For("claude").Sessions(config.Tool{Path: root}); err != nil || len(sessions) != 0 {
+		t.Errorf("sessions = %+v, %v, want none", sessions, err)
+	}
+}
+
+func TestTranscriptCwd(t *testing.T) {
+	dir := t.TempDir()
+	tests := map[string]string{
+		"claude":   `{"type":"summary"}` + "\n" + `{"cwd":"/a"}` + "\n",
+		"codex":    `{"type":"session_meta","payload":{"cwd":"/b"}}` + "\n",
+		"none":     `{"type":"x"}` + "\n",
+		"invalid":  "garbage\n",
+		"too late": "{}\n{}\n{}\n{}\n{}\n{}\n{}\n{}\n{}\n{}\n" + `{"cwd":"/c"}` + "\n",
+	}
+	want := map[string]string{"claude": "/a", "codex": "/b"}
+	for name, data := range tests {
+		path := filepath.Join(dir, name+".jsonl")
+		writeTestFile(t, path, data)
+		if got := transcriptCwd(path); got != want[name] {
+			t.Errorf("transcriptCwd(%s) = %q, want %q", name, got, want[name])
+		}
+	}
+}
@@ -0,0 +1,210 @@
+package adapter
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"ai-manager/internal/config"
+	"ai-manager/internal/safefile"
+
+	"gopkg.in/yaml.v3"
+)
+
+// Environment variables derived from a model's endpoint and ID
+const (
+	EnvBaseURL = "ANTHROPIC_BASE_URL"
+	EnvModel   = "ANTHROPIC_MODEL"
+)
+
+// ModelEnv returns the environment variables a model sets, including the
+// ones derived from its endpoint and model ID
+func ModelEnv(model config.Model) map[string]string {
+	env := make(map[string]string)
+	if model.APIEndpoint != "" {
+		env[EnvBaseURL] = model.APIEndpoint
+	}
+	if model.ModelID != "" {
+		env[EnvModel] = model.ModelID
+	}
+	for k, v := range model.Environment {
+		env[k] = v
+	}
+	return env
+}
+
+// readSettingsFile parses a settings file by its extension: TOML, YAML or
+// JSON, where comments and trailing commas are allowed as in VS Code's
+// settings. A missing or empty file gives empty settings.
+func readSettingsFile(path string) (map[string]interface{}, error) {
+	data, err := os.ReadFile(path)
+	if os.IsNotExist(err) {
+		return map[string]interface{}{}, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	settings := map[string]interface{}{}
+	if len(bytes.TrimSpace(data)) == 0 {
+		return settings, nil
+	}
+
+	switch strings.ToLower(filepath.Ext(path)) {
+	case ".toml":
+		settings, err = parseTOML(data)
+	case ".yaml", ".yml":
+		err = yaml.Unmarshal(data, &settings)
+	default:
+		err = json.Unmarshal(stripJSONC(data), &settings)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse %s: %w", path, err)
+	}
+	return settings, nil
+}
+
+// stripJSONC removes comments and trailing commas from JSON with comments
+func stripJSONC(data []byte) []byte {
+	// Comments first, so a comment can't hide a trailing comma
+	out := make([]byte, 0, len(data))
+	inString := false
+	for i := 0; i < len(data); i++ {
+		c := data[i]
+		switch {
+		case inString:
+			out = append(out, c)
+			if c == '\\' && i+1 < len(data) {
+				i++
+				out = append(out, data[i])
+			} else if c == '"' {
+				inString = false
+			}
+		case c == '"':
+			inString = true
+			out = append(out, c)
+		case c == '/' && i+1 < len(data) && data[i+1] == '/':
+			for i+1 < len(data) && data[i+1] != '\n' {
+				i++
+			}
+		case c == '/' && i+1 < len(data) && data[i+1] == '*':
+			end := bytes.Index(data[i+2:], []byte("*/"))
+			if end < 0 {
+				return out
+			}
+			i += end + 3
+		default:
+			out = append(out, c)
+		}
+	}
+
+	// Then commas followed only by whitespace and a closing bracket
+	clean := out[:0]
+	inString = false
+	for i := 0; i < len(out); i++ {
+		c := out[i]
+		switch {
+		case inString:
+			if c == '\\' && i+1 < len(out) {
+				clean = append(clean, c)
+				i++
+				c = out[i]
+			} else if c == '"' {
+				inString = false
+			}
+		case c == '"':
+			inString = true
+		case c == ',':
+			rest := bytes.TrimLeft(out[i+1:], " \t\r\n")
+			if len(rest) > 0 && (rest[0] == '}' || rest[0] == ']') {
+				continue
+			}
+		}
+		clean = append(clean, c)
+	}
+	return clean
+}
+
+// updateJSONSettings reads a JSON settings file, lets update change it and
+// writes it back, keeping the keys it doesn't touch in place
+func updateJSONSettings(path string, update func(settings *jsonObject) error) error {
+	data, err := os.ReadFile(path)
+	if err != nil && !os.IsNotExist(err) {
+		return err
+	}
+
+	settings, err := parseObject(data)
+	if err != nil {
+		return fmt.Errorf("failed to parse %s: %w", path, err)
+	}
+
+	if err := update(settings); err != nil {
+		return err
+	}
+
+	out, err := settings.Bytes()
+	if err != nil {
+		return err
+	}
+
+	return safefile.WriteFile(path, out, 0644)
+}
+
+func sortedStrings(m map[string]string) []string {
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+// setYAMLString sets a top-level string in a YAML settings file, keeping
+// its comments and key order
+func setYAMLString(path, key, value string) error {
+	data, err := os.ReadFile(path)
+	if err != nil && !os.IsNotExist(err) {
+		return err
+	}
+
+	var doc yaml.Node
+	if err := yaml.Unmarshal(data, &doc); err != nil {
+		return fmt.Errorf("failed to parse %s: %w", path, err)
+	}
+	if doc.Kind == 0 {
+		doc = yaml.Node{Kind: yaml.DocumentNode, Content: []*yaml.Node{{Kind: yaml.MappingNode, Tag: "!!map"}}}
+	}
+	root := doc.Content[0]
+	if root.Kind != yaml.MappingNode {
+		return fmt.Errorf("%s: expected a mapping", path)
+	}
+
+	found := false
+	for i := 0; i+1 < len(root.Content); i += 2 {
+		if root.Content[i].Value == key {
+			root.Content[i+1] = &yaml.Node{Kind: yaml.ScalarNode, Tag: "!!str", Value: value,
+				LineComment: root.Content[i+1].LineComment}
+			found = true
+		}
+	}
+	if !found {
+		root.Content = append(root.Content,
+			&yaml.Node{Kind: yaml.ScalarNode, Tag: "!!str", Value: key},
+			&yaml.Node{Kind: yaml.ScalarNode, Tag: "!!str", Value: value})
+	}
+
+	var buf bytes.Buffer
+	enc := yaml.NewEncoder(&buf)
+	enc.SetIndent(2)
+	if err := enc.Encode(&doc); err != nil {
+		return err
+	}
+	if err := enc.Close(); err != nil {
+		return err
+	}
+	return safefile.WriteFile(path, buf.Bytes(), 0644)
+}
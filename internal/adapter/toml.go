@@ -0,0 +1,622 @@
+package adapter
+
+import (
+	"fmt"
+	"slices"
+	"strconv"
+	"strings"
+)
+
+// This is a small TOML reader and editor for tool settings such as Codex's
+// config.toml. It reads strings, numbers, booleans, arrays and tables;
+// dates are returned as strings. Edits replace or insert single key/value
+// lines, so comments and everything else in the file are left alone.
+
+// tomlStatement is a table header or key/value line found while parsing
+type tomlStatement struct {
+	header  bool
+	table   []string // the [table] the statement is under
+	path    []string // the full path of the key, or of the header
+	start   int
+	end     int    // after the statement's line break
+	comment string // a comment after the statement on its last line
+}
+
+type tomlParser struct {
+	s       string
+	pos     int
+	root    map[string]interface{}
+	table   []string
+	stmts   []tomlStatement
+	comment string // the comment endLine passed
+}
+
+// parseTOML parses a TOML document into nested maps
+func parseTOML(data []byte) (map[string]interface{}, error) {
+	p := &tomlParser{s: string(data), root: map[string]interface{}{}}
+	if err := p.parse(); err != nil {
+		return nil, err
+	}
+	return p.root, nil
+}
+
+func (p *tomlParser) parse() error {
+	for {
+		p.skipBlank()
+		if p.pos >= len(p.s) {
+			return nil
+		}
+
+		start := p.pos
+		if p.s[p.pos] == '[' {
+			if err := p.parseHeader(); err != nil {
+				return p.errorf("%v", err)
+			}
+			if err := p.endLine(); err != nil {
+				return err
+			}
+			p.stmts = append(p.stmts, tomlStatement{header: true, table: p.table,
+				path: p.table, start: start, end: p.pos})
+			continue
+		}
+
+		key, err := p.parseKey()
+		if err != nil {
+			return p.errorf("%v", err)
+		}
+		p.skipSpace()
+		if !p.consume("=") {
+			return p.errorf("expected = after %s", strings.Join(key, "."))
+		}
+		p.skipSpace()
+		value, err := p.parseValue()
+		if err != nil {
+			return p.errorf("%v", err)
+		}
+		if err := p.endLine(); err != nil {
+			return err
+		}
+
+		table, err := tomlTable(p.root, append(append([]string{}, p.table...), key[:len(key)-1]...))
+		if err != nil {
+			return p.errorf("%v", err)
+		}
+		if _, ok := table[key[len(key)-1]]; ok {
+			return p.errorf("duplicate key %s", tomlPath(key))
+		}
+		table[key[len(key)-1]] = value
+
+		full := append(append([]string{}, p.table...), key...)
+		p.stmts = append(p.stmts, tomlStatement{table: p.table,
+			path: full, start: start, end: p.pos, comment: p.comment})
+	}
+}
+
+// parseHeader reads a [table] or [[array table]] header
+func (p *tomlParser) parseHeader() error {
+	array := strings.HasPrefix(p.s[p.pos:], "[[")
+	if array {
+		p.pos += 2
+	} else {
+		p.pos++
+	}
+	p.skipSpace()
+	path, err := p.parseKey()
+	if err != nil {
+		return err
+	}
+	p.skipSpace()
+
+	if array {
+		if !p.consume("]]") {
+			return fmt.Errorf("expected ]]")
+		}
+		parent, err := tomlTable(p.root, path[:len(path)-1])
+		if err != nil {
+			return err
+		}
+		last := path[len(path)-1]
+		list, _ := parent[last].([]interface{})
+		parent[last] = append(list, map[string]interface{}{})
+	} else {
+		if !p.consume("]") {
+			return fmt.Errorf("expected ]")
+		}
+		if _, err := tomlTable(p.root, path); err != nil {
+			return err
+		}
+	}
+	p.table = path
+	return nil
+}
+
+// tomlTable returns the table at path, creating missing ones. For an array
+// of tables, the last one is used.
+func tomlTable(root map[string]interface{}, path []string) (map[string]interface{}, error) {
+	table := root
+	for _, k := range path {
+		switch v := table[k].(type) {
+		case nil:
+			child := map[string]interface{}{}
+			table[k] = child
+			table = child
+		case map[string]interface{}:
+			table = v
+		case []interface{}:
+			last, ok := v[len(v)-1].(map[string]interface{})
+			if !ok {
+				return nil, fmt.Errorf("%s is not a table", k)
+			}
+			table = last
+		default:
+			return nil, fmt.Errorf("%s is not a table", k)
+		}
+	}
+	return table, nil
+}
+
+// parseKey reads a bare, quoted or dotted key
+func (p *tomlParser) parseKey() ([]string, error) {
+	var parts []string
+	for {
+		p.skipSpace()
+		if p.pos >= len(p.s) {
+			return nil, fmt.Errorf("expected a key")
+		}
+
+		switch p.s[p.pos] {
+		case '"':
+			s, err := p.parseBasicString()
+			if err != nil {
+				return nil, err
+			}
+			parts = append(parts, s)
+		case '\'':
+			s, err := p.parseLiteralString()
+			if err != nil {
+				return nil, err
+			}
+			parts = append(parts, s)
+		default:
+			start := p.pos
+			for p.pos < len(p.s) && isBareKeyChar(p.s[p.pos]) {
+				p.pos++
+			}
+			if p.pos == start {
+				return nil, fmt.Errorf("expected a key")
+			}
+			parts = append(parts, p.s[start:p.pos])
+		}
+
+		p.skipSpace()
+		if !p.consume(".") {
+			return parts, nil
+		}
+	}
+}
+
+func isBareKeyChar(c byte) bool {
+	return c >= 'a' && c <= 'z' || c >= 'A' && c <= 'Z' || c >= '0' && c <= '9' || c == '_' || c == '-'
+}
+
+func (p *tomlParser) parseValue() (interface{}, error) {
+	if p.pos >= len(p.s) {
+		return nil, fmt.Errorf("expected a value")
+	}
+
+	switch p.s[p.pos] {
+	case '"':
+		if strings.HasPrefix(p.s[p.pos:], `"""`) {
+			return p.parseMultilineString(`"""`)
+		}
+		return p.parseBasicString()
+	case '\'':
+		if strings.HasPrefix(p.s[p.pos:], "'''") {
+			return p.parseMultilineString("'''")
+		}
+		return p.parseLiteralString()
+	case '[':
+		return p.parseArray()
+	case '{':
+		return p.parseInlineTable()
+	}
+
+	start := p.pos
+	for p.pos < len(p.s) && !strings.ContainsRune(" \t\r\n,]}#", rune(p.s[p.pos])) {
+		p.pos++
+	}
+	// Dates and times may contain a space between date and time
+	if p.pos+1 < len(p.s) && p.s[p.pos] == ' ' && p.pos-start == 10 && p.s[p.pos+1] >= '0' && p.s[p.pos+1] <= '9' {
+		p.pos++
+		for p.pos < len(p.s) && !strings.ContainsRune(" \t\r\n,]}#", rune(p.s[p.pos])) {
+			p.pos++
+		}
+	}
+	token := p.s[start:p.pos]
+
+	switch token {
+	case "":
+		return nil, fmt.Errorf("expected a value")
+	case "true":
+		return true, nil
+	case "false":
+		return false, nil
+	case "inf", "+inf", "-inf", "nan", "+nan", "-nan":
+		return strconv.ParseFloat(strings.TrimPrefix(token, "+"), 64)
+	}
+
+	number := strings.ReplaceAll(token, "_", "")
+	if i, err := strconv.ParseInt(number, 0, 64); err == nil {
+		return i, nil
+	}
+	if f, err := strconv.ParseFloat(number, 64); err == nil {
+		return f, nil
+	}
+	if len(token) >= 8 && (token[2] == ':' || token[4] == '-') {
+		return token, nil // a date or time
+	}
+	return nil, fmt.Errorf("invalid value %q", token)
+}
+
+func (p *tomlParser) parseBasicString() (string, error) {
+	p.pos++ // opening quote
+	var b strings.Builder
+	for p.pos < len(p.s) {
+		c := p.s[p.pos]
+		switch {
+		case c == '"':
+			p.pos++
+			return b.String(), nil
+		case c == '\n':
+			return "", fmt.Errorf("unterminated string")
+		case c == '\\':
+			if err := p.parseEscape(&b); err != nil {
+				return "", err
+			}
+		default:
+			b.WriteByte(c)
+			p.pos++
+		}
+	}
+	return "", fmt.Errorf("unterminated string")
+}
+
+func (p *tomlParser) parseEscape(b *strings.Builder) error {
+	if p.pos+1 >= len(p.s) {
+		return fmt.Errorf("unterminated string")
+	}
+	c := p.s[p.pos+1]
+	p.pos += 2
+	switch c {
+	case 'b':
+		b.WriteByte('\b')
+	case 't':
+		b.WriteByte('\t')
+	case 'n':
+		b.WriteByte('\n')
+	case 'f':
+		b.WriteByte('\f')
+	case 'r':
+		b.WriteByte('\r')
+	case 'e':
+		b.WriteByte(0x1b)
+	case '"', '\\':
+		b.WriteByte(c)
+	case 'u', 'U':
+		n := 4
+		if c == 'U' {
+			n = 8
+		}
+		if p.pos+n > len(p.s) {
+			return fmt.Errorf("invalid unicode escape")
+		}
+		r, err := strconv.ParseUint(p.s[p.pos:p.pos+n], 16, 32)
+		if err != nil {
+			return fmt.Errorf("invalid unicode escape")
+		}
+		b.WriteRune(rune(r))
+		p.pos += n
+	default:
+		return fmt.Errorf("invalid escape \\%c", c)
+	}
+	return nil
+}
+
+func (p *tomlParser) parseLiteralString() (string, error) {
+	p.pos++ // opening quote
+	end := strings.IndexAny(p.s[p.pos:], "'\n")
+	if end < 0 || p.s[p.pos+end] != '\'' {
+		return "", fmt.Errorf("unterminated string")
+	}
+	s := p.s[p.pos : p.pos+end]
+	p.pos += end + 1
+	return s, nil
+}
+
+func (p *tomlParser) parseMultilineString(delim string) (string, error) {
+	p.pos += len(delim)
+	// A line break right after the opening delimiter is trimmed
+	if strings.HasPrefix(p.s[p.pos:], "\r\n") {
+		p.pos += 2
+	} else if strings.HasPrefix(p.s[p.pos:], "\n") {
+		p.pos++
+	}
+
+	var b strings.Builder
+	for p.pos < len(p.s) {
+		if strings.HasPrefix(p.s[p.pos:], delim) {
+			p.pos += len(delim)
+			// Up to two quotes may directly precede the closing delimiter
+			for i := 0; i < 2 && p.pos < len(p.s) && p.s[p.pos] == delim[0]; i++ {
+				b.WriteByte(delim[0])
+				p.pos++
+			}
+			return b.String(), nil
+		}
+
+		c := p.s[p.pos]
+		if c == '\\' && delim == `"""` {
+			// A backslash at the end of a line trims the following whitespace
+			rest := strings.TrimLeft(p.s[p.pos+1:], " \t")
+			if strings.HasPrefix(rest, "\n") || strings.HasPrefix(rest, "\r\n") {
+				p.pos = len(p.s) - len(strings.TrimLeft(rest, " \t\r\n"))
+				continue
+			}
+			if err := p.parseEscape(&b); err != nil {
+				return "", err
+			}
+			continue
+		}
+		b.WriteByte(c)
+		p.pos++
+	}
+	return "", fmt.Errorf("unterminated string")
+}
+
+func (p *tomlParser) parseArray() ([]interface{}, error) {
+	p.pos++ // [
+	list := make([]interface{}, 0)
+	for {
+		p.skipBlank()
+		if p.consume("]") {
+			return list, nil
+		}
+		v, err := p.parseValue()
+		if err != nil {
+			return nil, err
+		}
+		list = append(list, v)
+
+		p.skipBlank()
+		if p.consume("]") {
+			return list, nil
+		}
+		if !p.consume(",") {
+			return nil, fmt.Errorf("expected , or ] in array")
+		}
+	}
+}
+
+func (p *tomlParser) parseInlineTable() (map[string]interface{}, error) {
+	p.pos++ // {
+	table := map[string]interface{}{}
+	p.skipSpace()
+	if p.consume("}") {
+		return table, nil
+	}
+	for {
+		key, err := p.parseKey()
+		if err != nil {
+			return nil, err
+		}
+		p.skipSpace()
+		if !p.consume("=") {
+			return nil, fmt.Errorf("expected = in inline table")
+		}
+		p.skipSpace()
+		v, err := p.parseValue()
+		if err != nil {
+			return nil, err
+		}
+		parent, err := tomlTable(table, key[:len(key)-1])
+		if err != nil {
+			return nil, err
+		}
+		parent[key[len(key)-1]] = v
+
+		p.skipSpace()
+		if p.consume("}") {
+			return table, nil
+		}
+		if !p.consume(",") {
+			return nil, fmt.Errorf("expected , or } in inline table")
+		}
+		p.skipSpace()
+	}
+}
+
+// skipSpace skips spaces and tabs
+func (p *tomlParser) skipSpace() {
+	for p.pos < len(p.s) && (p.s[p.pos] == ' ' || p.s[p.pos] == '\t') {
+		p.pos++
+	}
+}
+
+// skipBlank skips whitespace, line breaks and comments
+func (p *tomlParser) skipBlank() {
+	for p.pos < len(p.s) {
+		switch p.s[p.pos] {
+		case ' ', '\t', '\r', '\n':
+			p.pos++
+		case '#':
+			p.skipComment()
+		default:
+			return
+		}
+	}
+}
+
+func (p *tomlParser) skipComment() {
+	if end := strings.IndexByte(p.s[p.pos:], '\n'); end >= 0 {
+		p.pos += end
+	} else {
+		p.pos = len(p.s)
+	}
+}
+
+// endLine expects only a comment after a statement, and moves past the
+// line break
+func (p *tomlParser) endLine() error {
+	p.skipSpace()
+	p.comment = ""
+	if p.pos < len(p.s) && p.s[p.pos] == '#' {
+		start := p.pos
+		p.skipComment()
+		p.comment = strings.TrimRight(p.s[start:p.pos], "\r")
+	}
+	p.consume("\r")
+	if p.pos < len(p.s) && !p.consume("\n") {
+		return p.errorf("unexpected %q", p.s[p.pos])
+	}
+	return nil
+}
+
+func (p *tomlParser) consume(s string) bool {
+	if strings.HasPrefix(p.s[p.pos:], s) {
+		p.pos += len(s)
+		return true
+	}
+	return false
+}
+
+func (p *tomlParser) errorf(format string, args ...interface{}) error {
+	line := strings.Count(p.s[:p.pos], "\n") + 1
+	return fmt.Errorf("line %d: %s", line, fmt.Sprintf(format, args...))
+}
+
+// setTOML sets key in table (nil for the top level) to an encoded TOML
+// value. Keys are compared by their parts, however they are quoted or
+// dotted in the file. An existing key is replaced in place; a new one is
+// added at the end of its table, which is appended to the document if it
+// doesn't exist. A table defined inline can't be extended this way.
+func setTOML(data []byte, table []string, key, value string) ([]byte, error) {
+	p := &tomlParser{s: string(data), root: map[string]interface{}{}}
+	if err := p.parse(); err != nil {
+		return nil, err
+	}
+	doc := p.s
+	path := append(slices.Clone(table), key)
+
+	// Replace the key where it is, written as it was relative to its table
+	for _, st := range p.stmts {
+		if st.header || !slices.Equal(st.path, path) {
+			continue
+		}
+		line := tomlPath(st.path[len(st.table):]) + " = " + value
+		if st.comment != "" {
+			line += "  " + st.comment
+		}
+		return []byte(doc[:st.start] + line + "\n" + doc[st.end:]), nil
+	}
+
+	for _, st := range p.stmts {
+		if !st.header && hasPrefix(table, st.path) {
+			return nil, fmt.Errorf("%s is set to a value, not a table that can be extended", tomlPath(st.path))
+		}
+	}
+
+	// Otherwise add it after the last statement of its table: its header,
+	// keys under the header, or dotted keys into it from a parent table
+	insert, under := -1, []string(nil)
+	for _, st := range p.stmts {
+		if st.header && slices.Equal(st.path, table) ||
+			!st.header && hasPrefix(table, st.table) && hasPrefix(st.path, table) {
+			insert, under = st.end, st.table
+		}
+	}
+
+	if insert < 0 && len(table) == 0 {
+		// Top-level keys go before the first table
+		insert = len(doc)
+		for _, st := range p.stmts {
+			if st.header {
+				insert = st.start
+				break
+			}
+		}
+		line := tomlKey(key) + " = " + value + "\n"
+		if insert < len(doc) {
+			line += "\n"
+		}
+		return []byte(doc[:insert] + line + doc[insert:]), nil
+	}
+
+	if insert < 0 {
+		var b strings.Builder
+		b.WriteString(doc)
+		if len(doc) > 0 && !strings.HasSuffix(doc, "\n") {
+			b.WriteString("\n")
+		}
+		if len(doc) > 0 {
+			b.WriteString("\n")
+		}
+		fmt.Fprintf(&b, "[%s]\n%s = %s\n", tomlPath(table), tomlKey(key), value)
+		return []byte(b.String()), nil
+	}
+
+	prefix := doc[:insert]
+	if !strings.HasSuffix(prefix, "\n") && len(prefix) > 0 {
+		prefix += "\n"
+	}
+	return []byte(prefix + tomlPath(path[len(under):]) + " = " + value + "\n" + doc[insert:]), nil
+}
+
+// hasPrefix reports whether the key path starts with prefix
+func hasPrefix(path, prefix []string) bool {
+	return len(path) >= len(prefix) && slices.Equal(path[:len(prefix)], prefix)
+}
+
+// tomlPath encodes a key path as a dotted key
+func tomlPath(path []string) string {
+	parts := make([]string, len(path))
+	for i, k := range path {
+		parts[i] = tomlKey(k)
+	}
+	return strings.Join(parts, ".")
+}
+
+// tomlKey quotes a key that isn't a bare key
+func tomlKey(key string) string {
+	for i := 0; i < len(key); i++ {
+		if !isBareKeyChar(key[i]) {
+			return tomlString(key)
+		}
+	}
+	if key == "" {
+		return `""`
+	}
+	return key
+}
+
+// tomlString encodes s as a TOML basic string
+func tomlString(s string) string {
+	var b strings.Builder
+	b.WriteByte('"')
+	for _, r := range s {
+		switch {
+		case r == '"' || r == '\\':
+			b.WriteByte('\\')
+			b.WriteRune(r)
+		case r == '\n':
+			b.WriteString(`\n`)
+		case r == '\t':
+			b.WriteString(`\t`)
+		case r < 0x20 || r == 0x7f:
+			fmt.Fprintf(&b, `\u%04X`, r)
+		default:
+			b.WriteRune(r)
+		}
+	}
+	b.WriteByte('"')
+	return b.String()
+}
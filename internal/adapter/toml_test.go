@@ -0,0 +1,194 @@
+package adapter
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestSetTOML(t *testing.T) {
+	zai := []string{"model_providers", "z.ai"}
+
+	tests := []struct {
+		name  string
+		doc   string
+		table []string
+		key   string
+		value string
+		want  string
+	}{
+		{
+			name:  "empty document",
+			key:   "model",
+			value: `"gpt-5"`,
+			want:  "model = \"gpt-5\"\n",
+		},
+		{
+			name:  "replace top-level key keeping its comment",
+			doc:   "# Codex\nmodel = \"o3\"  # the default\n\n[tui]\nnotifications = true\n",
+			key:   "model",
+			value: `"gpt-5"`,
+			want:  "# Codex\nmodel = \"gpt-5\"  # the default\n\n[tui]\nnotifications = true\n",
+		},
+		{
+			name:  "new top-level key goes before the first table",
+			doc:   "model = \"o3\"\n\n[tui]\nnotifications = true\n",
+			key:   "model_provider",
+			value: `"zai"`,
+			want:  "model = \"o3\"\nmodel_provider = \"zai\"\n\n[tui]\nnotifications = true\n",
+		},
+		{
+			name:  "quoted table header",
+			doc:   "[model_providers.\"z.ai\"]\nname = \"Z\"\nbase_url = \"https://old\"\n",
+			table: zai,
+			key:   "base_url",
+			value: `"https://new"`,
+			want:  "[model_providers.\"z.ai\"]\nname = \"Z\"\nbase_url = \"https://new\"\n",
+		},
+		{
+			name:  "literal quoted and spaced table header",
+			doc:   "[ model_providers . 'z.ai' ]\nname = \"Z\"\n",
+			table: zai,
+			key:   "base_url",
+			value: `"https://new"`,
+			want:  "[ model_providers . 'z.ai' ]\nname = \"Z\"\nbase_url = \"https://new\"\n",
+		},
+		{
+			name:  "new quoted table",
+			doc:   "model = \"o3\"\n",
+			table: zai,
+			key:   "name",
+			value: `"Z"`,
+			want:  "model = \"o3\"\n\n[model_providers.\"z.ai\"]\nname = \"Z\"\n",
+		},
+		{
+			name:  "dotted key at the top level",
+			doc:   "model_providers.\"z.ai\".name = \"Z\"\nmodel_providers.\"z.ai\".base_url = \"https://old\"\n",
+			table: zai,
+			key:   "base_url",
+			value: `"https://new"`,
+			want:  "model_providers.\"z.ai\".name = \"Z\"\nmodel_providers.\"z.ai\".base_url = \"https://new\"\n",
+		},
+		{
+			name:  "new key after a dotted key in a parent table",
+			doc:   "[model_providers]\n\"z.ai\".name = \"Z\"\n\n[tui]\nnotifications = true\n",
+			table: zai,
+			key:   "base_url",
+			value: `"https://new"`,
+			want:  "[model_providers]\n\"z.ai\".name = \"Z\"\n\"z.ai\".base_url = \"https://new\"\n\n[tui]\nnotifications = true\n",
+		},
+		{
+			name:  "keys of a subtable stay under it",
+			doc:   "[model_providers.\"z.ai\"]\nname = \"Z\"\n\n[model_providers.\"z.ai\".http_headers]\nX-Id = \"1\"\n",
+			table: zai,
+			key:   "base_url",
+			value: `"https://new"`,
+			want:  "[model_providers.\"z.ai\"]\nname = \"Z\"\nbase_url = \"https://new\"\n\n[model_providers.\"z.ai\".http_headers]\nX-Id = \"1\"\n",
+		},
+		{
+			name:  "comments elsewhere are kept",
+			doc:   "# top\nmodel = \"o3\" # inline\n\n# providers\n[model_providers.zai] # header\n# inside\nname = \"Z\"\n",
+			table: []string{"model_providers", "zai"},
+			key:   "name",
+			value: `"GLM"`,
+			want:  "# top\nmodel = \"o3\" # inline\n\n# providers\n[model_providers.zai] # header\n# inside\nname = \"GLM\"\n",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := setTOML([]byte(tt.doc), tt.table, tt.key, tt.value)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if string(got) != tt.want {
+				t.Fatalf("setTOML =\n%s\nwant\n%s", got, tt.want)
+			}
+
+			// Setting the same value again changes nothing
+			again, err := setTOML(got, tt.table, tt.key, tt.value)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if string(again) != string(got) {
+				t.Errorf("setting again =\n%s\nwant\n%s", again, got)
+			}
+
+			if _, err := parseTOML(got); err != nil {
+				t.Errorf("result doesn't parse: %v", err)
+			}
+		})
+	}
+}
+
+func TestSetTOMLInlineTable(t *testing.T) {
+	doc := "model_providers = { \"z.ai\" = { name = \"Z\" } }\n"
+	if _, err := setTOML([]byte(doc), []string{"model_providers", "z.ai"}, "base_url", `"https://new"`); err == nil {
+		t.Error("setTOML extended an inline table")
+	}
+
+	// The inline table itself can be replaced as a whole
+	got, err := setTOML([]byte(doc), nil, "model_providers", `{ zai = { name = "GLM" } }`)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := "model_providers = { zai = { name = \"GLM\" } }\n"; string(got) != want {
+		t.Errorf("setTOML = %q, want %q", got, want)
+	}
+}
+
+func TestParseTOML(t *testing.T) {
+	doc := `# settings
+model = "o3"
+approvals = ['a', "b"]
+retries = 3
+ratio = 0.5
+enabled = true
+notes = """
+two
+lines"""
+
+[model_providers."z.ai"]
+name = "Z"
+headers = { "X-Id" = "1", nested.key = 2 }
+
+[[profiles]]
+name = "one"
+
+[[profiles]]
+name = "two"
+`
+	got, err := parseTOML([]byte(doc))
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := map[string]interface{}{
+		"model":     "o3",
+		"approvals": []interface{}{"a", "b"},
+		"retries":   int64(3),
+		"ratio":     0.5,
+		"enabled":   true,
+		"notes":     "two\nlines",
+		"model_providers": map[string]interface{}{
+			"z.ai": map[string]interface{}{
+				"name": "Z",
+				"headers": map[string]interface{}{
+					"X-Id":   "1",
+					"nested": map[string]interface{}{"key": int64(2)},
+				},
+			},
+		},
+		"profiles": []interface{}{
+			map[string]interface{}{"name": "one"},
+			map[string]interface{}{"name": "two"},
+		},
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("parseTOML =\n%#v\nwant\n%#v", got, want)
+	}
+
+	for _, bad := range []string{"model = ", "[unclosed", "a = 1\na = 2\n", "x = \"open"} {
+		if _, err := parseTOML([]byte(bad)); err == nil {
+			t.Errorf("parseTOML(%q) succeeded", bad)
+		}
+	}
+}
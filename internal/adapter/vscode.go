@@ -0,0 +1,13 @@
+package adapter
+
+// VS Code, its Insiders build and VSCodium keep their settings and
+// workspace storage in their application data directories and their
+// extensions in a dot directory
+func init() {
+	Register(&editor{base: base{key: "vscode", binary: "code"}, dotDirs: []string{"~/.vscode"}},
+		editorTool("VSCode", "Code"))
+	Register(&editor{base: base{key: "vscode-insiders", binary: "code-insiders"}, dotDirs: []string{"~/.vscode-insiders"}},
+		editorTool("VSCode Insiders", "Code - Insiders"))
+	Register(&editor{base: base{key: "vscodium", binary: "codium"}, dotDirs: []string{"~/.vscode-oss"}},
+		editorTool("VSCodium", "VSCodium"))
+}
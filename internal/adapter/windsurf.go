@@ -0,0 +1,9 @@
+package adapter
+
+// Windsurf keeps its settings in the usual VS Code places, its extensions
+// in ~/.windsurf and its Cascade memories and MCP config in
+// ~/.codeium/windsurf
+func init() {
+	Register(&editor{base: base{key: "windsurf", binary: "windsurf"}, dotDirs: []string{"~/.windsurf", "~/.codeium/windsurf"}},
+		editorTool("Windsurf", "Windsurf"))
+}
@@ -0,0 +1,310 @@
+package cleanup
+
+import (
+	"archive/tar"
+	"compress/gzip"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path"
+	"path/filepath"
+	"time"
+
+	"ai-manager/internal/config"
+	"ai-manager/internal/models"
+	"ai-manager/internal/safefile"
+)
+
+// ArchiveDirName is the directory in the state directory that holds the
+// tar.gz archives written by the archive action, with an index.json
+const ArchiveDirName = "archive"
+
+// Archive stores files packed by cleanup for later extraction
+type Archive struct {
+	dir string
+}
+
+// NewArchive creates the archive for the configured state directory
+func NewArchive(cfg *config.Config) *Archive {
+	return &Archive{dir: filepath.Join(cfg.StateDir(), ArchiveDirName)}
+}
+
+// Dir returns the archive directory
+func (a *Archive) Dir() string {
+	return a.dir
+}
+
+func (a *Archive) indexPath() string {
+	return filepath.Join(a.dir, "index.json")
+}
+
+// List returns the archives in the index, oldest first
+func (a *Archive) List() ([]models.ArchiveEntry, error) {
+	data, err := os.ReadFile(a.indexPath())
+	if os.IsNotExist(err) {
+		return []models.ArchiveEntry{}, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	var entries []models.ArchiveEntry
+	if err := json.Unmarshal(data, &entries); err != nil {
+		return nil, fmt.Errorf("failed to parse %s: %w", a.indexPath(), err)
+	}
+	return entries, nil
+}
+
+// Get returns the index entry of an archive by file name
+func (a *Archive) Get(name string) (models.ArchiveEntry, error) {
+	entries, err := a.List()
+	if err != nil {
+		return models.ArchiveEntry{}, err
+	}
+	for _, e := range entries {
+		if e.Name == name || e.Name == name+".tar.gz" {
+			return e, nil
+		}
+	}
+	return models.ArchiveEntry{}, fmt.Errorf("archive %s not found", name)
+}
+
+func (a *Archive) saveIndex(entries []models.ArchiveEntry) error {
+	data, err := json.MarshalIndent(entries, "", "  ")
+	if err != nil {
+		return err
+	}
+	return safefile.WriteFile(a.indexPath(), append(data, '\n'), 0600)
+}
+
+// Pack writes the planned files of one tool into a new dated tar.gz and
+// records it in the index. The originals are left in place; the caller
+// removes them once Pack succeeds.
+func (a *Archive) Pack(tool string, items []PlanItem) (models.ArchiveEntry, error) {
+	if err := os.MkdirAll(a.dir, 0700); err != nil {
+		return models.ArchiveEntry{}, err
+	}
+
+	now := time.Now()
+	f, name, err := a.create(now, tool)
+	if err != nil {
+		return models.ArchiveEntry{}, err
+	}
+
+	entry := models.ArchiveEntry{
+		Name:    name,
+		Path:    filepath.Join(a.dir, name),
+		Tool:    tool,
+		Created: now,
+		Files:   make([]models.ArchivedFile, 0, len(items)),
+	}
+
+	if err := writeTar(f, tool, items, &entry); err != nil {
+		f.Close()
+		os.Remove(entry.Path)
+		return models.ArchiveEntry{}, err
+	}
+	if err := f.Sync(); err != nil {
+		f.Close()
+		os.Remove(entry.Path)
+		return models.ArchiveEntry{}, err
+	}
+	if err := f.Close(); err != nil {
+		os.Remove(entry.Path)
+		return models.ArchiveEntry{}, err
+	}
+
+	info, err := os.Stat(entry.Path)
+	if err != nil {
+		return models.ArchiveEntry{}, err
+	}
+	entry.Size = info.Size()
+
+	entries, err := a.List()
+	if err != nil {
+		os.Remove(entry.Path)
+		return models.ArchiveEntry{}, err
+	}
+	if err := a.saveIndex(append(entries, entry)); err != nil {
+		os.Remove(entry.Path)
+		return models.ArchiveEntry{}, err
+	}
+	return entry, nil
+}
+
+// create opens a new archive file named after the date and tool
+func (a *Archive) create(now time.Time, tool string) (*os.File, string, error) {
+	base := now.Format("2006-01-02") + "-" + tool
+	for n := 0; ; n++ {
+		name := base + ".tar.gz"
+		if n > 0 {
+			name = fmt.Sprintf("%s-%d.tar.gz", base, n)
+		}
+
+		f, err := os.OpenFile(filepath.Join(a.dir, name), os.O_WRONLY|os.O_CREATE|os.O_EXCL, 0600)
+		if errors.Is(err, os.ErrExist) {
+			continue
+		}
+		return f, name, err
+	}
+}
+
+// writeTar packs items into w, naming each file <tool>/<path in tool dir>
+func writeTar(w io.Writer, tool string, items []PlanItem, entry *models.ArchiveEntry) error {
+	zw := gzip.NewWriter(w)
+	tw := tar.NewWriter(zw)
+
+	for _, item := range items {
+		rel, err := filepath.Rel(item.Root, item.Path)
+		if err != nil {
+			return err
+		}
+		name := path.Join(tool, filepath.ToSlash(rel))
+
+		if err := addFile(tw, item.Path, name); err != nil {
+			return err
+		}
+		entry.Files = append(entry.Files, models.ArchivedFile{
+			Name:         name,
+			OriginalPath: item.Path,
+			Size:         item.Size,
+			ModTime:      item.ModTime,
+		})
+		entry.OriginalSize += item.Size
+	}
+
+	if err := tw.Close(); err != nil {
+		return err
+	}
+	return zw.Close()
+}
+
+// addFile writes one regular file to a tar stream
+func addFile(tw *tar.Writer, src, name string) error {
+	info, err := os.Lstat(src)
+	if err != nil {
+		return err
+	}
+	if !info.Mode().IsRegular() {
+		return fmt.Errorf("%s: not a regular file", src)
+	}
+
+	hdr, err := tar.FileInfoHeader(info, "")
+	if err != nil {
+		return err
+	}
+	hdr.Name = name
+
+	f, err := os.Open(src)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+
+	if err := tw.WriteHeader(hdr); err != nil {
+		return err
+	}
+	_, err = io.CopyN(tw, f, hdr.Size)
+	return err
+}
+
+// Extract unpacks files from an archive. Without dest, each file goes back
+// to its original path; otherwise it is written below dest under its name
+// in the archive. Only the named files are extracted if names is not
+// empty. Existing files are only replaced with force.
+func (a *Archive) Extract(name, dest string, names []string, force bool) ([]string, error) {
+	entry, err := a.Get(name)
+	if err != nil {
+		return nil, err
+	}
+
+	targets := make(map[string]string, len(entry.Files))
+	for _, f := range entry.Files {
+		if dest == "" {
+			targets[f.Name] = f.OriginalPath
+			continue
+		}
+		target := filepath.Join(dest, filepath.FromSlash(f.Name))
+		if !within(dest, target) {
+			return nil, fmt.Errorf("%s: invalid name in archive index", f.Name)
+		}
+		targets[f.Name] = target
+	}
+
+	wanted := make(map[string]bool, len(names))
+	for _, n := range names {
+		if _, ok := targets[n]; !ok {
+			return nil, fmt.Errorf("%s is not in %s", n, entry.Name)
+		}
+		wanted[n] = true
+	}
+
+	f, err := os.Open(entry.Path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	zr, err := gzip.NewReader(f)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", entry.Name, err)
+	}
+	tr := tar.NewReader(zr)
+
+	extracted := make([]string, 0)
+	for {
+		hdr, err := tr.Next()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return extracted, fmt.Errorf("%s: %w", entry.Name, err)
+		}
+
+		// Only files recorded in the index are written, so a damaged
+		// archive can't place files elsewhere
+		target, ok := targets[hdr.Name]
+		if !ok || hdr.Typeflag != tar.TypeReg || (len(wanted) > 0 && !wanted[hdr.Name]) {
+			continue
+		}
+		if err := extractFile(tr, hdr, target, force); err != nil {
+			return extracted, err
+		}
+		extracted = append(extracted, target)
+	}
+	return extracted, nil
+}
+
+// extractFile writes one tar entry to target, keeping its mode and time
+func extractFile(r io.Reader, hdr *tar.Header, target string, force bool) error {
+	if _, err := os.Lstat(target); err == nil && !force {
+		return fmt.Errorf("%s already exists (use --force to overwrite)", target)
+	}
+	if err := os.MkdirAll(filepath.Dir(target), 0755); err != nil {
+		return err
+	}
+
+	tmp, err := os.CreateTemp(filepath.Dir(target), "."+filepath.Base(target)+".tmp-*")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tmp.Name()) // no-op after a successful rename
+
+	if _, err := io.Copy(tmp, r); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := tmp.Chmod(os.FileMode(hdr.Mode).Perm()); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		return err
+	}
+	if err := os.Chtimes(tmp.Name(), hdr.ModTime, hdr.ModTime); err != nil {
+		return err
+	}
+	return os.Rename(tmp.Name(), target)
+}
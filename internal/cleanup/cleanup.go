@@ -1,141 +1,92 @@
 package cleanup
 
 import (
+	"context"
+	"fmt"
 	"os"
 	"path/filepath"
 	"strings"
-	"time"
 
 	"ai-manager/internal/config"
 	"ai-manager/internal/models"
+	"ai-manager/internal/utils"
 )
 
 // Cleaner handles cleanup of temporary files
 type Cleaner struct {
-	cfg *config.Config
+	cfg     *config.Config
+	trash   *Trash
+	procs   *procUsage
+	maxSize string
 }
 
-// NewCleaner creates a new cleanup handler
+// NewCleaner creates a new cleanup handler. Cleaned files are moved to the
+// trash rather than deleted.
 func NewCleaner(cfg *config.Config) *Cleaner {
-	return &Cleaner{cfg: cfg}
+	return &Cleaner{cfg: cfg, trash: NewTrash(cfg)}
 }
 
-// CleanupAll runs cleanup for all enabled tools
-func (c *Cleaner) CleanupAll() ([]models.CleanupResult, error) {
-	results := make([]models.CleanupResult, 0)
-
-	for key, tool := range c.cfg.Tools {
-		if !tool.Enabled {
-			continue
-		}
-
-		result := c.CleanupTool(key, tool)
-		results = append(results, result)
+// SetMaxSize trims every tool to at most size, e.g. "2GiB", in place of
+// its quota
+func (c *Cleaner) SetMaxSize(size string) error {
+	if _, err := utils.ParseSize(size); err != nil {
+		return err
 	}
+	c.maxSize = size
+	return nil
+}
 
-	return results, nil
+// Trash returns the trash that cleaned files are moved to
+func (c *Cleaner) Trash() *Trash {
+	return c.trash
 }
 
-// CleanupTool cleans temporary files for a specific tool
-func (c *Cleaner) CleanupTool(key string, tool config.Tool) models.CleanupResult {
-	result := models.CleanupResult{
-		Tool:     tool.Name,
-		Duration: time.Duration(0),
+// CleanupAll runs cleanup for all enabled tools
+func (c *Cleaner) CleanupAll(ctx context.Context) ([]models.CleanupResult, error) {
+	plan, err := c.BuildPlan(ctx)
+	if err != nil {
+		return nil, err
 	}
 
-	home, _ := os.UserHomeDir()
-	basePath := expandPath(tool.Path, home)
+	// An interrupted cleanup still reports what it did
+	applied, err := c.ApplyPlan(ctx, plan)
 
-	for _, tempPath := range tool.TempPaths {
-		fullPath := filepath.Join(basePath, tempPath)
-
-		// Check if path exists
-		if _, err := os.Stat(fullPath); os.IsNotExist(err) {
-			continue
-		}
-
-		// Count and delete old files
-		deleted, freed := c.cleanPath(fullPath, c.cfg.Retention.TempFiles)
-		result.FilesDeleted += deleted
-		result.SpaceFreed += freed
-		result.Path = fullPath
+	byName := make(map[string]models.CleanupResult, len(applied))
+	for _, r := range applied {
+		byName[r.Tool] = r
 	}
 
-	return result
-}
-
-// cleanPath removes files older than specified days
-func (c *Cleaner) cleanPath(path string, days int) (int, int64) {
-	var deleted int
-	var freed int64
-
-	cutoff := time.Now().AddDate(0, 0, -days)
-
-	filepath.Walk(path, func(filePath string, info os.FileInfo, err error) error {
-		if err != nil {
-			return nil // Skip errors
+	// Report every enabled tool, including those with nothing to remove
+	results := make([]models.CleanupResult, 0)
+	for _, key := range sortedKeys(c.cfg.Tools) {
+		tool := c.cfg.Tools[key]
+		if !tool.Enabled {
+			continue
 		}
-
-		if !info.IsDir() && info.ModTime().Before(cutoff) {
-			size := info.Size()
-			if err := os.Remove(filePath); err == nil {
-				deleted++
-				freed += size
-			}
+		result, ok := byName[tool.Name]
+		if !ok {
+			result = models.CleanupResult{Tool: tool.Name, Path: toolRoot(tool)}
 		}
-
-		return nil
-	})
-
-	return deleted, freed
-}
-
-// CleanupGemini is a simplified cleanup for Gemini specifically
-func (c *Cleaner) CleanupGemini() (models.CleanupResult, error) {
-	home, _ := os.UserHomeDir()
-	geminiPath := filepath.Join(home, ".gemini", "tmp")
-
-	result := models.CleanupResult{
-		Tool: "Gemini",
-		Path: geminiPath,
-	}
-
-	if _, err := os.Stat(geminiPath); os.IsNotExist(err) {
-		return result, nil
+		result.Warnings = plan.Warnings[key]
+		results = append(results, result)
 	}
 
-	deleted, freed := c.cleanPath(geminiPath, c.cfg.Retention.TempFiles)
-	result.FilesDeleted = deleted
-	result.SpaceFreed = freed
-	return result, nil
+	return results, err
 }
 
-// CleanupClaude is a simplified cleanup for Claude Code specifically
-func (c *Cleaner) CleanupClaude() (models.CleanupResult, error) {
-	home, _ := os.UserHomeDir()
-
-	result := models.CleanupResult{
-		Tool:     "Claude Code",
-		Duration: time.Duration(0),
-	}
-
-	// Clean debug directory
-	debugPath := filepath.Join(home, ".claude", "debug")
-	if _, err := os.Stat(debugPath); err == nil {
-		deleted, freed := c.cleanPath(debugPath, c.cfg.Retention.DebugLogs)
-		result.FilesDeleted += deleted
-		result.SpaceFreed += freed
+// CleanupTool cleans temporary files for a specific tool
+func (c *Cleaner) CleanupTool(ctx context.Context, key string, tool config.Tool) models.CleanupResult {
+	items, warnings, err := c.planTool(ctx, key, tool)
+	if err != nil {
+		return models.CleanupResult{Tool: tool.Name, Error: err}
 	}
 
-	// Clean shell-snapshots
-	snapshotsPath := filepath.Join(home, ".claude", "shell-snapshots")
-	if _, err := os.Stat(snapshotsPath); err == nil {
-		deleted, freed := c.cleanPath(snapshotsPath, c.cfg.Retention.ShellSnapshots)
-		result.FilesDeleted += deleted
-		result.SpaceFreed += freed
+	result := models.CleanupResult{Tool: tool.Name, Path: toolRoot(tool)}
+	if results := c.apply(ctx, items); len(results) > 0 {
+		result = results[0]
 	}
-
-	return result, nil
+	result.Warnings = warnings
+	return result
 }
 
 // expandPath expands ~ to home directory
@@ -151,7 +102,20 @@ func FormatResult(r models.CleanupResult) string {
 	if r.Error != nil {
 		return "[Error] " + r.Error.Error()
 	}
-	return "[Done] " + r.Tool + ": " +
-		models.FormatBytes(r.SpaceFreed) + " freed, " +
-		string(rune('0'+r.FilesDeleted%10)) + " files deleted"
+	line := fmt.Sprintf("[Done] %s: %s freed, %d files deleted",
+		r.Tool, models.FormatBytes(r.SpaceFreed), r.FilesDeleted)
+	if r.FilesTrashed > 0 {
+		line += fmt.Sprintf(" (%d moved to trash)", r.FilesTrashed)
+	}
+	if r.FilesCompressed > 0 || r.FilesArchived > 0 {
+		line += fmt.Sprintf(", %d compressed, %d archived, %s saved",
+			r.FilesCompressed, r.FilesArchived, models.FormatBytes(r.SpaceSaved))
+	}
+	if r.QuotaFreed > 0 {
+		line += fmt.Sprintf(", %s to fit the quota", models.FormatBytes(r.QuotaFreed))
+	}
+	if r.FilesSkipped > 0 {
+		line += fmt.Sprintf(", %d skipped", r.FilesSkipped)
+	}
+	return line
 }
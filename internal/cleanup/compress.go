@@ -0,0 +1,77 @@
+package cleanup
+
+import (
+	"compress/gzip"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+)
+
+// compressFile replaces path with a gzip-compressed path.gz that keeps its
+// mode and modification time, and returns the bytes saved
+func compressFile(path string) (int64, error) {
+	info, err := os.Lstat(path)
+	if err != nil {
+		return 0, err
+	}
+
+	if !info.Mode().IsRegular() {
+		return 0, fmt.Errorf("%s: not a regular file", path)
+	}
+
+	dst := path + ".gz"
+	if _, err := os.Lstat(dst); err == nil {
+		return 0, fmt.Errorf("%s already exists", dst)
+	}
+
+	in, err := os.Open(path)
+	if err != nil {
+		return 0, err
+	}
+	defer in.Close()
+
+	tmp, err := os.CreateTemp(filepath.Dir(path), "."+filepath.Base(dst)+".tmp-*")
+	if err != nil {
+		return 0, err
+	}
+	defer os.Remove(tmp.Name()) // no-op after a successful rename
+
+	zw := gzip.NewWriter(tmp)
+	zw.Name = filepath.Base(path)
+	zw.ModTime = info.ModTime()
+	if _, err := io.Copy(zw, in); err != nil {
+		tmp.Close()
+		return 0, err
+	}
+	if err := zw.Close(); err != nil {
+		tmp.Close()
+		return 0, err
+	}
+	if err := tmp.Chmod(info.Mode().Perm()); err != nil {
+		tmp.Close()
+		return 0, err
+	}
+	if err := tmp.Sync(); err != nil {
+		tmp.Close()
+		return 0, err
+	}
+	if err := tmp.Close(); err != nil {
+		return 0, err
+	}
+
+	compressed, err := os.Stat(tmp.Name())
+	if err != nil {
+		return 0, err
+	}
+	if err := os.Chtimes(tmp.Name(), info.ModTime(), info.ModTime()); err != nil {
+		return 0, err
+	}
+	if err := os.Rename(tmp.Name(), dst); err != nil {
+		return 0, err
+	}
+	if err := os.Remove(path); err != nil {
+		return 0, err
+	}
+	return info.Size() - compressed.Size(), nil
+}
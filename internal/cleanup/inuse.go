@@ -0,0 +1,80 @@
+package cleanup
+
+import (
+	"path/filepath"
+	"strings"
+
+	"ai-manager/internal/adapter"
+)
+
+// openFile is a file held open by a running AI tool
+type openFile struct {
+	PID   int
+	Tool  string
+	Write bool
+}
+
+// procDir is the working directory of a running AI tool
+type procDir struct {
+	PID  int
+	Tool string
+	Dir  string
+}
+
+// procUsage records which files and directories running AI tools use
+type procUsage struct {
+	files map[string]openFile
+	cwds  []procDir
+}
+
+// openBy returns the process holding path open, if any
+func (u *procUsage) openBy(path string) (openFile, bool) {
+	f, ok := u.files[filepath.Clean(path)]
+	return f, ok
+}
+
+// openIn returns a process holding a file below dir open, if any
+func (u *procUsage) openIn(dir string) (openFile, bool) {
+	for path, f := range u.files {
+		if within(dir, path) {
+			return f, true
+		}
+	}
+	return openFile{}, false
+}
+
+// activeIn reports a process of tool that works in dir or writes to a
+// file below it
+func (u *procUsage) activeIn(tool, dir string) (int, bool) {
+	for path, f := range u.files {
+		if f.Tool == tool && f.Write && within(dir, path) {
+			return f.PID, true
+		}
+	}
+	for _, d := range u.cwds {
+		if d.Tool == tool && within(dir, d.Dir) {
+			return d.PID, true
+		}
+	}
+	return 0, false
+}
+
+// within reports whether path is dir or lies below it
+func within(dir, path string) bool {
+	rel, err := filepath.Rel(dir, path)
+	return err == nil && rel != ".." && !strings.HasPrefix(rel, "../")
+}
+
+// processes returns what the running AI tools use, scanning once per Cleaner
+func (c *Cleaner) processes() *procUsage {
+	if c.procs == nil {
+		bins := make(map[string]string)
+		for key, tool := range c.cfg.Tools {
+			if tool.Enabled {
+				bins[adapter.For(key).Binary()] = key
+			}
+		}
+		c.procs = scanProcesses(bins)
+	}
+	return c.procs
+}
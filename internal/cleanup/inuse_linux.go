@@ -0,0 +1,108 @@
+//go:build linux
+
+package cleanup
+
+import (
+	"bufio"
+	"bytes"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+)
+
+// interpreters run AI tools installed as scripts, e.g. node for npm packages
+var interpreters = map[string]bool{"node": true, "bun": true, "deno": true, "python": true, "python3": true}
+
+// scanProcesses looks through /proc for processes of the tools in bins
+// (binary name -> tool key) and records their open files and working
+// directories. Processes of other users can't be read and are ignored.
+func scanProcesses(bins map[string]string) *procUsage {
+	usage := &procUsage{files: make(map[string]openFile)}
+
+	entries, err := os.ReadDir("/proc")
+	if err != nil {
+		return usage
+	}
+
+	self := os.Getpid()
+	for _, e := range entries {
+		pid, err := strconv.Atoi(e.Name())
+		if err != nil || pid == self {
+			continue
+		}
+
+		proc := filepath.Join("/proc", e.Name())
+		tool := processTool(proc, bins)
+		if tool == "" {
+			continue
+		}
+
+		if cwd, err := os.Readlink(filepath.Join(proc, "cwd")); err == nil {
+			usage.cwds = append(usage.cwds, procDir{PID: pid, Tool: tool, Dir: cwd})
+		}
+
+		fds, err := os.ReadDir(filepath.Join(proc, "fd"))
+		if err != nil {
+			continue
+		}
+		for _, fd := range fds {
+			target, err := os.Readlink(filepath.Join(proc, "fd", fd.Name()))
+			if err != nil || !strings.HasPrefix(target, "/") {
+				continue // sockets, pipes and the like
+			}
+			usage.files[filepath.Clean(target)] = openFile{
+				PID:   pid,
+				Tool:  tool,
+				Write: fdWritable(filepath.Join(proc, "fdinfo", fd.Name())),
+			}
+		}
+	}
+	return usage
+}
+
+// processTool returns the tool key of a process, or "" if it isn't one
+func processTool(proc string, bins map[string]string) string {
+	data, err := os.ReadFile(filepath.Join(proc, "cmdline"))
+	if err != nil || len(data) == 0 {
+		return ""
+	}
+
+	args := strings.Split(strings.TrimRight(string(data), "\x00"), "\x00")
+	name := filepath.Base(args[0])
+	if tool, ok := bins[name]; ok {
+		return tool
+	}
+
+	// Script installs show up as e.g. "node /usr/local/bin/claude"
+	if interpreters[name] && len(args) > 1 {
+		if tool, ok := bins[filepath.Base(args[1])]; ok {
+			return tool
+		}
+	}
+	return ""
+}
+
+// fdWritable reports whether a file descriptor was opened for writing,
+// from the flags line of its fdinfo
+func fdWritable(fdinfo string) bool {
+	f, err := os.Open(fdinfo)
+	if err != nil {
+		return false
+	}
+	defer f.Close()
+
+	scanner := bufio.NewScanner(f)
+	for scanner.Scan() {
+		value, ok := bytes.CutPrefix(scanner.Bytes(), []byte("flags:"))
+		if !ok {
+			continue
+		}
+		flags, err := strconv.ParseUint(strings.TrimSpace(string(value)), 8, 64)
+		if err != nil {
+			return false
+		}
+		return flags&uint64(os.O_WRONLY|os.O_RDWR) != 0
+	}
+	return false
+}
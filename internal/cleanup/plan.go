@@ -0,0 +1,365 @@
+package cleanup
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	"ai-manager/internal/config"
+	"ai-manager/internal/models"
+	"ai-manager/internal/safefile"
+	"ai-manager/internal/utils"
+)
+
+// PlanItem is a single file selected for cleanup
+type PlanItem struct {
+	Path     string    `json:"path"`
+	Tool     string    `json:"tool"`
+	ToolName string    `json:"tool_name"`
+	Root     string    `json:"root"`
+	Rule     string    `json:"rule"`
+	Reason   string    `json:"reason"`
+	Action   string    `json:"action"`
+	Size     int64     `json:"size"`
+	ModTime  time.Time `json:"mod_time"`
+	AgeDays  int       `json:"age_days"`
+	Quota    bool      `json:"quota,omitempty"`
+}
+
+// Plan is the full list of files a cleanup would remove. It can be saved,
+// reviewed and applied later.
+type Plan struct {
+	Created  time.Time           `json:"created"`
+	Items    []PlanItem          `json:"items"`
+	Warnings map[string][]string `json:"warnings,omitempty"`
+}
+
+// TotalSize returns the combined size of every planned file
+func (p *Plan) TotalSize() int64 {
+	var total int64
+	for _, item := range p.Items {
+		total += item.Size
+	}
+	return total
+}
+
+// BuildPlan selects the files that cleanup would remove from every enabled
+// tool without touching anything
+func (c *Cleaner) BuildPlan(ctx context.Context) (*Plan, error) {
+	plan := &Plan{Created: time.Now(), Items: make([]PlanItem, 0)}
+
+	for _, key := range sortedKeys(c.cfg.Tools) {
+		tool := c.cfg.Tools[key]
+		if !tool.Enabled {
+			continue
+		}
+		items, warnings, err := c.planTool(ctx, key, tool)
+		if err != nil {
+			return nil, fmt.Errorf("%s: %w", key, err)
+		}
+		plan.Items = append(plan.Items, items...)
+		if len(warnings) > 0 {
+			if plan.Warnings == nil {
+				plan.Warnings = make(map[string][]string)
+			}
+			plan.Warnings[key] = warnings
+		}
+	}
+
+	return plan, nil
+}
+
+// planTool evaluates a tool's cleanup rules against its directory. Files
+// held open by running AI tools are left out, and the whole tool is skipped
+// while one of its sessions is active in a directory its rules cover. When
+// the tool has a quota, or SetMaxSize was called, the oldest files its
+// rules cover are added until its measured usage fits.
+func (c *Cleaner) planTool(ctx context.Context, key string, tool config.Tool) ([]PlanItem, []string, error) {
+	rules, err := c.rulesFor(key, tool)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	now := time.Now()
+	root := toolRoot(tool)
+
+	procs := c.processes()
+	for _, dir := range ruleDirs(root, rules) {
+		if pid, ok := procs.activeIn(key, dir); ok {
+			return nil, []string{fmt.Sprintf("%s skipped: an active session (pid %d) is using %s", tool.Name, pid, dir)}, nil
+		}
+	}
+
+	files, err := collect(ctx, root, rules)
+	if err != nil {
+		return nil, nil, err
+	}
+	selected, spare := evaluate(rules, files, now)
+
+	var warnings []string
+	var inUse int
+	selected, inUse = c.notOpen(selected, inUse)
+	spare, inUse = c.notOpen(spare, inUse)
+	if inUse > 0 {
+		warnings = append(warnings, fmt.Sprintf("%s: %d files in use by running AI tools were left alone", tool.Name, inUse))
+	}
+
+	limit := tool.Quota
+	if c.maxSize != "" {
+		limit = c.maxSize
+	}
+	if limit != "" {
+		quota, err := utils.ParseSize(limit)
+		if err != nil {
+			return nil, nil, err
+		}
+
+		usage, err := models.CalculateDiskUsage(ctx, root)
+		if err != nil {
+			return nil, nil, err
+		}
+		remaining := usage.SizeBytes
+		for _, s := range selected {
+			remaining -= s.info.Size()
+		}
+
+		if remaining > quota {
+			picked, freed := fitQuota(spare, remaining-quota, quota)
+			selected = append(selected, picked...)
+			if remaining-freed > quota {
+				warnings = append(warnings, fmt.Sprintf("%s stays %s over its %s quota: no more files are covered by its cleanup rules",
+					tool.Name, models.FormatBytes(remaining-freed-quota), models.FormatBytes(quota)))
+			}
+		}
+	}
+
+	items := make([]PlanItem, 0, len(selected))
+	for _, s := range selected {
+		r := rules[s.rule]
+		items = append(items, PlanItem{
+			Path:     s.path,
+			Tool:     key,
+			ToolName: tool.Name,
+			Root:     root,
+			Rule:     r.name,
+			Reason:   s.reason,
+			Action:   r.action,
+			Size:     s.info.Size(),
+			ModTime:  s.info.ModTime(),
+			AgeDays:  int(now.Sub(s.info.ModTime()).Hours() / 24),
+			Quota:    s.quota,
+		})
+	}
+	return items, warnings, nil
+}
+
+// notOpen drops files held open by running AI tools, adding to count
+func (c *Cleaner) notOpen(files []selection, count int) ([]selection, int) {
+	procs := c.processes()
+	kept := files[:0]
+	for _, f := range files {
+		if _, ok := procs.openBy(f.path); ok {
+			count++
+			continue
+		}
+		kept = append(kept, f)
+	}
+	return kept, count
+}
+
+// ApplyPlan carries out exactly the files in the plan. Files that no longer
+// exist, or whose size or modification time changed since the plan was
+// made, are skipped. Files that no current rule covers with the same action
+// are refused so an edited plan can't touch arbitrary files.
+func (c *Cleaner) ApplyPlan(ctx context.Context, plan *Plan) ([]models.CleanupResult, error) {
+	return c.apply(ctx, plan.Items), ctx.Err()
+}
+
+// apply carries out every item that is still valid and returns one result
+// per tool, in the order the tools first appear
+func (c *Cleaner) apply(ctx context.Context, items []PlanItem) []models.CleanupResult {
+	start := time.Now()
+	byTool := make(map[string]*models.CleanupResult)
+	order := make([]string, 0)
+	pending := make(map[string][]PlanItem)
+
+	for _, item := range items {
+		// Stop between files when interrupted; what is done is reported
+		if ctx.Err() != nil {
+			break
+		}
+
+		result, ok := byTool[item.Tool]
+		if !ok {
+			result = &models.CleanupResult{Tool: item.ToolName, Path: item.Root}
+			byTool[item.Tool] = result
+			order = append(order, item.Tool)
+		}
+
+		if err := c.checkItem(item); err != nil {
+			result.FilesSkipped++
+			result.Skipped = append(result.Skipped, err.Error())
+			continue
+		}
+
+		var err error
+		switch item.Action {
+		case config.ActionDelete:
+			err = os.Remove(item.Path)
+		case config.ActionTrash:
+			_, err = c.trash.Put(item.Path, item.Tool)
+		case config.ActionArchive:
+			// Packed together once every item is checked
+			pending[item.Tool] = append(pending[item.Tool], item)
+			continue
+		case config.ActionCompress:
+			var saved int64
+			if saved, err = compressFile(item.Path); err == nil {
+				result.FilesCompressed++
+				result.SpaceSaved += saved
+				if item.Quota {
+					result.QuotaFreed += saved
+				}
+				continue
+			}
+		}
+		if err != nil {
+			result.FilesSkipped++
+			result.Skipped = append(result.Skipped, err.Error())
+			continue
+		}
+
+		result.FilesDeleted++
+		result.SpaceFreed += item.Size
+		if item.Quota {
+			result.QuotaFreed += item.Size
+		}
+		if item.Action == config.ActionTrash {
+			result.FilesTrashed++
+		}
+	}
+
+	for _, key := range order {
+		if len(pending[key]) > 0 {
+			c.archiveItems(key, pending[key], byTool[key])
+		}
+	}
+
+	results := make([]models.CleanupResult, 0, len(order))
+	for _, key := range order {
+		r := byTool[key]
+		r.Duration = time.Since(start)
+		results = append(results, *r)
+	}
+	return results
+}
+
+// archiveItems packs a tool's files into one archive, then removes them
+func (c *Cleaner) archiveItems(tool string, items []PlanItem, result *models.CleanupResult) {
+	entry, err := NewArchive(c.cfg).Pack(tool, items)
+	if err != nil {
+		result.FilesSkipped += len(items)
+		result.Skipped = append(result.Skipped, fmt.Sprintf("archive: %v", err))
+		return
+	}
+
+	var removed int64
+	for _, item := range items {
+		if err := os.Remove(item.Path); err != nil {
+			result.FilesSkipped++
+			result.Skipped = append(result.Skipped, fmt.Sprintf("%v (archived in %s)", err, entry.Name))
+			continue
+		}
+		result.FilesArchived++
+		removed += item.Size
+		if item.Quota {
+			result.QuotaFreed += item.Size
+		}
+	}
+	if saved := removed - entry.Size; saved > 0 {
+		result.SpaceSaved += saved
+	}
+}
+
+// checkItem verifies that a planned file is covered by the current rules
+// and is still the one that was reviewed
+func (c *Cleaner) checkItem(item PlanItem) error {
+	tool, ok := c.cfg.Tools[item.Tool]
+	if !ok || !tool.Enabled {
+		return fmt.Errorf("%s: tool %q is not enabled", item.Path, item.Tool)
+	}
+
+	rel, err := filepath.Rel(toolRoot(tool), filepath.Clean(item.Path))
+	if err != nil || rel == "." || strings.HasPrefix(rel, "..") {
+		return fmt.Errorf("%s: not inside the %s directory", item.Path, item.Tool)
+	}
+
+	rules, err := c.rulesFor(item.Tool, tool)
+	if err != nil {
+		return err
+	}
+	r := owner(rules, filepath.ToSlash(rel))
+	if r == nil || r.name != item.Rule || r.action != item.Action {
+		return fmt.Errorf("%s: no longer covered by rule %q", item.Path, item.Rule)
+	}
+
+	procs := c.processes()
+	if f, ok := procs.openBy(item.Path); ok {
+		return fmt.Errorf("%s: in use by %s (pid %d)", item.Path, f.Tool, f.PID)
+	}
+	if pid, ok := procs.activeIn(item.Tool, filepath.Dir(item.Path)); ok {
+		return fmt.Errorf("%s: an active %s session (pid %d) is using its directory", item.Path, item.Tool, pid)
+	}
+
+	info, err := os.Lstat(item.Path)
+	if err != nil {
+		return err
+	}
+	if info.IsDir() {
+		return fmt.Errorf("%s: is a directory", item.Path)
+	}
+	if info.Size() != item.Size {
+		return fmt.Errorf("%s: size changed since the plan was made", item.Path)
+	}
+	if !info.ModTime().Equal(item.ModTime) {
+		return fmt.Errorf("%s: modified since the plan was made", item.Path)
+	}
+	return nil
+}
+
+// WritePlan saves a plan as JSON for review
+func WritePlan(path string, plan *Plan) error {
+	data, err := json.MarshalIndent(plan, "", "  ")
+	if err != nil {
+		return err
+	}
+	return safefile.WriteFile(path, append(data, '\n'), 0644)
+}
+
+// ReadPlan loads a plan saved by WritePlan
+func ReadPlan(path string) (*Plan, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+
+	var plan Plan
+	if err := json.Unmarshal(data, &plan); err != nil {
+		return nil, fmt.Errorf("failed to parse plan %s: %w", path, err)
+	}
+	return &plan, nil
+}
+
+func sortedKeys(tools map[string]config.Tool) []string {
+	keys := make([]string, 0, len(tools))
+	for k := range tools {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
+}
@@ -0,0 +1,314 @@
+package cleanup
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"reflect"
+	"sort"
+	"strings"
+	"testing"
+	"time"
+
+	"ai-manager/internal/config"
+)
+
+// testTool is the key of the tool in the configs of newTestCleaner; no
+// process runs a binary by that name
+const testTool = "testtool"
+
+// newTestCleaner returns a cleaner for one enabled tool with the given
+// rules in a temp directory, and that directory
+func newTestCleaner(t *testing.T, tool config.Tool) (*Cleaner, string) {
+	t.Helper()
+	dir := t.TempDir()
+	root := filepath.Join(dir, "tool")
+	if err := os.Mkdir(root, 0755); err != nil {
+		t.Fatal(err)
+	}
+	tool.Name = "Test Tool"
+	tool.Path = root
+	tool.Enabled = true
+	cfg := &config.Config{
+		HomeDir:   filepath.Join(dir, "state"),
+		Tools:     map[string]config.Tool{testTool: tool},
+		Retention: config.RetentionPolicy{TempFiles: 7, Trash: 30},
+	}
+	return NewCleaner(cfg), root
+}
+
+// writeAged writes size bytes to root/rel and backdates it by age
+func writeAged(t *testing.T, root, rel string, size int, age time.Duration) string {
+	t.Helper()
+	path := filepath.Join(root, filepath.FromSlash(rel))
+	writeFile(t, path, strings.Repeat("x", size), time.Now().Add(-age).Truncate(time.Second))
+	return path
+}
+
+// itemFor returns the plan's item for path
+func itemFor(t *testing.T, plan *Plan, path string) PlanItem {
+	t.Helper()
+	for _, item := range plan.Items {
+		if item.Path == path {
+			return item
+		}
+	}
+	t.Fatalf("%s is not in the plan", path)
+	return PlanItem{}
+}
+
+func exists(path string) bool {
+	_, err := os.Lstat(path)
+	return err == nil
+}
+
+func TestApplyPlanRefusesTamperedItems(t *testing.T) {
+	day := 24 * time.Hour
+	c, root := newTestCleaner(t, config.Tool{Rules: []config.Rule{
+		{Name: "logs", Include: []string{"logs/*.log"}, OlderThan: "1d", Action: config.ActionDelete},
+	}})
+	resized := writeAged(t, root, "logs/resized.log", 10, 3*day)
+	touched := writeAged(t, root, "logs/touched.log", 10, 3*day)
+	untouched := writeAged(t, root, "logs/untouched.log", 10, 3*day)
+	retargeted := writeAged(t, root, "logs/retargeted.log", 10, 3*day)
+	fresh := writeAged(t, root, "logs/fresh.log", 10, time.Hour)
+	notes := writeAged(t, root, "notes/keep.txt", 10, 3*day)
+	outside := writeAged(t, filepath.Dir(root), "outside.log", 10, 3*day)
+
+	built, err := c.BuildPlan(context.Background())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(built.Items) != 4 {
+		t.Fatalf("plan has %d items, want the 4 old logs: %+v", len(built.Items), built.Items)
+	}
+
+	// Review the plan from its file, as cleanup --apply does
+	planPath := filepath.Join(t.TempDir(), "plan.json")
+	if err := WritePlan(planPath, built); err != nil {
+		t.Fatal(err)
+	}
+	plan, err := ReadPlan(planPath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(plan.Items) != 4 || !itemFor(t, plan, touched).ModTime.Equal(itemFor(t, built, touched).ModTime) {
+		t.Fatalf("plan changed on the way through its file: %+v", plan.Items)
+	}
+
+	// The files change after the review
+	writeFile(t, resized, "grown since the plan", itemFor(t, plan, resized).ModTime)
+	later := time.Now().Add(-2 * day).Truncate(time.Second)
+	if err := os.Chtimes(touched, later, later); err != nil {
+		t.Fatal(err)
+	}
+
+	// And the plan is edited
+	for i := range plan.Items {
+		if plan.Items[i].Path == retargeted {
+			plan.Items[i].Action = config.ActionTrash
+		}
+	}
+	forged := itemFor(t, plan, untouched)
+	forged.Path = filepath.Join(root, "..", "outside.log")
+	plan.Items = append(plan.Items, forged)
+	forged.Path = notes
+	plan.Items = append(plan.Items, forged)
+	forged.Path = fresh
+	forged.ModTime = itemFor(t, plan, untouched).ModTime
+	plan.Items = append(plan.Items, forged)
+
+	results, err := c.ApplyPlan(context.Background(), plan)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(results) != 1 {
+		t.Fatalf("results = %+v", results)
+	}
+	r := results[0]
+	if r.FilesDeleted != 1 || r.FilesTrashed != 0 || r.FilesSkipped != 6 {
+		t.Errorf("deleted %d, trashed %d, skipped %d, want 1, 0 and 6: %v",
+			r.FilesDeleted, r.FilesTrashed, r.FilesSkipped, r.Skipped)
+	}
+	if exists(untouched) {
+		t.Errorf("%s wasn't deleted", untouched)
+	}
+	for _, path := range []string{resized, touched, retargeted, fresh, notes, outside} {
+		if !exists(path) {
+			t.Errorf("%s was removed", path)
+		}
+	}
+
+	want := []string{
+		resized + ": size changed",
+		touched + ": modified since",
+		retargeted + `: no longer covered by rule "logs"`,
+		filepath.Join(root, "..", "outside.log") + ": not inside",
+		notes + `: no longer covered by rule "logs"`,
+		fresh + ": modified since",
+	}
+	skipped := strings.Join(r.Skipped, "\n")
+	for _, w := range want {
+		if !strings.Contains(skipped, w) {
+			t.Errorf("skipped = %q, want it to mention %q", r.Skipped, w)
+		}
+	}
+}
+
+func TestApplyPlanRefusesChangedConfig(t *testing.T) {
+	c, root := newTestCleaner(t, config.Tool{Rules: []config.Rule{
+		{Name: "logs", Include: []string{"logs/*.log"}, Action: config.ActionDelete},
+	}})
+	log := writeAged(t, root, "logs/a.log", 10, time.Hour)
+
+	plan, err := c.BuildPlan(context.Background())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(plan.Items) != 1 {
+		t.Fatalf("plan = %+v", plan.Items)
+	}
+
+	tests := map[string]struct {
+		change func(tool *config.Tool)
+		want   string
+	}{
+		"disabled":      {func(tool *config.Tool) { tool.Enabled = false }, `tool "testtool" is not enabled`},
+		"renamed rule":  {func(tool *config.Tool) { tool.Rules[0].Name = "old logs" }, `no longer covered by rule "logs"`},
+		"other action":  {func(tool *config.Tool) { tool.Rules[0].Action = config.ActionTrash }, `no longer covered by rule "logs"`},
+		"narrower rule": {func(tool *config.Tool) { tool.Rules[0].Exclude = []string{"logs/a.log"} }, `no longer covered by rule "logs"`},
+		"moved root":    {func(tool *config.Tool) { tool.Path = filepath.Join(filepath.Dir(root), "other") }, "not inside"},
+		"removed tool":  {nil, `tool "testtool" is not enabled`},
+	}
+	for name, tt := range tests {
+		t.Run(name, func(t *testing.T) {
+			tool := c.cfg.Tools[testTool]
+			tool.Rules = append([]config.Rule(nil), tool.Rules...)
+			cfg := *c.cfg
+			cfg.Tools = map[string]config.Tool{}
+			if tt.change != nil {
+				tt.change(&tool)
+				cfg.Tools[testTool] = tool
+			}
+
+			results, err := NewCleaner(&cfg).ApplyPlan(context.Background(), plan)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if len(results) != 1 || results[0].FilesDeleted != 0 || len(results[0].Skipped) != 1 ||
+				!strings.Contains(results[0].Skipped[0], tt.want) {
+				t.Errorf("results = %+v, want %s skipped with %q", results, log, tt.want)
+			}
+			if !exists(log) {
+				t.Fatalf("%s was removed", log)
+			}
+		})
+	}
+
+	// Unchanged, the plan is carried out
+	results, err := c.ApplyPlan(context.Background(), plan)
+	if err != nil || len(results) != 1 || results[0].FilesDeleted != 1 || exists(log) {
+		t.Errorf("results = %+v, %v, want %s deleted", results, err, log)
+	}
+}
+
+// quotaFixture fills a tool with 800 bytes: five 100 byte caches its rule
+// only removes past 30 days, one that old, and 200 bytes no rule covers.
+// keep_last protects the two newest caches.
+func quotaFixture(t *testing.T, quota string) (*Cleaner, string) {
+	t.Helper()
+	day := 24 * time.Hour
+	c, root := newTestCleaner(t, config.Tool{Quota: quota, Rules: []config.Rule{
+		{Name: "cache", Include: []string{"cache/**"}, OlderThan: "30d", KeepLast: 2, Action: config.ActionDelete},
+	}})
+	for i, name := range []string{"a", "b", "c", "d", "e"} {
+		writeAged(t, root, "cache/"+name, 100, time.Duration(i+1)*day)
+	}
+	writeAged(t, root, "cache/expired", 100, 40*day)
+	writeAged(t, root, "settings.json", 200, 50*day)
+	return c, root
+}
+
+// planned returns the tool's planned files relative to root, and which of
+// them were added for the quota
+func planned(t *testing.T, c *Cleaner, root string) (*Plan, []string, []string) {
+	t.Helper()
+	plan, err := c.BuildPlan(context.Background())
+	if err != nil {
+		t.Fatal(err)
+	}
+	var rels, quota []string
+	for _, item := range plan.Items {
+		rel, _ := filepath.Rel(root, item.Path)
+		rels = append(rels, filepath.ToSlash(rel))
+		if item.Quota {
+			quota = append(quota, filepath.ToSlash(rel))
+		}
+	}
+	return plan, rels, quota
+}
+
+func TestPlanQuota(t *testing.T) {
+	tests := []struct {
+		quota   string
+		rels    []string
+		quotaed []string
+		warning string
+	}{
+		// 800 bytes, 100 of them expired: nothing more to remove
+		{"", []string{"cache/expired"}, nil, ""},
+		{"800", []string{"cache/expired"}, nil, ""},
+		// The oldest caches go first until the rest fits
+		{"600", []string{"cache/expired", "cache/e"}, []string{"cache/e"}, ""},
+		{"450", []string{"cache/expired", "cache/e", "cache/d", "cache/c"}, []string{"cache/e", "cache/d", "cache/c"}, ""},
+		// keep_last keeps a and b, and settings.json isn't covered
+		{"100", []string{"cache/expired", "cache/e", "cache/d", "cache/c"}, []string{"cache/e", "cache/d", "cache/c"},
+			"Test Tool stays 300 B over its 100 B quota"},
+	}
+	for _, tt := range tests {
+		t.Run("quota "+tt.quota, func(t *testing.T) {
+			c, root := quotaFixture(t, tt.quota)
+			plan, rels, quotaed := planned(t, c, root)
+			sort.Strings(rels)
+			want := append([]string(nil), tt.rels...)
+			sort.Strings(want)
+			if !reflect.DeepEqual(rels, want) {
+				t.Errorf("planned %q, want %q", rels, want)
+			}
+			if !reflect.DeepEqual(quotaed, tt.quotaed) {
+				t.Errorf("added for the quota %q, want %q oldest first", quotaed, tt.quotaed)
+			}
+
+			warnings := plan.Warnings[testTool]
+			switch {
+			case tt.warning == "" && len(warnings) > 0:
+				t.Errorf("warnings = %q", warnings)
+			case tt.warning != "" && (len(warnings) != 1 || !strings.HasPrefix(warnings[0], tt.warning)):
+				t.Errorf("warnings = %q, want %q", warnings, tt.warning)
+			}
+		})
+	}
+}
+
+func TestPlanMaxSize(t *testing.T) {
+	// --max-size replaces a tool's quota, whether larger or smaller
+	c, root := quotaFixture(t, "100")
+	if err := c.SetMaxSize("1KiB"); err != nil {
+		t.Fatal(err)
+	}
+	if _, rels, _ := planned(t, c, root); !reflect.DeepEqual(rels, []string{"cache/expired"}) {
+		t.Errorf("planned %q under a 1KiB max size, want only cache/expired", rels)
+	}
+
+	c, root = quotaFixture(t, "1KiB")
+	if err := c.SetMaxSize("450"); err != nil {
+		t.Fatal(err)
+	}
+	if _, _, quotaed := planned(t, c, root); !reflect.DeepEqual(quotaed, []string{"cache/e", "cache/d", "cache/c"}) {
+		t.Errorf("added for a 450 B max size %q, want cache/e, cache/d and cache/c", quotaed)
+	}
+
+	if err := c.SetMaxSize("lots"); err == nil {
+		t.Error("SetMaxSize accepted an invalid size")
+	}
+}
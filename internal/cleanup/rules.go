@@ -0,0 +1,377 @@
+package cleanup
+
+import (
+	"context"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"ai-manager/internal/adapter"
+	"ai-manager/internal/config"
+	"ai-manager/internal/models"
+	"ai-manager/internal/utils"
+	"ai-manager/internal/walker"
+)
+
+// DefaultRules returns the rules for a tool that has none configured: one
+// per temp location of its adapter, removing files older than the
+// location's retention setting
+func DefaultRules(key string, tool config.Tool, retention config.RetentionPolicy) []config.Rule {
+	locations := adapter.For(key).TempLocations(tool)
+	rules := make([]config.Rule, 0, len(locations))
+	for _, l := range locations {
+		rules = append(rules, config.Rule{
+			Name:      fmt.Sprintf("%s (retention.%s)", l.Path, l.Retention),
+			Include:   []string{filepath.ToSlash(filepath.Clean(l.Path)) + "/**"},
+			OlderThan: fmt.Sprintf("%dd", retentionDays(retention, l.Retention)),
+			Action:    config.ActionTrash,
+		})
+	}
+	return rules
+}
+
+// retentionDays returns the value of a retention setting
+func retentionDays(r config.RetentionPolicy, setting string) int {
+	switch setting {
+	case "debug_logs_days":
+		return r.DebugLogs
+	case "shell_snapshots_days":
+		return r.ShellSnapshots
+	default:
+		return r.TempFiles
+	}
+}
+
+// rule is a config.Rule with its values parsed
+type rule struct {
+	name       string
+	include    []string
+	exclude    []string
+	olderThan  time.Duration
+	largerThan int64
+	keepLast   int
+	maxTotal   int64
+	action     string
+}
+
+// compileRules parses rules for evaluation
+func compileRules(rules []config.Rule) ([]rule, error) {
+	compiled := make([]rule, 0, len(rules))
+	for i, r := range rules {
+		c := rule{
+			name:     r.Name,
+			include:  r.Include,
+			exclude:  r.Exclude,
+			keepLast: r.KeepLast,
+			action:   r.ActionOrDefault(),
+		}
+		if c.name == "" {
+			c.name = fmt.Sprintf("rules[%d] %s", i, strings.Join(r.Include, ","))
+		}
+
+		var err error
+		if r.OlderThan != "" {
+			if c.olderThan, err = utils.ParseAge(r.OlderThan); err != nil {
+				return nil, fmt.Errorf("%s: %w", c.name, err)
+			}
+		}
+		if r.LargerThan != "" {
+			if c.largerThan, err = utils.ParseSize(r.LargerThan); err != nil {
+				return nil, fmt.Errorf("%s: %w", c.name, err)
+			}
+		}
+		if r.MaxTotalSize != "" {
+			if c.maxTotal, err = utils.ParseSize(r.MaxTotalSize); err != nil {
+				return nil, fmt.Errorf("%s: %w", c.name, err)
+			}
+		}
+		compiled = append(compiled, c)
+	}
+	return compiled, nil
+}
+
+// matches reports whether the rule covers a slash-separated path relative
+// to the tool directory
+func (r rule) matches(rel string) bool {
+	for _, glob := range r.exclude {
+		if utils.MatchGlob(glob, rel) {
+			return false
+		}
+	}
+	for _, glob := range r.include {
+		if utils.MatchGlob(glob, rel) {
+			return true
+		}
+	}
+	return false
+}
+
+// owner returns the first rule covering rel, or nil
+func owner(rules []rule, rel string) *rule {
+	for i := range rules {
+		if rules[i].matches(rel) {
+			return &rules[i]
+		}
+	}
+	return nil
+}
+
+// candidate is a file found in a tool directory
+type candidate struct {
+	path string
+	rel  string
+	info os.FileInfo
+}
+
+// selection is a file chosen for cleanup, the rule it belongs to and why
+type selection struct {
+	candidate
+	rule   int
+	reason string
+	quota  bool
+}
+
+// rulesFor returns the compiled rules of a tool
+func (c *Cleaner) rulesFor(key string, tool config.Tool) ([]rule, error) {
+	rules := tool.Rules
+	if len(rules) == 0 {
+		rules = DefaultRules(key, tool, c.cfg.Retention)
+	}
+	return compileRules(rules)
+}
+
+// toolRoot returns the absolute directory of a tool
+func toolRoot(tool config.Tool) string {
+	home, _ := os.UserHomeDir()
+	return filepath.Clean(expandPath(tool.Path, home))
+}
+
+// ruleDirs returns the directories the rules' include globs start from
+func ruleDirs(root string, rules []rule) []string {
+	seen := make(map[string]bool)
+	dirs := make([]string, 0)
+	for _, r := range rules {
+		for _, glob := range r.include {
+			dir := filepath.Join(root, filepath.FromSlash(utils.GlobBase(glob)))
+			if !seen[dir] {
+				seen[dir] = true
+				dirs = append(dirs, dir)
+			}
+		}
+	}
+	return dirs
+}
+
+// collect finds the files in root that any rule could cover. Only the
+// directories the include globs can reach are walked.
+func collect(ctx context.Context, root string, rules []rule) ([]candidate, error) {
+	// Walk each glob's literal base, as deep as the glob can reach
+	depths := make(map[string]int)
+	for _, r := range rules {
+		for _, glob := range r.include {
+			base := utils.GlobBase(glob)
+			depth := -1
+			if !strings.Contains(glob, "**") {
+				depth = len(segments(glob)) - len(segments(base))
+			}
+			if d, ok := depths[base]; !ok || (d != -1 && (depth == -1 || depth > d)) {
+				depths[base] = depth
+			}
+		}
+	}
+
+	var mu sync.Mutex
+	seen := make(map[string]bool)
+	files := make([]candidate, 0)
+	for _, base := range sortedStrings(depths) {
+		maxDepth := depths[base]
+		start := filepath.Join(root, filepath.FromSlash(base))
+
+		err := walker.Walk(ctx, start, walker.Options{}, func(path string, d fs.DirEntry) error {
+			rel, err := filepath.Rel(root, path)
+			if err != nil {
+				return nil
+			}
+			rel = filepath.ToSlash(rel)
+
+			if d.IsDir() {
+				if maxDepth >= 0 && len(segments(rel))-len(segments(base)) >= maxDepth {
+					return filepath.SkipDir
+				}
+				return nil
+			}
+
+			info, err := d.Info()
+			if err != nil {
+				return nil // Removed while walking
+			}
+
+			mu.Lock()
+			defer mu.Unlock()
+			if !seen[path] {
+				seen[path] = true
+				files = append(files, candidate{path: path, rel: rel, info: info})
+			}
+			return nil
+		})
+		if err != nil && !os.IsNotExist(err) {
+			return nil, err
+		}
+	}
+
+	// The walk finds files in no particular order
+	sort.Slice(files, func(i, j int) bool {
+		return files[i].path < files[j].path
+	})
+	return files, nil
+}
+
+// evaluate assigns files to their rules and returns the ones to clean up.
+// It also returns the spare files: those the rules could remove but didn't
+// select, which a quota may still take.
+func evaluate(rules []rule, files []candidate, now time.Time) (selected, spare []selection) {
+	owned := make([][]candidate, len(rules))
+	for _, f := range files {
+		for i := range rules {
+			if rules[i].matches(f.rel) {
+				owned[i] = append(owned[i], f)
+				break
+			}
+		}
+	}
+
+	for i, r := range rules {
+		s, rest := r.selectFiles(owned[i], now)
+		for _, sel := range s {
+			sel.rule = i
+			selected = append(selected, sel)
+		}
+		for _, f := range rest {
+			spare = append(spare, selection{candidate: f, rule: i})
+		}
+	}
+	return selected, spare
+}
+
+// selectFiles applies the rule's conditions to the files it owns, and
+// returns the selected files and the rest that keep_last doesn't protect
+func (r rule) selectFiles(files []candidate, now time.Time) ([]selection, []candidate) {
+	// Newest first, so keep_last and max_total_size keep recent files
+	sort.Slice(files, func(i, j int) bool {
+		return files[i].info.ModTime().After(files[j].info.ModTime())
+	})
+
+	filtered := r.olderThan > 0 || r.largerThan > 0
+	var total int64
+	over := false
+
+	selected := make([]selection, 0)
+	spare := make([]candidate, 0)
+	for i, f := range files {
+		size := f.info.Size()
+		if i < r.keepLast {
+			total += size
+			continue
+		}
+
+		// Compressed files are left alone by compress rules
+		if r.action == config.ActionCompress && strings.HasSuffix(f.rel, ".gz") {
+			continue
+		}
+
+		var reason string
+		switch {
+		case filtered:
+			reason = r.filterReason(f.info, now)
+		case r.maxTotal == 0:
+			reason = fmt.Sprintf("beyond keep_last %d", r.keepLast)
+		}
+
+		if reason == "" && r.maxTotal > 0 {
+			if over || total+size > r.maxTotal {
+				over = true
+				reason = "over max_total_size " + models.FormatBytes(r.maxTotal)
+			} else {
+				total += size
+			}
+		}
+
+		if reason != "" {
+			selected = append(selected, selection{candidate: f, reason: reason})
+		} else {
+			spare = append(spare, f)
+		}
+	}
+	return selected, spare
+}
+
+// fitQuota picks the oldest spare files until at least excess bytes are
+// freed, and returns them with how many bytes they free
+func fitQuota(spare []selection, excess, quota int64) ([]selection, int64) {
+	sort.SliceStable(spare, func(i, j int) bool {
+		return spare[i].info.ModTime().Before(spare[j].info.ModTime())
+	})
+
+	var freed int64
+	picked := make([]selection, 0)
+	for _, s := range spare {
+		if freed >= excess {
+			break
+		}
+		s.reason = "over quota " + models.FormatBytes(quota)
+		s.quota = true
+		picked = append(picked, s)
+		freed += s.info.Size()
+	}
+	return picked, freed
+}
+
+// filterReason describes why a file passes older_than and larger_than, or
+// returns "" if it doesn't
+func (r rule) filterReason(info os.FileInfo, now time.Time) string {
+	reasons := make([]string, 0, 2)
+	if r.olderThan > 0 {
+		if now.Sub(info.ModTime()) <= r.olderThan {
+			return ""
+		}
+		reasons = append(reasons, "older than "+formatAge(r.olderThan))
+	}
+	if r.largerThan > 0 {
+		if info.Size() <= r.largerThan {
+			return ""
+		}
+		reasons = append(reasons, "larger than "+models.FormatBytes(r.largerThan))
+	}
+	return strings.Join(reasons, " and ")
+}
+
+// formatAge formats a duration in days when it is a whole number of them
+func formatAge(d time.Duration) string {
+	day := 24 * time.Hour
+	if d >= day && d%day == 0 {
+		return fmt.Sprintf("%dd", d/day)
+	}
+	return d.String()
+}
+
+// segments splits a slash-separated path, returning nothing for ""
+func segments(path string) []string {
+	if path == "" {
+		return nil
+	}
+	return strings.Split(path, "/")
+}
+
+func sortedStrings[V any](m map[string]V) []string {
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
+}
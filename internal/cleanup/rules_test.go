@@ -0,0 +1,242 @@
+package cleanup
+
+import (
+	"io/fs"
+	"reflect"
+	"sort"
+	"strings"
+	"testing"
+	"time"
+
+	"ai-manager/internal/config"
+)
+
+var now = time.Date(2026, 1, 15, 12, 0, 0, 0, time.UTC)
+
+// fileInfo is a fake file with a size and an age relative to now
+type fileInfo struct {
+	name string
+	size int64
+	age  time.Duration
+}
+
+func (f fileInfo) Name() string       { return f.name }
+func (f fileInfo) Size() int64        { return f.size }
+func (f fileInfo) Mode() fs.FileMode  { return 0644 }
+func (f fileInfo) ModTime() time.Time { return now.Add(-f.age) }
+func (f fileInfo) IsDir() bool        { return false }
+func (f fileInfo) Sys() interface{}   { return nil }
+
+func file(rel string, size int64, age time.Duration) candidate {
+	return candidate{path: "/tool/" + rel, rel: rel, info: fileInfo{name: rel, size: size, age: age}}
+}
+
+func mustCompile(t *testing.T, rules ...config.Rule) []rule {
+	t.Helper()
+	compiled, err := compileRules(rules)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return compiled
+}
+
+// selectedRels returns the sorted relative paths of the selected files
+func selectedRels(selected []selection) []string {
+	rels := make([]string, 0, len(selected))
+	for _, s := range selected {
+		rels = append(rels, s.rel)
+	}
+	sort.Strings(rels)
+	return rels
+}
+
+func TestRuleMatches(t *testing.T) {
+	r := mustCompile(t, config.Rule{
+		Include: []string{"debug/**", "*.log"},
+		Exclude: []string{"debug/keep/**", "important.log"},
+	})[0]
+
+	tests := map[string]bool{
+		"debug/a.txt":        true,
+		"debug/x/y/a.txt":    true,
+		"debug/keep/a.txt":   false, // exclude wins over include
+		"debug/keep/x/a.txt": false,
+		"error.log":          true,
+		"important.log":      false,
+		"sub/error.log":      false,
+		"settings.json":      false,
+	}
+	for rel, want := range tests {
+		if got := r.matches(rel); got != want {
+			t.Errorf("matches(%q) = %v, want %v", rel, got, want)
+		}
+	}
+}
+
+func TestFirstRuleOwnsFile(t *testing.T) {
+	day := 24 * time.Hour
+	rules := mustCompile(t,
+		// Excluded files fall through to later rules
+		config.Rule{Name: "recent debug", Include: []string{"debug/**"}, Exclude: []string{"**/*.gz"}, OlderThan: "30d"},
+		config.Rule{Name: "everything", Include: []string{"**"}, OlderThan: "1d"},
+	)
+	files := []candidate{
+		file("debug/a.txt", 10, 10*day),    // first rule, too new for it
+		file("debug/b.txt", 10, 40*day),    // first rule, old enough
+		file("debug/c.txt.gz", 10, 10*day), // excluded there, so the second rule's
+		file("other.txt", 10, 2*day),
+		file("new.txt", 10, time.Hour),
+	}
+
+	selected, spare := evaluate(rules, files, now)
+	if got, want := selectedRels(selected), []string{"debug/b.txt", "debug/c.txt.gz", "other.txt"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("selected = %q, want %q", got, want)
+	}
+	for _, s := range selected {
+		wantRule := 1
+		if s.rel == "debug/b.txt" {
+			wantRule = 0
+		}
+		if s.rule != wantRule {
+			t.Errorf("%s owned by rule %d, want %d", s.rel, s.rule, wantRule)
+		}
+	}
+	if got, want := selectedRels(spare), []string{"debug/a.txt", "new.txt"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("spare = %q, want %q", got, want)
+	}
+}
+
+func TestSelectFiles(t *testing.T) {
+	day := 24 * time.Hour
+	files := []candidate{
+		file("a", 100, 1*day),
+		file("b", 2000, 2*day),
+		file("c", 300, 10*day),
+		file("d", 4000, 20*day),
+		file("e", 500, 40*day),
+	}
+
+	tests := []struct {
+		name   string
+		rule   config.Rule
+		want   []string
+		reason string
+	}{
+		{
+			name:   "older_than",
+			rule:   config.Rule{OlderThan: "7d"},
+			want:   []string{"c", "d", "e"},
+			reason: "older than 7d",
+		},
+		{
+			name: "older_than is exclusive",
+			rule: config.Rule{OlderThan: "10d"},
+			want: []string{"d", "e"},
+		},
+		{
+			name:   "larger_than",
+			rule:   config.Rule{LargerThan: "1KB"},
+			want:   []string{"b", "d"},
+			reason: "larger than",
+		},
+		{
+			name:   "older_than and larger_than",
+			rule:   config.Rule{OlderThan: "7d", LargerThan: "1KB"},
+			want:   []string{"d"},
+			reason: "older than 7d and larger than",
+		},
+		{
+			name:   "keep_last",
+			rule:   config.Rule{KeepLast: 3},
+			want:   []string{"d", "e"},
+			reason: "beyond keep_last 3",
+		},
+		{
+			name: "keep_last protects old files",
+			rule: config.Rule{KeepLast: 4, OlderThan: "7d"},
+			want: []string{"e"},
+		},
+		{
+			name:   "max_total_size keeps the newest",
+			rule:   config.Rule{MaxTotalSize: "2500B"},
+			want:   []string{"d", "e"},
+			reason: "over max_total_size",
+		},
+		{
+			name: "no conditions selects everything",
+			rule: config.Rule{},
+			want: []string{"a", "b", "c", "d", "e"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tt.rule.Include = []string{"**"}
+			r := mustCompile(t, tt.rule)[0]
+
+			input := append([]candidate(nil), files...)
+			selected, spare := r.selectFiles(input, now)
+			if got := selectedRels(selected); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("selected = %q, want %q", got, tt.want)
+			}
+			if len(selected)+len(spare)+min(tt.rule.KeepLast, len(files)) != len(files) {
+				t.Errorf("%d selected and %d spare of %d files with keep_last %d",
+					len(selected), len(spare), len(files), tt.rule.KeepLast)
+			}
+			for _, s := range selected {
+				if tt.reason != "" && !strings.HasPrefix(s.reason, tt.reason) {
+					t.Errorf("%s reason = %q, want %q...", s.rel, s.reason, tt.reason)
+				}
+			}
+		})
+	}
+}
+
+func TestCompileRulesErrors(t *testing.T) {
+	for _, r := range []config.Rule{
+		{Include: []string{"**"}, OlderThan: "-1d"},
+		{Include: []string{"**"}, OlderThan: "soon"},
+		{Include: []string{"**"}, LargerThan: "-1MB"},
+		{Include: []string{"**"}, MaxTotalSize: "lots"},
+	} {
+		if _, err := compileRules([]config.Rule{r}); err == nil {
+			t.Errorf("compileRules(%+v) succeeded", r)
+		}
+	}
+}
+
+func TestFitQuota(t *testing.T) {
+	day := 24 * time.Hour
+	spare := []selection{
+		{candidate: file("b", 300, 2*day)},
+		{candidate: file("d", 100, 4*day)},
+		{candidate: file("a", 500, 1*day)},
+		{candidate: file("c", 200, 3*day)},
+	}
+
+	tests := []struct {
+		excess int64
+		want   []string
+		freed  int64
+	}{
+		{0, []string{}, 0},
+		{100, []string{"d"}, 100},
+		{101, []string{"d", "c"}, 300},
+		{600, []string{"d", "c", "b"}, 600},
+		{5000, []string{"d", "c", "b", "a"}, 1100},
+	}
+	for _, tt := range tests {
+		picked, freed := fitQuota(append([]selection(nil), spare...), tt.excess, 1000)
+		// Oldest first, in the order they are picked
+		got := make([]string, 0, len(picked))
+		for _, s := range picked {
+			got = append(got, s.rel)
+			if !s.quota || s.reason != "over quota 1000 B" {
+				t.Errorf("%s: quota %v, reason %q", s.rel, s.quota, s.reason)
+			}
+		}
+		if !reflect.DeepEqual(got, tt.want) || freed != tt.freed {
+			t.Errorf("excess %d: picked %q freeing %d, want %q freeing %d", tt.excess, got, freed, tt.want, tt.freed)
+		}
+	}
+}
@@ -0,0 +1,350 @@
+package cleanup
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"syscall"
+	"time"
+
+	"ai-manager/internal/config"
+	"ai-manager/internal/models"
+	"ai-manager/internal/safefile"
+	"ai-manager/internal/walker"
+)
+
+// TrashDirName is the directory in the state directory that holds trashed
+// files. Like the freedesktop trash, file contents live in files/ and their
+// metadata in info/<id>.json.
+const TrashDirName = "trash"
+
+// Trash is a quarantine for files removed by cleanup, so they can be
+// restored until they expire
+type Trash struct {
+	dir       string
+	retention int
+}
+
+// NewTrash creates the trash for the configured state directory
+func NewTrash(cfg *config.Config) *Trash {
+	return &Trash{
+		dir:       filepath.Join(cfg.StateDir(), TrashDirName),
+		retention: cfg.Retention.Trash,
+	}
+}
+
+// Dir returns the trash directory
+func (t *Trash) Dir() string {
+	return t.dir
+}
+
+func (t *Trash) filePath(id string) string {
+	return filepath.Join(t.dir, "files", id)
+}
+
+func (t *Trash) infoPath(id string) string {
+	return filepath.Join(t.dir, "info", id+".json")
+}
+
+// Put moves a file or directory into the trash and records where it came
+// from
+func (t *Trash) Put(path, tool string) (models.TrashItem, error) {
+	info, err := os.Lstat(path)
+	if err != nil {
+		return models.TrashItem{}, err
+	}
+	size := info.Size()
+	if info.IsDir() {
+		usage, err := walker.Usage(context.Background(), path, walker.Options{})
+		if err != nil {
+			return models.TrashItem{}, err
+		}
+		size = usage.Size
+	}
+
+	for _, dir := range []string{filepath.Join(t.dir, "files"), filepath.Join(t.dir, "info")} {
+		if err := os.MkdirAll(dir, 0700); err != nil {
+			return models.TrashItem{}, err
+		}
+	}
+
+	now := time.Now()
+	id, err := t.reserve(now, filepath.Base(path))
+	if err != nil {
+		return models.TrashItem{}, err
+	}
+
+	item := models.TrashItem{
+		ID:           id,
+		OriginalPath: path,
+		Tool:         tool,
+		TrashedAt:    now,
+		Size:         size,
+		ModTime:      info.ModTime(),
+	}
+
+	if err := moveFile(path, t.filePath(id)); err != nil {
+		os.Remove(t.infoPath(id))
+		return models.TrashItem{}, err
+	}
+	if err := t.writeInfo(item); err != nil {
+		// Without its info the file could be neither listed nor restored,
+		// so put it back
+		defer os.Remove(t.infoPath(id))
+		if backErr := moveFile(t.filePath(id), path); backErr != nil {
+			return models.TrashItem{}, fmt.Errorf("%w (and %s was left at %s: %v)", err, path, t.filePath(id), backErr)
+		}
+		return models.TrashItem{}, err
+	}
+	return item, nil
+}
+
+// reserve claims a unique id by creating its info file
+func (t *Trash) reserve(now time.Time, name string) (string, error) {
+	base := now.Format("20060102-150405") + "-" + name
+	for n := 0; ; n++ {
+		id := base
+		if n > 0 {
+			id = fmt.Sprintf("%s-%d", base, n)
+		}
+
+		f, err := os.OpenFile(t.infoPath(id), os.O_WRONLY|os.O_CREATE|os.O_EXCL, 0600)
+		if errors.Is(err, os.ErrExist) {
+			continue
+		}
+		if err != nil {
+			return "", err
+		}
+		f.Close()
+		return id, nil
+	}
+}
+
+func (t *Trash) writeInfo(item models.TrashItem) error {
+	data, err := json.MarshalIndent(item, "", "  ")
+	if err != nil {
+		return err
+	}
+	return safefile.WriteFile(t.infoPath(item.ID), append(data, '\n'), 0600)
+}
+
+// List returns the trashed files, oldest first
+func (t *Trash) List() ([]models.TrashItem, error) {
+	entries, err := os.ReadDir(filepath.Join(t.dir, "info"))
+	if os.IsNotExist(err) {
+		return []models.TrashItem{}, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	items := make([]models.TrashItem, 0, len(entries))
+	for _, e := range entries {
+		id, ok := strings.CutSuffix(e.Name(), ".json")
+		if !ok || e.IsDir() {
+			continue
+		}
+		item, err := t.Get(id)
+		if err != nil {
+			continue // Skip entries still being written or damaged
+		}
+		items = append(items, item)
+	}
+
+	sort.Slice(items, func(i, j int) bool {
+		return items[i].TrashedAt.Before(items[j].TrashedAt)
+	})
+	return items, nil
+}
+
+// Get returns the metadata of a trashed file
+func (t *Trash) Get(id string) (models.TrashItem, error) {
+	if id == "" || id != filepath.Base(id) || id == "." || id == ".." {
+		return models.TrashItem{}, fmt.Errorf("invalid trash id %q", id)
+	}
+
+	data, err := os.ReadFile(t.infoPath(id))
+	if os.IsNotExist(err) {
+		return models.TrashItem{}, fmt.Errorf("%s is not in the trash", id)
+	}
+	if err != nil {
+		return models.TrashItem{}, err
+	}
+
+	var item models.TrashItem
+	if err := json.Unmarshal(data, &item); err != nil {
+		return models.TrashItem{}, fmt.Errorf("failed to parse trash entry %s: %w", id, err)
+	}
+	item.ID = id
+	return item, nil
+}
+
+// Restore moves a trashed file back to where it came from. An existing
+// file at that path is only replaced with force.
+func (t *Trash) Restore(id string, force bool) (models.TrashItem, error) {
+	item, err := t.Get(id)
+	if err != nil {
+		return item, err
+	}
+
+	if _, err := os.Lstat(item.OriginalPath); err == nil && !force {
+		return item, fmt.Errorf("%s already exists (use --force to overwrite)", item.OriginalPath)
+	}
+
+	if err := os.MkdirAll(filepath.Dir(item.OriginalPath), 0755); err != nil {
+		return item, err
+	}
+	if err := moveFile(t.filePath(id), item.OriginalPath); err != nil {
+		return item, err
+	}
+	return item, os.Remove(t.infoPath(id))
+}
+
+// Delete permanently removes a trashed file
+func (t *Trash) Delete(id string) (models.TrashItem, error) {
+	item, err := t.Get(id)
+	if err != nil {
+		return item, err
+	}
+
+	if err := os.RemoveAll(t.filePath(id)); err != nil {
+		return item, err
+	}
+	return item, os.Remove(t.infoPath(id))
+}
+
+// Purge permanently removes files trashed before cutoff
+func (t *Trash) Purge(cutoff time.Time) ([]models.TrashItem, error) {
+	items, err := t.List()
+	if err != nil {
+		return nil, err
+	}
+
+	purged := make([]models.TrashItem, 0)
+	for _, item := range items {
+		if !item.TrashedAt.Before(cutoff) {
+			continue
+		}
+		if _, err := t.Delete(item.ID); err != nil {
+			return purged, err
+		}
+		purged = append(purged, item)
+	}
+	return purged, nil
+}
+
+// PurgeExpired permanently removes files kept longer than retention.trash_days
+func (t *Trash) PurgeExpired() ([]models.TrashItem, error) {
+	return t.Purge(time.Now().AddDate(0, 0, -t.retention))
+}
+
+// rename is os.Rename; tests replace it to move across filesystems
+var rename = os.Rename
+
+// moveFile renames src to dst, copying and deleting when they are on
+// different filesystems. A directory is moved with everything in it.
+func moveFile(src, dst string) error {
+	err := rename(src, dst)
+	if err == nil || !errors.Is(err, syscall.EXDEV) {
+		return err
+	}
+
+	info, err := os.Lstat(src)
+	if err != nil {
+		return err
+	}
+	if info.IsDir() {
+		if err := copyDir(src, dst); err != nil {
+			os.RemoveAll(dst)
+			return err
+		}
+		return os.RemoveAll(src)
+	}
+
+	if err := copyFile(src, dst); err != nil {
+		return err
+	}
+	return os.Remove(src)
+}
+
+// copyDir copies a directory tree with copyFile
+func copyDir(src, dst string) error {
+	return filepath.WalkDir(src, func(path string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		rel, err := filepath.Rel(src, path)
+		if err != nil {
+			return err
+		}
+		target := filepath.Join(dst, rel)
+
+		if d.IsDir() {
+			info, err := d.Info()
+			if err != nil {
+				return err
+			}
+			return os.MkdirAll(target, info.Mode().Perm()|0700)
+		}
+		return copyFile(path, target)
+	})
+}
+
+// copyFile copies src to dst keeping its mode and modification time. The
+// copy is written under a temporary name and renamed into place, so an
+// interrupted copy never leaves a partial dst behind.
+func copyFile(src, dst string) error {
+	info, err := os.Lstat(src)
+	if err != nil {
+		return err
+	}
+
+	tmp := filepath.Join(filepath.Dir(dst), "."+filepath.Base(dst)+".tmp")
+	os.Remove(tmp)
+
+	if info.Mode()&os.ModeSymlink != 0 {
+		target, err := os.Readlink(src)
+		if err != nil {
+			return err
+		}
+		if err := os.Symlink(target, tmp); err != nil {
+			return err
+		}
+		return os.Rename(tmp, dst)
+	}
+
+	in, err := os.Open(src)
+	if err != nil {
+		return err
+	}
+	defer in.Close()
+
+	out, err := os.OpenFile(tmp, os.O_WRONLY|os.O_CREATE|os.O_EXCL, info.Mode().Perm())
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tmp) // no-op after a successful rename
+
+	if _, err := io.Copy(out, in); err != nil {
+		out.Close()
+		return err
+	}
+	if err := out.Sync(); err != nil {
+		out.Close()
+		return err
+	}
+	if err := out.Close(); err != nil {
+		return err
+	}
+	if err := os.Chtimes(tmp, info.ModTime(), info.ModTime()); err != nil {
+		return err
+	}
+	return os.Rename(tmp, dst)
+}
@@ -0,0 +1,283 @@
+package cleanup
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"syscall"
+	"testing"
+	"time"
+)
+
+func writeFile(t *testing.T, path, data string, mtime time.Time) {
+	t.Helper()
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(path, []byte(data), 0640); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.Chtimes(path, mtime, mtime); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func readFile(t *testing.T, path string) string {
+	t.Helper()
+	data, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return string(data)
+}
+
+func newTestTrash(t *testing.T) *Trash {
+	return &Trash{dir: filepath.Join(t.TempDir(), TrashDirName), retention: 30}
+}
+
+// crossDevice makes moveFile see every rename as crossing filesystems
+func crossDevice(t *testing.T) {
+	rename = func(src, dst string) error {
+		return &os.LinkError{Op: "rename", Old: src, New: dst, Err: syscall.EXDEV}
+	}
+	t.Cleanup(func() { rename = os.Rename })
+}
+
+func TestTrashPutRestore(t *testing.T) {
+	trash := newTestTrash(t)
+	src := filepath.Join(t.TempDir(), "tool", "debug.log")
+	mtime := time.Now().Add(-time.Hour).Truncate(time.Second)
+	writeFile(t, src, "old log", mtime)
+
+	item, err := trash.Put(src, "claude")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := os.Lstat(src); !os.IsNotExist(err) {
+		t.Errorf("%s still exists after Put: %v", src, err)
+	}
+	if item.OriginalPath != src || item.Tool != "claude" || item.Size != 7 || !item.ModTime.Equal(mtime) ||
+		!strings.HasSuffix(item.ID, "-debug.log") {
+		t.Errorf("item = %+v", item)
+	}
+	if got := readFile(t, trash.filePath(item.ID)); got != "old log" {
+		t.Errorf("trashed content = %q", got)
+	}
+	items, err := trash.List()
+	if err != nil || len(items) != 1 || items[0].ID != item.ID || items[0].OriginalPath != src {
+		t.Fatalf("List = %+v, %v", items, err)
+	}
+
+	// A new file at the path is kept unless forced
+	writeFile(t, src, "new log", time.Now())
+	if _, err := trash.Restore(item.ID, false); err == nil || !strings.Contains(err.Error(), "already exists") {
+		t.Errorf("Restore without force = %v, want an already exists error", err)
+	}
+	if got := readFile(t, src); got != "new log" {
+		t.Errorf("Restore without force replaced the file with %q", got)
+	}
+	if _, err := trash.Get(item.ID); err != nil {
+		t.Errorf("entry gone after a refused restore: %v", err)
+	}
+
+	if _, err := trash.Restore(item.ID, true); err != nil {
+		t.Fatal(err)
+	}
+	if got := readFile(t, src); got != "old log" {
+		t.Errorf("restored content = %q", got)
+	}
+	if items, err := trash.List(); err != nil || len(items) != 0 {
+		t.Errorf("List after Restore = %+v, %v", items, err)
+	}
+	if _, err := trash.Restore(item.ID, false); err == nil {
+		t.Error("restoring twice succeeded")
+	}
+}
+
+func TestTrashPutDirectory(t *testing.T) {
+	trash := newTestTrash(t)
+	src := filepath.Join(t.TempDir(), "cache")
+	writeFile(t, filepath.Join(src, "a"), "12345", time.Now())
+	writeFile(t, filepath.Join(src, "sub", "b"), "678", time.Now())
+
+	item, err := trash.Put(src, "gemini")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if item.Size != 8 {
+		t.Errorf("size = %d, want 8", item.Size)
+	}
+	// The parent is recreated on restore
+	if err := os.Remove(filepath.Dir(src)); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := trash.Restore(item.ID, false); err != nil {
+		t.Fatal(err)
+	}
+	if got := readFile(t, filepath.Join(src, "sub", "b")); got != "678" {
+		t.Errorf("restored sub/b = %q", got)
+	}
+}
+
+func TestTrashPutRollsBack(t *testing.T) {
+	trash := newTestTrash(t)
+	src := filepath.Join(t.TempDir(), "debug.log")
+	writeFile(t, src, "log", time.Now())
+
+	// Once the file is in the trash, turn its info file into a directory
+	// so writing the info fails
+	sabotaged := false
+	rename = func(from, to string) error {
+		if err := os.Rename(from, to); err != nil {
+			return err
+		}
+		if !sabotaged {
+			sabotaged = true
+			info := trash.infoPath(filepath.Base(to))
+			if err := os.Remove(info); err != nil {
+				return err
+			}
+			return os.Mkdir(info, 0700)
+		}
+		return nil
+	}
+	t.Cleanup(func() { rename = os.Rename })
+
+	if _, err := trash.Put(src, "claude"); err == nil {
+		t.Fatal("Put succeeded without its info")
+	}
+	if got := readFile(t, src); got != "log" {
+		t.Errorf("file not moved back: %q", got)
+	}
+	for _, dir := range []string{"files", "info"} {
+		entries, err := os.ReadDir(filepath.Join(trash.dir, dir))
+		if err != nil || len(entries) != 0 {
+			t.Errorf("%s/ = %v, %v, want it empty", dir, entries, err)
+		}
+	}
+}
+
+func TestTrashPurge(t *testing.T) {
+	trash := newTestTrash(t)
+	dir := t.TempDir()
+	var ids []string
+	for i, age := range []int{40, 10, 31} {
+		src := filepath.Join(dir, string(rune('a'+i)))
+		writeFile(t, src, "x", time.Now())
+		item, err := trash.Put(src, "claude")
+		if err != nil {
+			t.Fatal(err)
+		}
+		item.TrashedAt = time.Now().AddDate(0, 0, -age)
+		if err := trash.writeInfo(item); err != nil {
+			t.Fatal(err)
+		}
+		ids = append(ids, item.ID)
+	}
+
+	purged, err := trash.PurgeExpired()
+	if err != nil {
+		t.Fatal(err)
+	}
+	// Oldest first
+	if len(purged) != 2 || purged[0].ID != ids[0] || purged[1].ID != ids[2] {
+		t.Errorf("purged = %+v, want %s and %s", purged, ids[0], ids[2])
+	}
+	for _, id := range []string{ids[0], ids[2]} {
+		if _, err := os.Lstat(trash.filePath(id)); !os.IsNotExist(err) {
+			t.Errorf("%s still in files/: %v", id, err)
+		}
+	}
+	items, err := trash.List()
+	if err != nil || len(items) != 1 || items[0].ID != ids[1] {
+		t.Errorf("List = %+v, %v, want only %s", items, err, ids[1])
+	}
+
+	if purged, err := trash.Purge(time.Now()); err != nil || len(purged) != 1 {
+		t.Errorf("Purge(now) = %+v, %v", purged, err)
+	}
+}
+
+func TestMoveFileAcrossFilesystems(t *testing.T) {
+	crossDevice(t)
+	dir := t.TempDir()
+	mtime := time.Now().Add(-24 * time.Hour).Truncate(time.Second)
+
+	src := filepath.Join(dir, "src")
+	writeFile(t, filepath.Join(src, "a.txt"), "a", mtime)
+	writeFile(t, filepath.Join(src, "sub", "b.txt"), "bb", mtime)
+	if err := os.Chmod(filepath.Join(src, "sub", "b.txt"), 0600); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.Mkdir(filepath.Join(src, "empty"), 0750); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.Symlink("a.txt", filepath.Join(src, "link")); err != nil {
+		t.Fatal(err)
+	}
+
+	dst := filepath.Join(dir, "dst")
+	if err := moveFile(src, dst); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := os.Lstat(src); !os.IsNotExist(err) {
+		t.Errorf("source still exists: %v", err)
+	}
+	if got := readFile(t, filepath.Join(dst, "sub", "b.txt")); got != "bb" {
+		t.Errorf("sub/b.txt = %q", got)
+	}
+	info, err := os.Stat(filepath.Join(dst, "sub", "b.txt"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if info.Mode().Perm() != 0600 || !info.ModTime().Equal(mtime) {
+		t.Errorf("sub/b.txt mode %v, mtime %v, want 0600 and %v", info.Mode().Perm(), info.ModTime(), mtime)
+	}
+	if info, err := os.Stat(filepath.Join(dst, "empty")); err != nil || !info.IsDir() {
+		t.Errorf("empty dir not copied: %v", err)
+	}
+	if target, err := os.Readlink(filepath.Join(dst, "link")); err != nil || target != "a.txt" {
+		t.Errorf("link = %q, %v, want a symlink to a.txt", target, err)
+	}
+
+	// A single file, without leaving the temporary copy behind
+	if err := moveFile(filepath.Join(dst, "a.txt"), filepath.Join(dir, "a.txt")); err != nil {
+		t.Fatal(err)
+	}
+	if got := readFile(t, filepath.Join(dir, "a.txt")); got != "a" {
+		t.Errorf("a.txt = %q", got)
+	}
+	if _, err := os.Lstat(filepath.Join(dst, "a.txt")); !os.IsNotExist(err) {
+		t.Errorf("moved file still exists: %v", err)
+	}
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, e := range entries {
+		if strings.HasSuffix(e.Name(), ".tmp") {
+			t.Errorf("temporary copy %s left behind", e.Name())
+		}
+	}
+}
+
+func TestTrashAcrossFilesystems(t *testing.T) {
+	crossDevice(t)
+	trash := newTestTrash(t)
+	src := filepath.Join(t.TempDir(), "sessions")
+	writeFile(t, filepath.Join(src, "s1.json"), "{}", time.Now())
+
+	item, err := trash.Put(src, "continue")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := readFile(t, filepath.Join(trash.filePath(item.ID), "s1.json")); got != "{}" {
+		t.Errorf("trashed s1.json = %q", got)
+	}
+	if _, err := trash.Restore(item.ID, false); err != nil {
+		t.Fatal(err)
+	}
+	if got := readFile(t, filepath.Join(src, "s1.json")); got != "{}" {
+		t.Errorf("restored s1.json = %q", got)
+	}
+}
@@ -0,0 +1,74 @@
+package cleanup
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"ai-manager/internal/models"
+	"ai-manager/internal/workspace"
+)
+
+// CleanWorkspaces moves the storage of orphaned workspaces to the trash,
+// one result per tool. Workspaces whose folder has come back, or whose
+// storage a running AI tool is using, are skipped.
+func (c *Cleaner) CleanWorkspaces(ctx context.Context, workspaces []models.Workspace) []models.CleanupResult {
+	start := time.Now()
+	byTool := make(map[string]*models.CleanupResult)
+	order := make([]string, 0)
+
+	for _, ws := range workspaces {
+		if !ws.Orphaned {
+			continue
+		}
+		if ctx.Err() != nil {
+			break
+		}
+
+		result, ok := byTool[ws.Tool]
+		if !ok {
+			tool := c.cfg.Tools[ws.Tool]
+			result = &models.CleanupResult{Tool: tool.Name, Path: toolRoot(tool)}
+			byTool[ws.Tool] = result
+			order = append(order, ws.Tool)
+		}
+
+		if err := c.checkWorkspace(ws); err != nil {
+			result.FilesSkipped += ws.Files
+			result.Skipped = append(result.Skipped, err.Error())
+			continue
+		}
+		if _, err := c.trash.Put(ws.Path, ws.Tool); err != nil {
+			result.FilesSkipped += ws.Files
+			result.Skipped = append(result.Skipped, err.Error())
+			continue
+		}
+
+		result.FilesDeleted += ws.Files
+		result.FilesTrashed += ws.Files
+		result.SpaceFreed += ws.Size
+	}
+
+	results := make([]models.CleanupResult, 0, len(order))
+	for _, key := range order {
+		r := byTool[key]
+		r.Duration = time.Since(start)
+		results = append(results, *r)
+	}
+	return results
+}
+
+// checkWorkspace verifies that a workspace is still orphaned and that no
+// running AI tool uses its storage
+func (c *Cleaner) checkWorkspace(ws models.Workspace) error {
+	if tool, ok := c.cfg.Tools[ws.Tool]; !ok || !tool.Enabled {
+		return fmt.Errorf("%s: tool %q is not enabled", ws.Path, ws.Tool)
+	}
+	if !workspace.StillOrphaned(ws) {
+		return fmt.Errorf("%s: %s exists again", ws.Path, ws.Folder)
+	}
+	if f, ok := c.processes().openIn(ws.Path); ok {
+		return fmt.Errorf("%s: in use by %s (pid %d)", ws.Path, f.Tool, f.PID)
+	}
+	return nil
+}
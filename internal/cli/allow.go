@@ -0,0 +1,74 @@
+package cli
+
+import (
+	"fmt"
+	"os"
+
+	"ai-manager/internal/config"
+	"ai-manager/internal/safefile"
+
+	"github.com/spf13/cobra"
+)
+
+// newAllowCmd returns the allow command
+func newAllowCmd() *cobra.Command {
+	return &cobra.Command{
+		Use:   "allow [file]",
+		Short: "Trust a project .ai-manager.yaml",
+		Long: `Trust a project config, by default the nearest .ai-manager.yaml above
+the current directory. A project config is ignored until it is allowed,
+and again whenever its content changes, so cloning a repository and
+changing into it can't change your models or shell environment.
+
+Review the file before allowing it. Even when allowed, a project config
+can't set home_dir, a tool's paths or cleanup rules, API endpoints or
+variables other than *_MODEL, *_TIMEOUT_MS, *_TOKENS and DISABLE_*.`,
+		Args: cobra.MaximumNArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			return changeTrust(args, config.Allow, "Allowed")
+		},
+	}
+}
+
+// newDenyCmd returns the deny command
+func newDenyCmd() *cobra.Command {
+	return &cobra.Command{
+		Use:   "deny [file]",
+		Short: "Stop trusting a project .ai-manager.yaml",
+		Args:  cobra.MaximumNArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			return changeTrust(args, config.Deny, "Denied")
+		},
+	}
+}
+
+// changeTrust allows or denies the project config named in args, or the
+// nearest one
+func changeTrust(args []string, change func(stateDir, path string) error, done string) error {
+	path := ""
+	if len(args) == 1 {
+		path = args[0]
+	} else {
+		cwd, err := os.Getwd()
+		if err != nil {
+			return err
+		}
+		if path = config.FindProjectConfig(cwd); path == "" {
+			return fmt.Errorf("no %s found in this directory or above", config.ProjectConfigName)
+		}
+	}
+
+	dir, err := config.TrustStateDir(configPath())
+	if err != nil {
+		return err
+	}
+	err = safefile.WithLock(dir, func() error {
+		return change(dir, path)
+	})
+	if err != nil {
+		return err
+	}
+
+	fmt.Printf("[Done] %s %s\n", done, path)
+	return nil
+}
@@ -0,0 +1,150 @@
+package cli
+
+import (
+	"fmt"
+	"os"
+	"text/tabwriter"
+	"time"
+
+	"ai-manager/internal/cleanup"
+	"ai-manager/internal/models"
+	"ai-manager/internal/safefile"
+
+	"github.com/spf13/cobra"
+)
+
+var (
+	archiveTo    string
+	archiveForce bool
+)
+
+// newArchiveCmd returns the archive command group
+func newArchiveCmd() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "archive",
+		Short: "List and extract files archived by cleanup",
+		Long: `List and extract the tar.gz archives written by cleanup rules with
+"action: archive". Archives are kept in <home_dir>/archive, one per tool
+and cleanup run, with an index of the files each one holds.`,
+	}
+
+	cmd.AddCommand(
+		newArchiveListCmd(),
+		newArchiveExtractCmd(),
+	)
+	return cmd
+}
+
+// openArchive loads the configuration and returns its archive
+func openArchive() (*cleanup.Archive, error) {
+	cfg, err := loadConfig()
+	if err != nil {
+		return nil, err
+	}
+	return cleanup.NewArchive(cfg), nil
+}
+
+// newArchiveListCmd returns the archive list command
+func newArchiveListCmd() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "list [archive]",
+		Short: "List archives, or the files in one archive",
+		Args:  cobra.MaximumNArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			archive, err := openArchive()
+			if err != nil {
+				return err
+			}
+
+			if len(args) == 1 {
+				entry, err := archive.Get(args[0])
+				if err != nil {
+					return err
+				}
+				if jsonOutput {
+					return printJSON(entry)
+				}
+				printArchiveFiles(entry)
+				return nil
+			}
+
+			entries, err := archive.List()
+			if err != nil {
+				return err
+			}
+			if jsonOutput {
+				return printJSON(entries)
+			}
+			printArchives(entries)
+			return nil
+		},
+	}
+
+	cmd.Flags().BoolVar(&jsonOutput, "json", false, "Output in JSON format")
+	return cmd
+}
+
+// printArchives prints the archive index as a table
+func printArchives(entries []models.ArchiveEntry) {
+	fmt.Println("=== Archives ===")
+	if len(entries) == 0 {
+		fmt.Println("No archives")
+		return
+	}
+
+	w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(w, "NAME\tTOOL\tFILES\tSIZE\tORIGINAL\tCREATED")
+	for _, e := range entries {
+		fmt.Fprintf(w, "%s\t%s\t%d\t%s\t%s\t%s\n",
+			e.Name, e.Tool, len(e.Files), models.FormatBytes(e.Size),
+			models.FormatBytes(e.OriginalSize), e.Created.Format(time.DateTime))
+	}
+	w.Flush()
+}
+
+// printArchiveFiles prints the files in one archive
+func printArchiveFiles(entry models.ArchiveEntry) {
+	fmt.Printf("=== %s ===\n", entry.Name)
+
+	w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(w, "NAME\tSIZE\tMODIFIED\tORIGINAL PATH")
+	for _, f := range entry.Files {
+		fmt.Fprintf(w, "%s\t%s\t%s\t%s\n",
+			f.Name, models.FormatBytes(f.Size), f.ModTime.Format(time.DateTime), f.OriginalPath)
+	}
+	w.Flush()
+}
+
+// newArchiveExtractCmd returns the archive extract command
+func newArchiveExtractCmd() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "extract <archive> [file...]",
+		Short: "Extract archived files",
+		Long: `Extract files from an archive, by default all of them and back to
+their original paths. With --to, files are written below that directory
+under their names in the archive instead. Existing files are only
+replaced with --force.`,
+		Args: cobra.MinimumNArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			archive, err := openArchive()
+			if err != nil {
+				return err
+			}
+
+			var extracted []string
+			err = safefile.WithLock(stateDir(), func() error {
+				var err error
+				extracted, err = archive.Extract(args[0], archiveTo, args[1:], archiveForce)
+				return err
+			})
+			for _, path := range extracted {
+				fmt.Printf("[Done] Extracted %s\n", path)
+			}
+			return err
+		},
+	}
+
+	cmd.Flags().StringVar(&archiveTo, "to", "", "Extract below this directory instead of the original paths")
+	cmd.Flags().BoolVar(&archiveForce, "force", false, "Overwrite existing files")
+	return cmd
+}
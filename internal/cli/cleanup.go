@@ -0,0 +1,250 @@
+package cli
+
+import (
+	"fmt"
+	"os"
+	"sort"
+	"text/tabwriter"
+
+	"ai-manager/internal/cleanup"
+	"ai-manager/internal/models"
+	"ai-manager/internal/safefile"
+
+	"github.com/spf13/cobra"
+)
+
+var (
+	cleanupDryRun   bool
+	cleanupPlanFile string
+	cleanupApply    string
+	cleanupMaxSize  string
+)
+
+// newCleanupCmd returns the cleanup command with implementation
+func newCleanupCmd() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "cleanup",
+		Short: "Clean up temporary files",
+		Long: `Clean up temporary files from AI tools.
+Each tool's rules (tools.<tool>.rules) decide which files go and whether
+they are deleted, moved to the trash or compressed. Tools without rules
+clean their temp_paths: debug logs and shell snapshots after
+retention.debug_logs_days and retention.shell_snapshots_days, everything
+else after retention.temp_files_days (7 days, or --days).
+
+Trashed files can be restored with 'ai-mgr trash restore' until they
+expire after retention.trash_days.
+
+With --dry-run, nothing is deleted; every file that would be removed is
+listed with its tool, temp path, size, age and the rule that matched.
+--plan-file saves that list as JSON, and --apply deletes exactly the files
+in a saved plan, skipping any whose size or modification time changed
+since it was written:
+
+  ai-mgr cleanup --plan-file plan.json
+  ai-mgr cleanup --apply plan.json
+
+A tool with a quota (tools.<tool>.quota), or every tool with --max-size,
+is also trimmed to that size: the oldest files its rules cover are removed
+until its measured usage fits. keep_last still protects the newest files,
+and compressed files count as removed.`,
+		Args: cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			cfg, err := loadConfig()
+			if err != nil {
+				return err
+			}
+
+			// Override retention days if specified
+			if cmd.Flags().Changed("days") {
+				if days <= 0 {
+					return fmt.Errorf("--days must be greater than 0")
+				}
+				cfg.Retention.TempFiles = days
+			}
+
+			cleaner := cleanup.NewCleaner(cfg)
+
+			// A size budget applies to every tool, replacing their quotas
+			if cleanupMaxSize != "" {
+				if err := cleaner.SetMaxSize(cleanupMaxSize); err != nil {
+					return fmt.Errorf("--max-size: %w", err)
+				}
+			}
+
+			ctx, stop := walkContext(cmd)
+			defer stop()
+
+			if cleanupApply != "" {
+				plan, err := cleanup.ReadPlan(cleanupApply)
+				if err != nil {
+					return err
+				}
+				return runCleanup(cleaner, func() ([]models.CleanupResult, error) {
+					return cleaner.ApplyPlan(ctx, plan)
+				})
+			}
+
+			if cleanupDryRun || cleanupPlanFile != "" {
+				plan, err := cleaner.BuildPlan(ctx)
+				if err != nil {
+					return err
+				}
+
+				if cleanupPlanFile != "" {
+					if err := cleanup.WritePlan(cleanupPlanFile, plan); err != nil {
+						return err
+					}
+				}
+
+				if jsonOutput {
+					return printJSON(plan)
+				}
+				printPlan(plan)
+				if cleanupPlanFile != "" {
+					fmt.Printf("\nPlan written to %s (apply with: ai-mgr cleanup --apply %s)\n",
+						cleanupPlanFile, cleanupPlanFile)
+				}
+				return nil
+			}
+
+			return runCleanup(cleaner, func() ([]models.CleanupResult, error) {
+				return cleaner.CleanupAll(ctx)
+			})
+		},
+	}
+
+	cmd.Flags().IntVarP(&days, "days", "d", 7, "Override retention.temp_files_days for default rules")
+	cmd.Flags().BoolVarP(&verbose, "verbose", "v", false, "List skipped files")
+	cmd.Flags().StringVar(&cleanupMaxSize, "max-size", "", "Trim each tool to at most this size, e.g. 2GiB")
+	cmd.Flags().BoolVar(&cleanupDryRun, "dry-run", false, "List what would be deleted without deleting")
+	cmd.Flags().StringVar(&cleanupPlanFile, "plan-file", "", "Write the dry-run plan to a JSON file")
+	cmd.Flags().StringVar(&cleanupApply, "apply", "", "Delete exactly the files in a saved plan")
+	cmd.Flags().BoolVar(&jsonOutput, "json", false, "Output in JSON format")
+	cmd.MarkFlagsMutuallyExclusive("apply", "dry-run")
+	cmd.MarkFlagsMutuallyExclusive("apply", "plan-file")
+	cmd.MarkFlagsMutuallyExclusive("apply", "days")
+	cmd.MarkFlagsMutuallyExclusive("apply", "max-size")
+	return cmd
+}
+
+// runCleanup runs a cleanup under the ai-mgr lock, then purges expired
+// files from the trash and prints the results. An interrupted cleanup
+// prints what it did before returning the error.
+func runCleanup(cleaner *cleanup.Cleaner, run func() ([]models.CleanupResult, error)) error {
+	var results []models.CleanupResult
+	var purged []models.TrashItem
+	var runErr error
+	err := safefile.WithLock(stateDir(), func() error {
+		if results, runErr = run(); runErr != nil {
+			return nil
+		}
+		var err error
+		purged, err = cleaner.Trash().PurgeExpired()
+		return err
+	})
+	if err != nil {
+		return err
+	}
+	if results == nil {
+		return runErr
+	}
+
+	if jsonOutput {
+		if err := printJSON(results); err != nil {
+			return err
+		}
+		return runErr
+	}
+	printCleanupResults(results)
+
+	if len(purged) > 0 {
+		var size int64
+		for _, item := range purged {
+			size += item.Size
+		}
+		fmt.Printf("Purged %d expired files (%s) from the trash\n", len(purged), models.FormatBytes(size))
+	}
+	return runErr
+}
+
+// printCleanupResults prints the outcome of a cleanup
+func printCleanupResults(results []models.CleanupResult) {
+	totalFreed := int64(0)
+	totalSaved := int64(0)
+	totalDeleted := 0
+	totalSkipped := 0
+
+	fmt.Println("=== Cleanup Results ===")
+	for _, r := range results {
+		fmt.Println(cleanup.FormatResult(r))
+		for _, w := range r.Warnings {
+			fmt.Printf("  warning: %s\n", w)
+		}
+		if verbose {
+			for _, s := range r.Skipped {
+				fmt.Printf("  [Skip] %s\n", s)
+			}
+		}
+		totalFreed += r.SpaceFreed
+		totalSaved += r.SpaceSaved
+		totalDeleted += r.FilesDeleted
+		totalSkipped += r.FilesSkipped
+	}
+
+	fmt.Printf("\nTotal: %d files deleted, %s freed\n",
+		totalDeleted, models.FormatBytes(totalFreed))
+	if totalSaved > 0 {
+		fmt.Printf("Compression saved %s\n", models.FormatBytes(totalSaved))
+	}
+	if totalSkipped > 0 && !verbose {
+		fmt.Printf("%d files skipped (use -v for details)\n", totalSkipped)
+	}
+}
+
+// printPlan prints a cleanup plan as a table
+func printPlan(plan *cleanup.Plan) {
+	fmt.Println("=== Cleanup Plan (dry run) ===")
+	defer printPlanWarnings(plan)
+	if len(plan.Items) == 0 {
+		fmt.Println("Nothing to clean up")
+		return
+	}
+
+	w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(w, "TOOL\tACTION\tSIZE\tAGE\tRULE\tPATH")
+	for _, item := range plan.Items {
+		fmt.Fprintf(w, "%s\t%s\t%s\t%dd\t%s: %s\t%s\n",
+			item.ToolName, item.Action, models.FormatBytes(item.Size),
+			item.AgeDays, item.Rule, item.Reason, item.Path)
+	}
+	w.Flush()
+
+	fmt.Printf("\nTotal: %d files (%s)\n",
+		len(plan.Items), models.FormatBytes(plan.TotalSize()))
+
+	var quota int64
+	for _, item := range plan.Items {
+		if item.Quota {
+			quota += item.Size
+		}
+	}
+	if quota > 0 {
+		fmt.Printf("%s of it to fit tool quotas\n", models.FormatBytes(quota))
+	}
+}
+
+// printPlanWarnings prints the warnings of a plan, ordered by tool
+func printPlanWarnings(plan *cleanup.Plan) {
+	keys := make([]string, 0, len(plan.Warnings))
+	for k := range plan.Warnings {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+
+	for _, k := range keys {
+		for _, w := range plan.Warnings[k] {
+			fmt.Printf("warning: %s\n", w)
+		}
+	}
+}
@@ -4,20 +4,26 @@ import (
 	"encoding/json"
 	"fmt"
 	"os"
+	"path/filepath"
+	"sort"
+	"text/tabwriter"
+	"time"
 
-	"ai-manager/internal/cleanup"
+	"ai-manager/internal/adapter"
 	"ai-manager/internal/config"
 	"ai-manager/internal/discovery"
 	"ai-manager/internal/models"
 	"ai-manager/internal/utils"
+	"ai-manager/internal/walker"
 
 	"github.com/spf13/cobra"
 )
 
 var (
-	days int
-	verbose bool
+	days       int
+	verbose    bool
 	jsonOutput bool
+	refresh    bool
 )
 
 // newScanCmd returns the scan command with implementation
@@ -26,15 +32,33 @@ func newScanCmd() *cobra.Command {
 		Use:   "scan",
 		Short: "Scan for AI tools on your system",
 		Long: `Scan and discover AI tools installed on your system.
-Shows which tools are found, their paths, and disk usage.`,
+Shows which tools are found, their paths, and disk usage.
+
+With -v, each tool also shows when it was last used and its sessions
+per project, with projects whose directory no longer exists marked.
+
+Each tool's binary is looked up on PATH and where nvm, npm, bun and pipx
+install binaries, and run with --version. When several copies are on
+PATH, all of them are listed with the one PATH runs marked active.
+
+Disk usage comes from an index in the state directory, so only
+directories that changed since the last scan or stats are read again.
+--refresh rebuilds the index from scratch.`,
 		RunE: func(cmd *cobra.Command, args []string) error {
-			cfg, err := config.Load(config.GetDefaultConfigPath())
+			cfg, err := loadConfig()
 			if err != nil {
 				return err
 			}
 
+			ctx, stop := walkContext(cmd)
+			defer stop()
+
+			index := openUsageIndex(cfg)
+			defer saveUsageIndex(index)
+
 			scanner := discovery.NewScanner(cfg)
-			result, err := scanner.Scan()
+			scanner.UseIndex(index)
+			result, err := scanner.Scan(ctx)
 			if err != nil {
 				return err
 			}
@@ -50,162 +74,190 @@ Shows which tools are found, their paths, and disk usage.`,
 
 	cmd.Flags().BoolVarP(&verbose, "verbose", "v", false, "Show detailed information")
 	cmd.Flags().BoolVar(&jsonOutput, "json", false, "Output in JSON format")
+	cmd.Flags().BoolVar(&refresh, "refresh", false, "Rebuild the disk usage index")
 	return cmd
 }
 
-// newCleanupCmd returns the cleanup command with implementation
-func newCleanupCmd() *cobra.Command {
+// newCheckCmd returns the health check command
+func newCheckCmd() *cobra.Command {
 	cmd := &cobra.Command{
-		Use:   "cleanup",
-		Short: "Clean up temporary files",
-		Long: `Clean up temporary files from AI tools.
-By default, removes files older than 7 days.`,
+		Use:   "check",
+		Short: "Health check for AI tools",
+		Long: `Run health checks on your AI tools and configurations.
+Reports on configuration validity, broken links, and disk usage.`,
 		RunE: func(cmd *cobra.Command, args []string) error {
-			cfg, err := config.Load(config.GetDefaultConfigPath())
+			cfg, err := loadConfig()
 			if err != nil {
 				return err
 			}
 
-			// Override retention days if specified
-			if days > 0 {
-				cfg.Retention.TempFiles = days
+			keys := make([]string, 0, len(cfg.Tools))
+			for k, tool := range cfg.Tools {
+				if tool.Enabled {
+					keys = append(keys, k)
+				}
 			}
-
-			cleaner := cleanup.NewCleaner(cfg)
-			results, err := cleaner.CleanupAll()
-			if err != nil {
-				return err
+			sort.Strings(keys)
+
+			results := make([]models.ToolHealth, 0, len(keys))
+			for _, k := range keys {
+				tool := cfg.Tools[k]
+				results = append(results, models.ToolHealth{
+					Key:    k,
+					Tool:   tool.Name,
+					Checks: adapter.For(k).Health(tool),
+				})
 			}
 
 			if jsonOutput {
 				return printJSON(results)
 			}
 
-			totalFreed := int64(0)
-			totalDeleted := 0
-
-			fmt.Println("=== Cleanup Results ===")
-			for _, r := range results {
-				fmt.Println(cleanup.FormatResult(r))
-				totalFreed += r.SpaceFreed
-				totalDeleted += r.FilesDeleted
-			}
-
-			fmt.Printf("\nTotal: %d files deleted, %s freed\n",
-				totalDeleted, models.FormatBytes(totalFreed))
-
+			printHealth(results)
 			return nil
 		},
 	}
 
-	cmd.Flags().IntVarP(&days, "days", "d", 7, "Delete files older than N days")
 	cmd.Flags().BoolVar(&jsonOutput, "json", false, "Output in JSON format")
 	return cmd
 }
 
-// newCheckCmd returns the health check command
-func newCheckCmd() *cobra.Command {
+// printHealth prints the health checks of each tool
+func printHealth(results []models.ToolHealth) {
+	fmt.Println("=== AI Tools Health Check ===")
+	fmt.Println()
+
+	issues := 0
+	for _, r := range results {
+		fmt.Printf("[%s]\n", r.Tool)
+		for _, c := range r.Checks {
+			mark := "✓"
+			switch c.Status {
+			case models.StatusWarning:
+				mark = "⚠"
+				issues++
+			case models.StatusError:
+				mark = "✗"
+				issues++
+			}
+			fmt.Printf("  %s %s\n", mark, c.Message)
+		}
+		fmt.Println()
+	}
+
+	if issues > 0 {
+		fmt.Printf("Found %d issue(s)\n", issues)
+	} else {
+		fmt.Println("All tools are healthy!")
+	}
+}
+
+// newStatsCmd returns the stats command
+func newStatsCmd() *cobra.Command {
 	cmd := &cobra.Command{
-		Use:   "check",
-		Short: "Health check for AI tools",
-		Long: `Run health checks on your AI tools and configurations.
-Reports on configuration validity, broken links, and disk usage.`,
+		Use:   "stats",
+		Short: "Show usage statistics",
+		Long: `Show usage statistics and disk usage for AI tools.
+Each tool directory is walked once for its size, file count, the age of
+its files and its largest files. Like scan, stats only reads directories
+that changed since the last run; --refresh rebuilds the index.`,
 		RunE: func(cmd *cobra.Command, args []string) error {
-			cfg, err := config.Load(config.GetDefaultConfigPath())
+			cfg, err := loadConfig()
 			if err != nil {
 				return err
 			}
 
-			fmt.Println("=== AI Tools Health Check ===\n")
-
-			issues := 0
-			for _, tool := range cfg.Tools {
-				if !tool.Enabled {
-					continue
-				}
-
-				toolPath := utils.ExpandPath(tool.Path)
-				configPath := utils.ExpandPath(tool.ConfigPath)
+			ctx, stop := walkContext(cmd)
+			defer stop()
 
-				fmt.Printf("[%s]\n", tool.Name)
+			index := openUsageIndex(cfg)
+			defer saveUsageIndex(index)
 
-				// Check if tool exists
-				if _, err := os.Stat(toolPath); os.IsNotExist(err) {
-					fmt.Printf("  ✗ Tool path not found: %s\n", toolPath)
-					issues++
-					continue
+			keys := make([]string, 0, len(cfg.Tools))
+			for k, tool := range cfg.Tools {
+				if tool.Enabled {
+					keys = append(keys, k)
 				}
-				fmt.Printf("  ✓ Path exists: %s\n", toolPath)
-
-				// Check config file
-				if _, err := os.Stat(configPath); os.IsNotExist(err) {
-					fmt.Printf("  ⚠ Config file missing: %s\n", configPath)
-					issues++
-				} else {
-					fmt.Printf("  ✓ Config file found: %s\n", configPath)
+			}
+			sort.Strings(keys)
+
+			stats := make([]models.ToolStats, 0, len(keys))
+			for _, k := range keys {
+				tool := cfg.Tools[k]
+				summary, err := index.Usage(ctx, utils.ExpandPath(tool.Path), walker.Options{Largest: 5})
+				if err != nil {
+					return err
 				}
-
-				// Check symlinks if any
-				fmt.Println()
+				stats = append(stats, models.ToolStats{Key: k, Tool: tool.Name, Summary: summary})
 			}
 
-			if issues > 0 {
-				fmt.Printf("Found %d issue(s)\n", issues)
-			} else {
-				fmt.Println("All tools are healthy!")
+			if jsonOutput {
+				return printJSON(stats)
 			}
 
+			printStats(stats)
 			return nil
 		},
 	}
 
 	cmd.Flags().BoolVar(&jsonOutput, "json", false, "Output in JSON format")
+	cmd.Flags().BoolVar(&refresh, "refresh", false, "Rebuild the disk usage index")
 	return cmd
 }
 
-// newStatsCmd returns the stats command
-func newStatsCmd() *cobra.Command {
-	cmd := &cobra.Command{
-		Use:   "stats",
-		Short: "Show usage statistics",
-		Long: `Show usage statistics and disk usage for AI tools.`,
-		RunE: func(cmd *cobra.Command, args []string) error {
-			cfg, err := config.Load(config.GetDefaultConfigPath())
-			if err != nil {
-				return err
-			}
+// openUsageIndex opens the disk usage index in the state directory,
+// emptied first with --refresh
+func openUsageIndex(cfg *config.Config) *walker.Index {
+	index := walker.OpenIndex(filepath.Join(cfg.StateDir(), walker.IndexFileName))
+	if refresh {
+		index.Reset()
+	}
+	return index
+}
 
-			fmt.Println("=== AI Tools Disk Usage ===\n")
+// saveUsageIndex stores the index for the next run. The index is only a
+// cache, so failing to save it is not an error.
+func saveUsageIndex(index *walker.Index) {
+	if err := index.Save(); err != nil {
+		fmt.Fprintf(os.Stderr, "warning: failed to save the disk usage index: %v\n", err)
+	}
+}
 
-			totalSize := int64(0)
-			totalFiles := 0
+// printStats prints the disk usage of each tool
+func printStats(stats []models.ToolStats) {
+	fmt.Println("=== AI Tools Disk Usage ===")
+	fmt.Println()
 
-			for _, tool := range cfg.Tools {
-				if !tool.Enabled {
-					continue
-				}
+	totalSize := int64(0)
+	totalFiles := 0
 
-				path := utils.ExpandPath(tool.Path)
-				size, _ := utils.FileSize(path)
-				files, _ := utils.CountFiles(path)
+	for _, st := range stats {
+		totalSize += st.Size
+		totalFiles += st.Files
 
-				totalSize += size
-				totalFiles += files
+		fmt.Printf("[%s]\n", st.Tool)
+		fmt.Printf("  Path: %s\n", st.Path)
+		fmt.Printf("  Size: %s (%d files in %d directories)\n", utils.FormatSize(st.Size), st.Files, st.Dirs)
 
-				fmt.Printf("[%s]\n", tool.Name)
-				fmt.Printf("  Path: %s\n", path)
-				fmt.Printf("  Size: %s (%d files)\n\n", utils.FormatSize(size), files)
+		if st.Files > 0 {
+			fmt.Println("  Age:")
+			for _, b := range st.Ages {
+				if b.Files > 0 {
+					fmt.Printf("    %-11s %10s  %d files\n", b.Label, utils.FormatSize(b.Size), b.Files)
+				}
 			}
-
-			fmt.Printf("Total: %s (%d files across %d tools)\n",
-				utils.FormatSize(totalSize), totalFiles, len(cfg.Tools))
-
-			return nil
-		},
+		}
+		if len(st.Largest) > 0 {
+			fmt.Println("  Largest:")
+			for _, f := range st.Largest {
+				fmt.Printf("    %10s  %s\n", utils.FormatSize(f.Size), f.Path)
+			}
+		}
+		fmt.Println()
 	}
 
-	cmd.Flags().BoolVar(&jsonOutput, "json", false, "Output in JSON format")
-	return cmd
+	fmt.Printf("Total: %s (%d files across %d tools)\n",
+		utils.FormatSize(totalSize), totalFiles, len(stats))
 }
 
 // Helper functions
@@ -225,12 +277,25 @@ func printScanResult(result *models.ScanResult, verbose bool) {
 
 		if tool.Found {
 			fmt.Printf("  Path: %s\n", tool.Path)
+			if tool.Version != "" {
+				fmt.Printf("  Version: %s (%s)\n", tool.Version, tool.InstallMethod)
+			}
+			if tool.Shadowed {
+				fmt.Printf("  ⚠ %d copies installed, more than one on PATH:\n", len(tool.Binaries))
+			}
+			if tool.Shadowed || verbose {
+				printBinaries(tool.Binaries)
+			}
 			if verbose {
 				fmt.Printf("  Config: %s\n", tool.ConfigPath)
 				fmt.Printf("  Data: %s\n", tool.DataPath)
 				fmt.Printf("  Disk: %s (%d files)\n",
 					models.FormatBytes(tool.DiskUsage.SizeBytes),
 					tool.DiskUsage.Files)
+				if !tool.LastUsed.IsZero() {
+					fmt.Printf("  Last used: %s (%s)\n", tool.LastUsed.Format(time.DateTime), daysAgo(tool.LastUsed))
+				}
+				printProjects(tool.Projects)
 			}
 		} else {
 			fmt.Printf("  Not found on system\n")
@@ -239,6 +304,64 @@ func printScanResult(result *models.ScanResult, verbose bool) {
 	}
 }
 
+// printProjects lists a tool's activity per project, most recent first
+func printProjects(projects []models.ProjectActivity) {
+	if len(projects) == 0 {
+		return
+	}
+
+	fmt.Println("  Projects:")
+	w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	for _, p := range projects {
+		name := p.Project
+		if name == "" {
+			name = "(unknown)"
+		}
+		if p.Missing {
+			name += " (missing)"
+		}
+		sessions := fmt.Sprintf("%d sessions", p.Sessions)
+		if p.Sessions == 1 {
+			sessions = "1 session"
+		}
+		fmt.Fprintf(w, "    %s\t%s\t%s\t%s\n",
+			name, sessions, models.FormatBytes(p.Size), daysAgo(p.LastActive))
+	}
+	w.Flush()
+}
+
+// daysAgo describes how long ago t was in whole days
+func daysAgo(t time.Time) string {
+	switch days := int(time.Since(t).Hours() / 24); days {
+	case 0:
+		return "today"
+	case 1:
+		return "1 day ago"
+	default:
+		return fmt.Sprintf("%d days ago", days)
+	}
+}
+
+// printBinaries lists the copies of a tool's binary, marking the one PATH
+// runs
+func printBinaries(binaries []models.Binary) {
+	for _, b := range binaries {
+		status := "not on PATH"
+		switch {
+		case b.Active:
+			status = "active"
+		case b.OnPath:
+			status = "shadowed"
+		}
+
+		version := b.Version
+		if b.Error != "" {
+			version = "error: " + b.Error
+		}
+		fmt.Printf("    %s [%s, %s] %s\n", b.Path, b.Method, status, version)
+	}
+}
+
 // printJSON outputs data as JSON
 func printJSON(data interface{}) error {
 	b, err := json.MarshalIndent(data, "", "  ")
@@ -0,0 +1,444 @@
+package cli
+
+import (
+	"bufio"
+	"bytes"
+	"fmt"
+	"os"
+	"os/exec"
+	"strings"
+	"text/tabwriter"
+	"time"
+
+	"ai-manager/internal/config"
+	"ai-manager/internal/safefile"
+	"ai-manager/internal/utils"
+
+	"github.com/spf13/cobra"
+	"gopkg.in/yaml.v3"
+)
+
+var (
+	showOrigin    bool
+	migrateDryRun bool
+)
+
+// newConfigCmd returns the config command group
+func newConfigCmd() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "config",
+		Short: "Inspect and manage configuration",
+		Long: `Inspect and manage the ai-mgr configuration.
+
+Configuration is merged from these layers, later ones winning:
+  default   built-in defaults
+  system    /etc/ai-manager/config.yaml
+  user      ~/.ai-manager/config.yaml
+  project   nearest .ai-manager.yaml above the current directory`,
+	}
+
+	cmd.AddCommand(
+		newConfigShowCmd(),
+		newConfigValidateCmd(),
+		newConfigMigrateCmd(),
+		newConfigGetCmd(),
+		newConfigSetCmd(),
+		newConfigUnsetCmd(),
+		newConfigEditCmd(),
+	)
+	return cmd
+}
+
+// newConfigShowCmd returns the config show command
+func newConfigShowCmd() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "show",
+		Short: "Show the effective configuration",
+		Long: `Show the effective configuration after all layers are merged.
+With --origin, every value is listed with the layer that supplied it.`,
+		Args: cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			cfg, err := loadConfig()
+			if err != nil {
+				return err
+			}
+
+			if showOrigin {
+				if jsonOutput {
+					return printJSON(cfg.Values())
+				}
+				printOrigins(cfg)
+				return nil
+			}
+
+			if jsonOutput {
+				return printJSON(cfg)
+			}
+
+			data, err := yaml.Marshal(cfg)
+			if err != nil {
+				return err
+			}
+			fmt.Print(string(data))
+			return nil
+		},
+	}
+
+	cmd.Flags().BoolVar(&showOrigin, "origin", false, "Show which layer supplied each value")
+	cmd.Flags().BoolVar(&jsonOutput, "json", false, "Output in JSON format")
+	return cmd
+}
+
+// printOrigins prints each resolved value with its origin
+func printOrigins(cfg *config.Config) {
+	w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(w, "KEY\tVALUE\tORIGIN")
+	for _, v := range cfg.Values() {
+		fmt.Fprintf(w, "%s\t%s\t%s\n", v.Key, v.Value, v.Origin)
+	}
+	w.Flush()
+}
+
+// newConfigValidateCmd returns the config validate command
+func newConfigValidateCmd() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "validate [file]",
+		Short: "Validate the configuration",
+		Long: `Validate the merged configuration, or the given file in place of the
+user configuration. Reports unknown keys, invalid retention values,
+undefined default models, malformed endpoints, duplicate tool paths and
+temp paths that escape their tool directory.`,
+		Args: cobra.MaximumNArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			path := configPath()
+			if len(args) == 1 {
+				path = args[0]
+				if _, err := os.Stat(path); err != nil {
+					return err
+				}
+			}
+
+			cfg, err := config.LoadUnchecked(path)
+			if err != nil {
+				return err
+			}
+
+			issues := config.Validate(cfg)
+			if jsonOutput {
+				if err := printJSON(issues); err != nil {
+					return err
+				}
+			} else {
+				printIssues(issues)
+			}
+
+			if config.HasFatal(issues) {
+				return fmt.Errorf("configuration is invalid")
+			}
+			return nil
+		},
+	}
+
+	cmd.Flags().BoolVar(&jsonOutput, "json", false, "Output in JSON format")
+	return cmd
+}
+
+// printIssues prints validation issues
+func printIssues(issues []config.Issue) {
+	if len(issues) == 0 {
+		fmt.Println("Configuration is valid")
+		return
+	}
+
+	for _, i := range issues {
+		level := "warning"
+		if i.Fatal {
+			level = "error"
+		}
+		fmt.Printf("%s: %s\n", level, i)
+	}
+}
+
+// newConfigMigrateCmd returns the config migrate command
+func newConfigMigrateCmd() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "migrate",
+		Short: "Upgrade the config file to the current schema",
+		Long: `Upgrade config.yaml to the current schema version step by step,
+keeping comments. The original is backed up next to it first.
+With --dry-run, only the resulting diff is shown.`,
+		Args: cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			path := configPath()
+			data, err := os.ReadFile(path)
+			if err != nil {
+				return err
+			}
+
+			result, err := config.Migrate(data)
+			if err != nil {
+				return fmt.Errorf("%s: %w", path, err)
+			}
+
+			if !result.Changed() {
+				fmt.Printf("%s is up to date (version %s)\n", path, result.To)
+				return nil
+			}
+
+			for _, m := range result.Applied {
+				fmt.Printf("%s -> %s: %s\n", m.From, m.To, m.Description)
+			}
+
+			if migrateDryRun {
+				fmt.Println()
+				fmt.Print(utils.UnifiedDiff(path, path+" (migrated)", string(data), string(result.Data)))
+				return nil
+			}
+
+			var backup string
+			err = safefile.WithLock(stateDir(), func() error {
+				current, err := os.ReadFile(path)
+				if err != nil {
+					return err
+				}
+				if !bytes.Equal(current, data) {
+					return fmt.Errorf("%s changed while migrating, try again", path)
+				}
+
+				backup, err = backupFile(path, data, result.From)
+				if err != nil {
+					return err
+				}
+				return safefile.WriteFile(path, result.Data, 0644)
+			})
+			if err != nil {
+				return err
+			}
+
+			fmt.Printf("Migrated %s to version %s (backup: %s)\n", path, result.To, backup)
+			return nil
+		},
+	}
+
+	cmd.Flags().BoolVar(&migrateDryRun, "dry-run", false, "Show the diff without writing")
+	return cmd
+}
+
+// backupFile saves data next to path, tagged with the old version
+func backupFile(path string, data []byte, version string) (string, error) {
+	backup := fmt.Sprintf("%s.%s.bak", path, version)
+	if _, err := os.Stat(backup); err == nil {
+		backup = fmt.Sprintf("%s.%s.%s.bak", path, version, time.Now().Format("20060102-150405"))
+	}
+
+	info, err := os.Stat(path)
+	if err != nil {
+		return "", err
+	}
+	return backup, safefile.WriteFile(backup, data, info.Mode().Perm())
+}
+
+// newConfigGetCmd returns the config get command
+func newConfigGetCmd() *cobra.Command {
+	return &cobra.Command{
+		Use:   "get <key>",
+		Short: "Print an effective configuration value",
+		Long: `Print the effective value at a dotted key path, e.g.
+
+  ai-mgr config get tools.claude.enabled
+  ai-mgr config get models.glm-4.7`,
+		Args: cobra.ExactArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			cfg, err := loadConfig()
+			if err != nil {
+				return err
+			}
+
+			node, err := cfg.Get(args[0])
+			if err != nil {
+				return err
+			}
+			if node == nil {
+				return fmt.Errorf("%s is not set", args[0])
+			}
+
+			if node.Kind == yaml.ScalarNode {
+				fmt.Println(node.Value)
+				return nil
+			}
+
+			data, err := yaml.Marshal(node)
+			if err != nil {
+				return err
+			}
+			fmt.Print(string(data))
+			return nil
+		},
+	}
+}
+
+// newConfigSetCmd returns the config set command
+func newConfigSetCmd() *cobra.Command {
+	return &cobra.Command{
+		Use:   "set <key> <value>",
+		Short: "Set a value in the user config file",
+		Long: `Set a value in the user config file, keeping comments and key order.
+The value is parsed as YAML, so numbers, booleans and [lists] work:
+
+  ai-mgr config set retention.temp_files_days 3
+  ai-mgr config set tools.gemini.enabled false`,
+		Args: cobra.ExactArgs(2),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			return editUserConfig(func(doc *config.Document) error {
+				return doc.Set(args[0], args[1])
+			})
+		},
+	}
+}
+
+// newConfigUnsetCmd returns the config unset command
+func newConfigUnsetCmd() *cobra.Command {
+	return &cobra.Command{
+		Use:   "unset <key>",
+		Short: "Remove a value from the user config file",
+		Long: `Remove a value from the user config file, so the value from a lower
+layer (or the built-in default) applies again.`,
+		Args: cobra.ExactArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			return editUserConfig(func(doc *config.Document) error {
+				found, err := doc.Unset(args[0])
+				if err != nil {
+					return err
+				}
+				if !found {
+					return fmt.Errorf("%s is not set in %s", args[0], doc.Path())
+				}
+				return nil
+			})
+		},
+	}
+}
+
+// editUserConfig applies an edit to the user config file and saves it only
+// if the result is valid
+func editUserConfig(edit func(doc *config.Document) error) error {
+	return safefile.WithLock(stateDir(), func() error {
+		return editUserConfigLocked(edit)
+	})
+}
+
+// editUserConfigLocked performs an edit while the ai-mgr lock is held
+func editUserConfigLocked(edit func(doc *config.Document) error) error {
+	path := configPath()
+	doc, err := config.OpenDocument(path)
+	if err != nil {
+		return err
+	}
+
+	if err := edit(doc); err != nil {
+		return err
+	}
+
+	data, err := doc.Bytes()
+	if err != nil {
+		return err
+	}
+
+	issues, err := config.ValidateUserData(path, data)
+	if err != nil {
+		return err
+	}
+	if config.HasFatal(issues) {
+		printIssues(issues)
+		return fmt.Errorf("not saved: the change makes the configuration invalid")
+	}
+
+	return doc.Save()
+}
+
+// newConfigEditCmd returns the config edit command
+func newConfigEditCmd() *cobra.Command {
+	return &cobra.Command{
+		Use:   "edit",
+		Short: "Edit the user config file in $EDITOR",
+		Long: `Open the user config file in $EDITOR (or vi). The file is validated
+when the editor exits and only saved if it is valid; otherwise you can
+reopen the editor to fix it.`,
+		Args: cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			path := configPath()
+			original, err := os.ReadFile(path)
+			if err != nil && !os.IsNotExist(err) {
+				return err
+			}
+
+			tmp, err := os.CreateTemp("", "ai-mgr-config-*.yaml")
+			if err != nil {
+				return err
+			}
+			defer os.Remove(tmp.Name())
+			if _, err := tmp.Write(original); err != nil {
+				tmp.Close()
+				return err
+			}
+			tmp.Close()
+
+			reader := bufio.NewReader(os.Stdin)
+			for {
+				if err := runEditor(tmp.Name()); err != nil {
+					return err
+				}
+
+				data, err := os.ReadFile(tmp.Name())
+				if err != nil {
+					return err
+				}
+				if bytes.Equal(data, original) {
+					fmt.Println("No changes")
+					return nil
+				}
+
+				issues, err := config.ValidateUserData(path, data)
+				if err != nil {
+					issues = []config.Issue{{Message: err.Error(), Fatal: true}}
+				}
+				if !config.HasFatal(issues) {
+					err := safefile.WithLock(stateDir(), func() error {
+						return safefile.WriteFile(path, data, 0644)
+					})
+					if err != nil {
+						return err
+					}
+					fmt.Printf("Saved %s\n", path)
+					return nil
+				}
+
+				printIssues(issues)
+				fmt.Print("Reopen the editor? [Y/n] ")
+				answer, _ := reader.ReadString('\n')
+				if a := strings.ToLower(strings.TrimSpace(answer)); a == "n" || a == "no" {
+					return fmt.Errorf("not saved: configuration is invalid")
+				}
+			}
+		},
+	}
+}
+
+// runEditor opens path in the user's editor
+func runEditor(path string) error {
+	editor := os.Getenv("VISUAL")
+	if editor == "" {
+		editor = os.Getenv("EDITOR")
+	}
+	if editor == "" {
+		editor = "vi"
+	}
+
+	// EDITOR may carry arguments, e.g. "code --wait"
+	parts := strings.Fields(editor)
+	c := exec.Command(parts[0], append(parts[1:], path)...)
+	c.Stdin = os.Stdin
+	c.Stdout = os.Stdout
+	c.Stderr = os.Stderr
+	return c.Run()
+}
@@ -0,0 +1,153 @@
+package cli
+
+import (
+	"fmt"
+	"os"
+	"strings"
+
+	"ai-manager/internal/config"
+	"ai-manager/internal/switcher"
+
+	"github.com/spf13/cobra"
+)
+
+// envActiveProject records the project config whose model the hook applied
+const envActiveProject = "AI_MGR_PROJECT"
+
+// hookScripts are the shell snippets installed by `ai-mgr hook`. Each one
+// calls `ai-mgr hook-env` whenever the working directory changes.
+var hookScripts = map[string]string{
+	"zsh": `_ai_mgr_hook() {
+  eval "$(%[1]s hook-env --shell zsh)"
+}
+typeset -ag chpwd_functions
+if (( ! ${chpwd_functions[(I)_ai_mgr_hook]} )); then
+  chpwd_functions=(_ai_mgr_hook $chpwd_functions)
+fi
+_ai_mgr_hook
+`,
+	"bash": `_ai_mgr_hook() {
+  local previous_exit_status=$?
+  if [[ "$PWD" != "$_ai_mgr_last_pwd" ]]; then
+    _ai_mgr_last_pwd="$PWD"
+    eval "$(%[1]s hook-env --shell bash)"
+  fi
+  return $previous_exit_status
+}
+if [[ ";${PROMPT_COMMAND[*]:-};" != *";_ai_mgr_hook;"* ]]; then
+  PROMPT_COMMAND="_ai_mgr_hook${PROMPT_COMMAND:+;$PROMPT_COMMAND}"
+fi
+`,
+	"fish": `function _ai_mgr_hook --on-variable PWD
+    %[1]s hook-env --shell fish | source
+end
+_ai_mgr_hook
+`,
+}
+
+// newHookCmd returns the hook command
+func newHookCmd() *cobra.Command {
+	return &cobra.Command{
+		Use:   "hook <shell>",
+		Short: "Print a shell hook for per-directory models",
+		Long: `Print a shell hook that applies the model of the nearest project
+.ai-manager.yaml whenever you change directory, and removes it again when
+you leave the project. Add one of these to your shell's rc file:
+
+  eval "$(ai-mgr hook zsh)"     # ~/.zshrc
+  eval "$(ai-mgr hook bash)"    # ~/.bashrc
+  ai-mgr hook fish | source     # ~/.config/fish/config.fish`,
+		Args:      cobra.ExactArgs(1),
+		ValidArgs: switcher.Shells,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			script, ok := hookScripts[args[0]]
+			if !ok {
+				return fmt.Errorf("unsupported shell: %s (supported: %s)",
+					args[0], strings.Join(switcher.Shells, ", "))
+			}
+
+			exe, err := os.Executable()
+			if err != nil {
+				exe = "ai-mgr"
+			}
+
+			fmt.Printf(script, fmt.Sprintf("%q", exe))
+			return nil
+		},
+	}
+}
+
+// newHookEnvCmd returns the hidden command the shell hook runs on cd
+func newHookEnvCmd() *cobra.Command {
+	var shell string
+
+	cmd := &cobra.Command{
+		Use:    "hook-env",
+		Short:  "Print environment changes for the current directory",
+		Hidden: true,
+		Args:   cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			if !switcher.IsShell(shell) {
+				return fmt.Errorf("unsupported shell: %s", shell)
+			}
+
+			cfg, err := loadConfig()
+			if err != nil {
+				return err
+			}
+
+			out, err := hookEnv(cfg, shell, os.Getenv(envActiveProject), os.Getenv(switcher.EnvActiveModel))
+			if err != nil {
+				return err
+			}
+			fmt.Print(out)
+			return nil
+		},
+	}
+
+	cmd.Flags().StringVar(&shell, "shell", "bash", "Shell syntax: bash, zsh or fish")
+	return cmd
+}
+
+// hookEnv computes the statements needed when entering or leaving a project.
+// Nothing is printed while staying inside the same project, so a model
+// switched by hand there is kept until the project is left.
+func hookEnv(cfg *config.Config, shell, activeProject, activeModel string) (string, error) {
+	project := cfg.ProjectPath
+	if project == activeProject {
+		return "", nil
+	}
+
+	// Only a model the allowed project config chose itself
+	modelKey := ""
+	if o, ok := cfg.Origin("defaults.model"); ok && project != "" && o.Layer == config.LayerProject {
+		modelKey = cfg.Defaults.Model
+	}
+
+	// Entering a project without a model, or leaving one that had none
+	if modelKey == "" && activeProject == "" {
+		if project == "" {
+			return "", nil
+		}
+		return switcher.ExportStatement(shell, envActiveProject, project), nil
+	}
+
+	out, err := switcher.ShellEnv(cfg, shell, previousHookModel(activeProject, activeModel), modelKey)
+	if err != nil {
+		return "", err
+	}
+
+	if project == "" {
+		return out + switcher.UnsetStatement(shell, envActiveProject), nil
+	}
+	return out + switcher.ExportStatement(shell, envActiveProject, project), nil
+}
+
+// previousHookModel returns the model the hook applied, if any. A model the
+// user exported by hand outside a project is left alone.
+func previousHookModel(activeProject, activeModel string) string {
+	if activeProject == "" {
+		return ""
+	}
+	return activeModel
+}
@@ -0,0 +1,218 @@
+package cli
+
+import (
+	"bufio"
+	"context"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"ai-manager/internal/adapter"
+	"ai-manager/internal/config"
+	"ai-manager/internal/discovery"
+	"ai-manager/internal/utils"
+
+	"github.com/spf13/cobra"
+)
+
+var (
+	initYes   bool
+	initForce bool
+)
+
+// newInitCmd returns the init command
+func newInitCmd() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "init",
+		Short: "Create a config file for this machine",
+		Long: `Create a commented config.yaml tailored to this machine.
+Scans for AI tools and keeps only those actually installed, honors
+XDG_CONFIG_HOME, and asks which models to register and which one to use
+by default. With --yes, all built-in models are registered without asking.
+An existing config file is only replaced with --force.`,
+		Args: cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			path := configPath()
+			if _, err := os.Stat(path); err == nil && !initForce {
+				return fmt.Errorf("%s already exists (use --force to overwrite)", path)
+			}
+
+			cfg := config.Default()
+			if homeFlag != "" {
+				cfg.HomeDir = homeFlag
+			}
+			applyXDGConfigHome(cfg)
+
+			ctx, stop := walkContext(cmd)
+			defer stop()
+
+			missing, err := keepInstalledTools(ctx, cfg)
+			if err != nil {
+				return err
+			}
+
+			in := bufio.NewReader(os.Stdin)
+			if !initYes {
+				if err := chooseModels(cfg, in, os.Stdout); err != nil {
+					return err
+				}
+			}
+
+			if err := config.CreateDefaultConfig(cfg, missing, path, initForce); err != nil {
+				return err
+			}
+
+			fmt.Printf("\nWrote %s\n", path)
+			return nil
+		},
+	}
+
+	cmd.Flags().BoolVarP(&initYes, "yes", "y", false, "Accept the defaults without asking")
+	cmd.Flags().BoolVar(&initForce, "force", false, "Overwrite an existing config file")
+	return cmd
+}
+
+// applyXDGConfigHome moves tools that live under ~/.config to
+// $XDG_CONFIG_HOME when it is set to somewhere else
+func applyXDGConfigHome(cfg *config.Config) {
+	xdg := os.Getenv("XDG_CONFIG_HOME")
+	if xdg == "" || filepath.Clean(xdg) == filepath.Join(utils.HomeDir(), ".config") {
+		return
+	}
+
+	for key, tool := range cfg.Tools {
+		if rest, ok := strings.CutPrefix(tool.Path, "~/.config/"); ok {
+			tool.Path = filepath.Join(xdg, rest)
+			cfg.Tools[key] = tool
+		}
+	}
+}
+
+// keepInstalledTools drops tools that aren't installed and returns their
+// keys. Installed tools that are disabled by default are listed with how to
+// enable them.
+func keepInstalledTools(ctx context.Context, cfg *config.Config) ([]string, error) {
+	result, err := discovery.NewScanner(cfg).Scan(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	sort.Slice(result.Tools, func(i, j int) bool {
+		return result.Tools[i].Key < result.Tools[j].Key
+	})
+
+	fmt.Println("=== Tools ===")
+	var missing []string
+	for _, info := range result.Tools {
+		if info.Found {
+			fmt.Printf("  ✓ %s (%s)\n", info.Name, info.Path)
+			continue
+		}
+		fmt.Printf("  ✗ %s (not found, disabled)\n", info.Name)
+		missing = append(missing, info.Key)
+		delete(cfg.Tools, info.Key)
+	}
+
+	keys := make([]string, 0, len(cfg.Tools))
+	for k := range cfg.Tools {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	for _, k := range keys {
+		tool := cfg.Tools[k]
+		if !tool.Enabled && adapter.For(k).Detect(tool) {
+			fmt.Printf("  - %s (%s, disabled by default: ai-mgr config set tools.%s.enabled true)\n", tool.Name, tool.Path, k)
+		}
+	}
+
+	return missing, nil
+}
+
+// chooseModels asks which models to register and which one is the default.
+// Declined models are disabled rather than left out, since built-in models
+// are merged back in otherwise.
+func chooseModels(cfg *config.Config, in *bufio.Reader, out io.Writer) error {
+	keys := make([]string, 0, len(cfg.Models))
+	for k := range cfg.Models {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+
+	enabled := func(k string) bool {
+		m, ok := cfg.Models[k]
+		return ok && !m.Disabled
+	}
+
+	fmt.Fprintln(out)
+	fmt.Fprintln(out, "=== Models ===")
+	var registered []string
+	for _, k := range keys {
+		m := cfg.Models[k]
+		ok, err := askYesNo(in, out, fmt.Sprintf("Register %s (%s, %s)?", k, m.Name, m.Provider), true)
+		if err != nil {
+			return err
+		}
+		if !ok {
+			m.Disabled = true
+			cfg.Models[k] = m
+			continue
+		}
+		registered = append(registered, k)
+	}
+
+	if len(registered) == 0 {
+		cfg.Defaults.Model = ""
+		return nil
+	}
+
+	def := cfg.Defaults.Model
+	if !enabled(def) {
+		def = registered[0]
+	}
+
+	for {
+		fmt.Fprintf(out, "Default model [%s]: ", def)
+		answer, err := in.ReadString('\n')
+		if err != nil && err != io.EOF {
+			return err
+		}
+		answer = strings.TrimSpace(answer)
+		if answer == "" {
+			answer = def
+		}
+		if enabled(answer) {
+			cfg.Defaults.Model = answer
+			return nil
+		}
+		fmt.Fprintf(out, "Unknown model %q\n", answer)
+		if err == io.EOF {
+			return fmt.Errorf("no valid default model given")
+		}
+	}
+}
+
+// askYesNo asks a yes/no question, returning def on an empty answer
+func askYesNo(in *bufio.Reader, out io.Writer, question string, def bool) (bool, error) {
+	hint := "[Y/n]"
+	if !def {
+		hint = "[y/N]"
+	}
+	fmt.Fprintf(out, "%s %s ", question, hint)
+
+	answer, err := in.ReadString('\n')
+	if err != nil && err != io.EOF {
+		return false, err
+	}
+
+	switch strings.ToLower(strings.TrimSpace(answer)) {
+	case "":
+		return def, nil
+	case "y", "yes":
+		return true, nil
+	default:
+		return false, nil
+	}
+}
@@ -1,9 +1,24 @@
 package cli
 
 import (
+	"context"
+	"fmt"
+	"os"
+	"os/signal"
+	"syscall"
+
+	"ai-manager/internal/config"
+	"ai-manager/internal/utils"
+
 	"github.com/spf13/cobra"
 )
 
+// Global flags shared by every command
+var (
+	configFlag string
+	homeFlag   string
+)
+
 var rootCmd = &cobra.Command{
 	Use:   "ai-mgr",
 	Short: "AI Tools Manager - Unified management for AI development tools",
@@ -19,12 +34,72 @@ Features:
 	SilenceUsage: true,
 }
 
+func init() {
+	rootCmd.PersistentFlags().StringVar(&configFlag, "config", "",
+		"Config file (default: $"+config.EnvConfigPath+" or ~/.ai-manager/config.yaml)")
+	rootCmd.PersistentFlags().StringVar(&homeFlag, "home", "",
+		"State directory, overriding home_dir from the config")
+}
+
+// configPath returns the config file selected by --config, AI_MGR_CONFIG
+// or --home
+func configPath() string {
+	return config.ResolveConfigPath(configFlag, homeFlag)
+}
+
+// loadConfig loads the selected configuration, applies --home and prints
+// non-fatal validation issues
+func loadConfig() (*config.Config, error) {
+	cfg, err := config.Load(configPath())
+	if err != nil {
+		return nil, err
+	}
+
+	if homeFlag != "" {
+		cfg.SetHomeDir(homeFlag)
+	}
+
+	for _, issue := range config.Validate(cfg) {
+		fmt.Fprintf(os.Stderr, "warning: %s\n", issue)
+	}
+
+	return cfg, nil
+}
+
+// stateDir returns the state directory for commands that must work even
+// when the configuration is invalid, such as the config editing commands
+func stateDir() string {
+	if homeFlag != "" {
+		return utils.ExpandPath(homeFlag)
+	}
+	if cfg, err := config.LoadUnchecked(configPath()); err == nil {
+		return cfg.StateDir()
+	}
+	return config.Default().StateDir()
+}
+
+// walkContext returns a context cancelled on Ctrl-C or SIGTERM, for
+// commands that walk tool directories and should stop early
+func walkContext(cmd *cobra.Command) (context.Context, context.CancelFunc) {
+	return signal.NotifyContext(cmd.Context(), os.Interrupt, syscall.SIGTERM)
+}
+
 func Run() error {
 	// Add subcommands
 	rootCmd.AddCommand(
+		newInitCmd(),
 		newScanCmd(),
 		newCleanupCmd(),
+		newTrashCmd(),
+		newArchiveCmd(),
+		newWorkspacesCmd(),
 		newSwitchCmd(),
+		newRunCmd(),
+		newHookCmd(),
+		newHookEnvCmd(),
+		newAllowCmd(),
+		newDenyCmd(),
+		newConfigCmd(),
 		newLinkCmd(),
 		newCheckCmd(),
 		newBackupCmd(),
@@ -37,17 +112,6 @@ func Run() error {
 }
 
 // Placeholder commands
-func newSwitchCmd() *cobra.Command {
-	return &cobra.Command{
-		Use:   "switch",
-		Short: "Switch between AI models",
-		Long:  `Switch between different AI model configurations.`,
-		Run: func(cmd *cobra.Command, args []string) {
-			cmd.Println("Model switching - coming soon!")
-		},
-	}
-}
-
 func newLinkCmd() *cobra.Command {
 	return &cobra.Command{
 		Use:   "link",
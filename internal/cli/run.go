@@ -0,0 +1,60 @@
+package cli
+
+import (
+	"os"
+
+	"ai-manager/internal/runner"
+
+	"github.com/spf13/cobra"
+)
+
+var runModel string
+
+// newRunCmd returns the run command
+func newRunCmd() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "run <tool> [-- args...]",
+		Short: "Launch an AI tool with a model injected",
+		Long: `Launch an AI tool (claude, gemini, opencode) with the selected model's
+environment injected for this invocation only. Settings files are not
+modified, so concurrent sessions can use different models.
+
+Every tool gets ANTHROPIC_BASE_URL and ANTHROPIC_MODEL; tools that take
+their model elsewhere also get GEMINI_MODEL (gemini), --model
+provider/model_id (opencode), OPENAI_BASE_URL and --model (codex) or
+OPENAI_MODEL and OPENAI_BASE_URL (qwen). A --model passed to the tool
+after -- wins.
+
+The API key is read from the provider's variable (e.g. ZHIPU_API_KEY) or
+from the system secret store under the "ai-manager" service.
+
+Arguments after -- are passed to the tool:
+
+  ai-mgr run claude --model glm-4.7 -- --resume`,
+		Args: cobra.MinimumNArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			cfg, err := loadConfig()
+			if err != nil {
+				return err
+			}
+
+			modelKey := runModel
+			if modelKey == "" {
+				modelKey = cfg.Defaults.Model
+			}
+
+			r := runner.NewRunner(cfg)
+			code, err := r.Run(args[0], modelKey, args[1:])
+			if err != nil {
+				return err
+			}
+			if code != 0 {
+				os.Exit(code)
+			}
+			return nil
+		},
+	}
+
+	cmd.Flags().StringVarP(&runModel, "model", "m", "", "Model to use (default: defaults.model)")
+	return cmd
+}
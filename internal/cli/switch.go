@@ -0,0 +1,202 @@
+package cli
+
+import (
+	"fmt"
+	"os"
+	"sort"
+	"strings"
+
+	"ai-manager/internal/config"
+	"ai-manager/internal/models"
+	"ai-manager/internal/switcher"
+
+	"github.com/spf13/cobra"
+)
+
+var (
+	switchUndo  bool
+	switchList  bool
+	switchShell string
+)
+
+// newSwitchCmd returns the switch command
+func newSwitchCmd() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "switch [model]",
+		Short: "Switch between AI models",
+		Long: `Switch every enabled AI tool to the given model.
+Writes the model's API endpoint, model ID and environment into each
+tool's own settings file. Settings not managed by ai-mgr are kept.
+
+Every switch is journaled with the previous settings file content, so
+--undo restores the previous state exactly and --list shows the history.
+
+With --shell, no files are touched. Instead, export/unset statements for
+the model are printed so a single terminal session can switch models:
+
+  eval "$(ai-mgr switch --shell bash glm-4.7)"
+  ai-mgr switch --shell fish glm-4.7 | source`,
+		Args: cobra.MaximumNArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			cfg, err := loadConfig()
+			if err != nil {
+				return err
+			}
+
+			sw := switcher.NewSwitcher(cfg)
+
+			if switchList {
+				history, err := sw.History()
+				if err != nil {
+					return err
+				}
+				if jsonOutput {
+					return printJSON(history)
+				}
+				printSwitchHistory(cfg, history)
+				return nil
+			}
+
+			if switchUndo {
+				entry, err := sw.Undo()
+				if err != nil {
+					return err
+				}
+				if jsonOutput {
+					return printJSON(entry)
+				}
+				printUndo(entry)
+				return nil
+			}
+
+			if switchShell != "" {
+				if len(args) == 0 {
+					return fmt.Errorf("--shell requires a model")
+				}
+				out, err := switcher.ShellEnv(cfg, switchShell, os.Getenv(switcher.EnvActiveModel), args[0])
+				if err != nil {
+					return err
+				}
+				fmt.Print(out)
+				return nil
+			}
+
+			if len(args) == 0 {
+				printModels(cfg)
+				return nil
+			}
+
+			results, err := sw.Switch(args[0])
+			if err != nil {
+				return err
+			}
+
+			if jsonOutput {
+				return printJSON(results)
+			}
+
+			return printSwitchResults(cfg, args[0], results)
+		},
+	}
+
+	cmd.Flags().BoolVar(&switchUndo, "undo", false, "Revert the most recent switch")
+	cmd.Flags().BoolVar(&switchList, "list", false, "Show the switch history")
+	cmd.Flags().BoolVar(&jsonOutput, "json", false, "Output in JSON format")
+	cmd.Flags().StringVar(&switchShell, "shell", "", "Print environment statements for bash, zsh or fish instead of editing settings")
+	cmd.MarkFlagsMutuallyExclusive("undo", "list", "shell")
+	return cmd
+}
+
+// printModels lists the configured models
+func printModels(cfg *config.Config) {
+	fmt.Println("=== Available Models ===")
+	fmt.Println()
+
+	keys := make([]string, 0, len(cfg.Models))
+	for k := range cfg.Models {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+
+	for _, k := range keys {
+		m := cfg.Models[k]
+		marker := " "
+		if k == cfg.Defaults.Model {
+			marker = "*"
+		}
+		fmt.Printf("%s %-20s %s (%s)\n", marker, k, m.Name, m.Provider)
+	}
+}
+
+// printSwitchResults prints per-tool switch results
+func printSwitchResults(cfg *config.Config, modelKey string, results []models.SwitchResult) error {
+	fmt.Printf("=== Switching to %s (%s) ===\n", cfg.Models[modelKey].Name, modelKey)
+
+	failed := 0
+	for _, r := range results {
+		switch {
+		case r.Error != "":
+			fmt.Printf("[Error] %s: %s\n", r.Tool, r.Error)
+			failed++
+		case r.Skipped:
+			fmt.Printf("[Skip] %s: %s\n", r.Tool, r.Reason)
+		default:
+			fmt.Printf("[Done] %s: %s\n", r.Tool, r.SettingsPath)
+		}
+	}
+
+	if failed > 0 {
+		return fmt.Errorf("%d tool(s) failed to switch", failed)
+	}
+	return nil
+}
+
+// printSwitchHistory prints the active models and the switch journal
+func printSwitchHistory(cfg *config.Config, history *switcher.History) {
+	fmt.Println("=== Active Models ===")
+	if len(history.Active) == 0 {
+		fmt.Println("  (none recorded)")
+	}
+
+	keys := make([]string, 0, len(history.Active))
+	for k := range history.Active {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	for _, k := range keys {
+		name := k
+		if tool, ok := cfg.Tools[k]; ok {
+			name = tool.Name
+		}
+		fmt.Printf("  %-14s %s\n", name, history.Active[k])
+	}
+
+	fmt.Println()
+	fmt.Println("=== Switch History ===")
+	if len(history.Entries) == 0 {
+		fmt.Println("  (empty)")
+		return
+	}
+
+	for i := len(history.Entries) - 1; i >= 0; i-- {
+		e := history.Entries[i]
+		tools := make([]string, 0, len(e.Files))
+		for _, f := range e.Files {
+			tools = append(tools, f.Tool)
+		}
+		fmt.Printf("  #%-4d %s  %-16s %s\n",
+			e.ID, e.Time.Format("2006-01-02 15:04:05"), e.Model, strings.Join(tools, ", "))
+	}
+}
+
+// printUndo prints the files restored by an undo
+func printUndo(entry *switcher.HistoryEntry) {
+	fmt.Printf("=== Undid switch #%d to %s ===\n", entry.ID, entry.Model)
+	for _, f := range entry.Files {
+		if f.Existed {
+			fmt.Printf("[Restored] %s\n", f.Path)
+		} else {
+			fmt.Printf("[Removed] %s\n", f.Path)
+		}
+	}
+}
@@ -0,0 +1,190 @@
+package cli
+
+import (
+	"fmt"
+	"os"
+	"text/tabwriter"
+	"time"
+
+	"ai-manager/internal/cleanup"
+	"ai-manager/internal/models"
+	"ai-manager/internal/safefile"
+
+	"github.com/spf13/cobra"
+)
+
+var (
+	trashForce bool
+	trashAll   bool
+)
+
+// newTrashCmd returns the trash command group
+func newTrashCmd() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "trash",
+		Short: "Manage files removed by cleanup",
+		Long: `List, restore and purge files that cleanup moved to the trash.
+Trashed files are kept in <home_dir>/trash for retention.trash_days and
+purged by the next cleanup after that.`,
+	}
+
+	cmd.AddCommand(
+		newTrashListCmd(),
+		newTrashRestoreCmd(),
+		newTrashPurgeCmd(),
+	)
+	return cmd
+}
+
+// openTrash loads the configuration and returns its trash
+func openTrash() (*cleanup.Trash, error) {
+	cfg, err := loadConfig()
+	if err != nil {
+		return nil, err
+	}
+	return cleanup.NewTrash(cfg), nil
+}
+
+// newTrashListCmd returns the trash list command
+func newTrashListCmd() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "list",
+		Short: "List trashed files",
+		Args:  cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			trash, err := openTrash()
+			if err != nil {
+				return err
+			}
+
+			items, err := trash.List()
+			if err != nil {
+				return err
+			}
+
+			if jsonOutput {
+				return printJSON(items)
+			}
+			printTrash(items)
+			return nil
+		},
+	}
+
+	cmd.Flags().BoolVar(&jsonOutput, "json", false, "Output in JSON format")
+	return cmd
+}
+
+// printTrash prints trashed files as a table
+func printTrash(items []models.TrashItem) {
+	fmt.Println("=== Trash ===")
+	if len(items) == 0 {
+		fmt.Println("Trash is empty")
+		return
+	}
+
+	var total int64
+	w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(w, "ID\tTOOL\tSIZE\tTRASHED\tORIGINAL PATH")
+	for _, item := range items {
+		fmt.Fprintf(w, "%s\t%s\t%s\t%s\t%s\n",
+			item.ID, item.Tool, models.FormatBytes(item.Size),
+			item.TrashedAt.Format(time.DateTime), item.OriginalPath)
+		total += item.Size
+	}
+	w.Flush()
+
+	fmt.Printf("\nTotal: %d files, %s\n", len(items), models.FormatBytes(total))
+}
+
+// newTrashRestoreCmd returns the trash restore command
+func newTrashRestoreCmd() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "restore <id>...",
+		Short: "Move trashed files back to where they came from",
+		Long: `Move trashed files back to their original paths. A file that has
+since been recreated at that path is only replaced with --force.`,
+		Args: cobra.MinimumNArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			trash, err := openTrash()
+			if err != nil {
+				return err
+			}
+
+			failed := 0
+			err = safefile.WithLock(stateDir(), func() error {
+				for _, id := range args {
+					item, err := trash.Restore(id, trashForce)
+					if err != nil {
+						fmt.Printf("[Error] %s: %v\n", id, err)
+						failed++
+						continue
+					}
+					fmt.Printf("[Done] Restored %s\n", item.OriginalPath)
+				}
+				return nil
+			})
+			if err != nil {
+				return err
+			}
+
+			if failed > 0 {
+				return fmt.Errorf("%d of %d files not restored", failed, len(args))
+			}
+			return nil
+		},
+	}
+
+	cmd.Flags().BoolVar(&trashForce, "force", false, "Overwrite files that exist at the original path")
+	return cmd
+}
+
+// newTrashPurgeCmd returns the trash purge command
+func newTrashPurgeCmd() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "purge [id...]",
+		Short: "Permanently delete trashed files",
+		Long: `Permanently delete trashed files. Without arguments, only files kept
+longer than retention.trash_days are deleted; --all empties the trash.`,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			if trashAll && len(args) > 0 {
+				return fmt.Errorf("--all cannot be combined with ids")
+			}
+
+			trash, err := openTrash()
+			if err != nil {
+				return err
+			}
+
+			var purged []models.TrashItem
+			err = safefile.WithLock(stateDir(), func() error {
+				switch {
+				case trashAll:
+					purged, err = trash.Purge(time.Now())
+					return err
+				case len(args) > 0:
+					for _, id := range args {
+						item, err := trash.Delete(id)
+						if err != nil {
+							return err
+						}
+						purged = append(purged, item)
+					}
+					return nil
+				default:
+					purged, err = trash.PurgeExpired()
+					return err
+				}
+			})
+
+			var total int64
+			for _, item := range purged {
+				total += item.Size
+			}
+			fmt.Printf("Purged %d files, %s freed\n", len(purged), models.FormatBytes(total))
+			return err
+		},
+	}
+
+	cmd.Flags().BoolVar(&trashAll, "all", false, "Delete everything in the trash")
+	return cmd
+}
@@ -0,0 +1,206 @@
+package cli
+
+import (
+	"fmt"
+	"os"
+	"sort"
+	"strings"
+	"text/tabwriter"
+	"time"
+
+	"ai-manager/internal/cleanup"
+	"ai-manager/internal/models"
+	"ai-manager/internal/workspace"
+
+	"github.com/spf13/cobra"
+)
+
+var (
+	workspacesOrphaned bool
+	workspacesDryRun   bool
+)
+
+// newWorkspacesCmd returns the workspaces command group
+func newWorkspacesCmd() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "workspaces",
+		Short: "List and clean up editor workspace storage",
+		Long: `List the workspace storage of VSCode, VSCode Insiders, VSCodium, Cursor
+and Windsurf. The editors keep a folder in User/workspaceStorage for every
+folder they opened, named by a hash; its workspace.json tells which folder
+it belongs to. Storage whose folder no longer exists is orphaned and can
+be moved to the trash with 'ai-mgr workspaces clean'.
+
+The storage AI extensions such as GitHub Copilot, Cline and Continue keep
+in each workspace is shown separately. Only enabled editors are listed;
+they are disabled by default.`,
+	}
+
+	cmd.AddCommand(
+		newWorkspacesListCmd(),
+		newWorkspacesCleanCmd(),
+	)
+	return cmd
+}
+
+// newWorkspacesListCmd returns the workspaces list command
+func newWorkspacesListCmd() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "list",
+		Short: "List workspace storage, newest first",
+		Args:  cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			cfg, err := loadConfig()
+			if err != nil {
+				return err
+			}
+
+			ctx, stop := walkContext(cmd)
+			defer stop()
+
+			workspaces, err := workspace.List(ctx, cfg)
+			if err != nil {
+				return err
+			}
+			if workspacesOrphaned {
+				workspaces = orphaned(workspaces)
+			}
+
+			if jsonOutput {
+				return printJSON(workspaces)
+			}
+			printWorkspaces(workspaces)
+			return nil
+		},
+	}
+
+	cmd.Flags().BoolVar(&workspacesOrphaned, "orphaned", false, "Only list storage whose folder no longer exists")
+	cmd.Flags().BoolVar(&jsonOutput, "json", false, "Output in JSON format")
+	return cmd
+}
+
+// newWorkspacesCleanCmd returns the workspaces clean command
+func newWorkspacesCleanCmd() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "clean",
+		Short: "Move orphaned workspace storage to the trash",
+		Long: `Move the storage of workspaces whose folder no longer exists to the
+trash, from where 'ai-mgr trash restore' brings it back. Storage of
+remote folders and of empty windows is never removed, and neither is
+storage a running AI tool is using.`,
+		Args: cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			cfg, err := loadConfig()
+			if err != nil {
+				return err
+			}
+
+			ctx, stop := walkContext(cmd)
+			defer stop()
+
+			workspaces, err := workspace.List(ctx, cfg)
+			if err != nil {
+				return err
+			}
+			workspaces = orphaned(workspaces)
+
+			if workspacesDryRun {
+				if jsonOutput {
+					return printJSON(workspaces)
+				}
+				fmt.Println("=== Orphaned Workspaces (dry run) ===")
+				printWorkspaces(workspaces)
+				return nil
+			}
+
+			cleaner := cleanup.NewCleaner(cfg)
+			return runCleanup(cleaner, func() ([]models.CleanupResult, error) {
+				return cleaner.CleanWorkspaces(ctx, workspaces), ctx.Err()
+			})
+		},
+	}
+
+	cmd.Flags().BoolVar(&workspacesDryRun, "dry-run", false, "List what would be removed without removing")
+	cmd.Flags().BoolVarP(&verbose, "verbose", "v", false, "List skipped workspaces")
+	cmd.Flags().BoolVar(&jsonOutput, "json", false, "Output in JSON format")
+	return cmd
+}
+
+// orphaned returns the workspaces whose folder no longer exists
+func orphaned(workspaces []models.Workspace) []models.Workspace {
+	kept := make([]models.Workspace, 0)
+	for _, ws := range workspaces {
+		if ws.Orphaned {
+			kept = append(kept, ws)
+		}
+	}
+	return kept
+}
+
+// printWorkspaces prints workspaces as a table, followed by the storage of
+// each AI extension across them
+func printWorkspaces(workspaces []models.Workspace) {
+	if len(workspaces) == 0 {
+		fmt.Println("No workspace storage found")
+		return
+	}
+
+	w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(w, "TOOL\tSIZE\tLAST USED\tSTATUS\tAI EXTENSIONS\tFOLDER")
+
+	var total, orphanedSize int64
+	byExt := make(map[string]int64)
+	for _, ws := range workspaces {
+		status := "ok"
+		switch {
+		case ws.Orphaned:
+			status = "orphaned"
+			orphanedSize += ws.Size
+		case ws.Remote:
+			status = "remote"
+		case ws.Folder == "":
+			status = "unknown"
+		}
+
+		exts := make([]string, 0, len(ws.Extensions))
+		for _, e := range ws.Extensions {
+			exts = append(exts, fmt.Sprintf("%s %s", e.Name, models.FormatBytes(e.Size)))
+			byExt[e.Name] += e.Size
+		}
+		if len(exts) == 0 {
+			exts = append(exts, "-")
+		}
+
+		folder := ws.Folder
+		if folder == "" {
+			folder = ws.Path
+		}
+		fmt.Fprintf(w, "%s\t%s\t%s\t%s\t%s\t%s\n",
+			ws.Tool, models.FormatBytes(ws.Size), ws.LastUsed.Format(time.DateTime),
+			status, strings.Join(exts, ", "), folder)
+		total += ws.Size
+	}
+	w.Flush()
+
+	fmt.Printf("\nTotal: %d workspaces, %s", len(workspaces), models.FormatBytes(total))
+	if orphanedSize > 0 {
+		fmt.Printf(" (%s orphaned)", models.FormatBytes(orphanedSize))
+	}
+	fmt.Println()
+
+	if len(byExt) == 0 {
+		return
+	}
+	names := make([]string, 0, len(byExt))
+	for name := range byExt {
+		names = append(names, name)
+	}
+	sort.Slice(names, func(i, j int) bool {
+		return byExt[names[i]] > byExt[names[j]]
+	})
+
+	fmt.Println("\nAI extension storage:")
+	for _, name := range names {
+		fmt.Printf("  %-20s %s\n", name, models.FormatBytes(byExt[name]))
+	}
+}
@@ -1,9 +1,13 @@
 package config
 
 import (
+	"fmt"
 	"os"
 	"path/filepath"
 
+	"ai-manager/internal/safefile"
+	"ai-manager/internal/utils"
+
 	"gopkg.in/yaml.v3"
 )
 
@@ -14,6 +18,13 @@ type Config struct {
 	Models      map[string]Model  `yaml:"models"`
 	Defaults    Defaults          `yaml:"defaults"`
 	Retention   RetentionPolicy   `yaml:"retention"`
+
+	// ProjectPath is the project config layered over this one, if any
+	ProjectPath string `yaml:"-"`
+
+	origins map[string]Origin
+	node    *yaml.Node
+	issues  []Issue
 }
 
 type Tool struct {
@@ -22,6 +33,8 @@ type Tool struct {
 	ConfigPath  string   `yaml:"config_path"`
 	DataPath    string   `yaml:"data_path"`
 	TempPaths   []string `yaml:"temp_paths"`
+	Rules       []Rule   `yaml:"rules,omitempty"`
+	Quota       string   `yaml:"quota,omitempty"`
 	Enabled     bool     `yaml:"enabled"`
 }
 
@@ -30,7 +43,10 @@ type Model struct {
 	Provider    string `yaml:"provider"`
 	APIEndpoint string `yaml:"api_endpoint"`
 	ModelID     string `yaml:"model_id"`
-	Environment map[string]string `yaml:"environment"`
+	APIKeyEnv   string `yaml:"api_key_env,omitempty"`
+	Environment map[string]string `yaml:"environment,omitempty"`
+	// Disabled hides a model, including a built-in one, from every command
+	Disabled bool `yaml:"disabled,omitempty"`
 }
 
 type Defaults struct {
@@ -42,37 +58,14 @@ type RetentionPolicy struct {
 	DebugLogs      int `yaml:"debug_logs_days"`
 	TempFiles      int `yaml:"temp_files_days"`
 	ShellSnapshots int `yaml:"shell_snapshots_days"`
+	Trash          int `yaml:"trash_days"`
 }
 
 var defaultConfig = &Config{
-	Version: "1.0.0",
+	Version: CurrentVersion,
 	HomeDir: "~/.ai-manager",
-	Tools: map[string]Tool{
-		"claude": {
-			Name:       "Claude Code",
-			Path:       "~/.claude",
-			ConfigPath: "settings.json",
-			DataPath:   "projects",
-			TempPaths:  []string{"debug", "shell-snapshots"},
-			Enabled:    true,
-		},
-		"gemini": {
-			Name:       "Gemini CLI",
-			Path:       "~/.gemini",
-			ConfigPath: "settings.json",
-			DataPath:   "tmp",
-			TempPaths:  []string{"tmp"},
-			Enabled:    true,
-		},
-		"opencode": {
-			Name:       "OpenCode",
-			Path:       "~/.config/opencode",
-			ConfigPath: "settings.json",
-			DataPath:   "projects",
-			TempPaths:  []string{"node_modules", ".cache"},
-			Enabled:    true,
-		},
-	},
+	// Tools are registered by their adapters, see RegisterTool
+	Tools: map[string]Tool{},
 	Models: map[string]Model{
 		"claude-sonnet-4": {
 			Name:        "Claude Sonnet 4",
@@ -101,43 +94,132 @@ var defaultConfig = &Config{
 		DebugLogs:      7,
 		TempFiles:      7,
 		ShellSnapshots: 30,
+		Trash:          30,
 	},
 }
 
-// Load loads the configuration from the specified path
+// RegisterTool adds a tool to the built-in configuration. Each tool
+// adapter registers its defaults when the program starts.
+func RegisterTool(key string, tool Tool) {
+	defaultConfig.Tools[key] = tool
+}
+
+// Load loads the configuration from the specified path. The system,
+// user and nearest project configurations are merged over the defaults,
+// so a file only needs to list the values it changes. The result is
+// validated and fatal issues are returned as a *ValidationError.
 func Load(configPath string) (*Config, error) {
-	// If file doesn't exist, return default config
-	if _, err := os.Stat(configPath); os.IsNotExist(err) {
-		return defaultConfig, nil
+	cfg, err := LoadUnchecked(configPath)
+	if err != nil {
+		return nil, err
 	}
 
-	data, err := os.ReadFile(configPath)
+	if issues := Validate(cfg); HasFatal(issues) {
+		return nil, &ValidationError{Issues: issues}
+	}
+
+	return cfg, nil
+}
+
+// LoadUnchecked loads and merges the configuration layers without
+// rejecting invalid values, so that every issue can be reported
+func LoadUnchecked(configPath string) (*Config, error) {
+	return loadWithUser(layerFile{name: LayerUser, path: configPath})
+}
+
+// ValidateUserData validates data as the content of the user config file at
+// configPath, merged with the other layers, without writing it
+func ValidateUserData(configPath string, data []byte) ([]Issue, error) {
+	cfg, err := loadWithUser(layerFile{name: LayerUser, path: configPath, data: data})
 	if err != nil {
 		return nil, err
 	}
+	return Validate(cfg), nil
+}
 
-	var cfg Config
-	if err := yaml.Unmarshal(data, &cfg); err != nil {
+// loadWithUser merges the system, user and project layers. The nearest
+// project config is only merged if it was allowed with its current
+// content; otherwise it is ignored with a warning.
+func loadWithUser(user layerFile) (*Config, error) {
+	layers := []layerFile{
+		{name: LayerSystem, path: SystemConfigPath},
+		user,
+	}
+
+	cfg, err := loadLayers(layers)
+	if err != nil {
 		return nil, err
 	}
 
-	return &cfg, nil
-}
+	cwd, err := os.Getwd()
+	if err != nil {
+		return cfg, nil
+	}
+	project := FindProjectConfig(cwd)
+	if project == "" {
+		return cfg, nil
+	}
 
-// Save saves the configuration to the specified path
-func Save(cfg *Config, configPath string) error {
-	// Create directory if it doesn't exist
-	dir := filepath.Dir(configPath)
-	if err := os.MkdirAll(dir, 0755); err != nil {
-		return err
+	// The checked content is the content merged, even if the file changes
+	data, err := os.ReadFile(project)
+	if err != nil {
+		return nil, err
+	}
+	if !IsAllowed(cfg.StateDir(), project, data) {
+		cfg.issues = append(cfg.issues, Issue{
+			Message: fmt.Sprintf("project config %s is not allowed and was ignored; review it, then run 'ai-mgr allow'", project),
+		})
+		return cfg, nil
 	}
 
-	data, err := yaml.Marshal(cfg)
+	cfg, err = loadLayers(append(layers, layerFile{name: LayerProject, path: project, data: data}))
 	if err != nil {
-		return err
+		return nil, err
 	}
+	cfg.ProjectPath = project
+
+	return cfg, nil
+}
+
+// Save saves the configuration to the specified path. An existing file is
+// updated in place so its comments and key order are kept.
+func Save(cfg *Config, configPath string) error {
+	return safefile.WithLock(cfg.StateDir(), func() error {
+		doc, err := OpenDocument(configPath)
+		if err != nil {
+			return err
+		}
+
+		if err := doc.Update(cfg); err != nil {
+			return err
+		}
+
+		return doc.Save()
+	})
+}
+
+// EnvConfigPath names the environment variable that overrides the config path
+const EnvConfigPath = "AI_MGR_CONFIG"
+
+// StateDir returns the expanded HomeDir, where ai-mgr keeps its own state
+func (c *Config) StateDir() string {
+	return utils.ExpandPath(c.HomeDir)
+}
 
-	return os.WriteFile(configPath, data, 0644)
+// ResolveConfigPath picks the config file from, in order, an explicit path,
+// the AI_MGR_CONFIG variable, config.yaml in an explicit home directory,
+// and the default location
+func ResolveConfigPath(path, homeDir string) string {
+	if path != "" {
+		return utils.ExpandPath(path)
+	}
+	if env := os.Getenv(EnvConfigPath); env != "" {
+		return utils.ExpandPath(env)
+	}
+	if homeDir != "" {
+		return filepath.Join(utils.ExpandPath(homeDir), "config.yaml")
+	}
+	return GetDefaultConfigPath()
 }
 
 // GetDefaultConfigPath returns the default config path
@@ -146,9 +228,29 @@ func GetDefaultConfigPath() string {
 	return filepath.Join(home, ".ai-manager", "config.yaml")
 }
 
-// CreateDefaultConfig creates the default config file
-func CreateDefaultConfig() error {
-	cfg := defaultConfig
-	path := GetDefaultConfigPath()
-	return Save(cfg, path)
+// CreateDefaultConfig writes cfg as a new, commented config file. Tools in
+// missing are written as disabled. An existing file is only replaced if
+// force is set.
+func CreateDefaultConfig(cfg *Config, missing []string, path string, force bool) error {
+	if _, err := os.Stat(path); err == nil && !force {
+		return fmt.Errorf("%s already exists (use --force to overwrite)", path)
+	}
+
+	data, err := renderCommented(cfg, missing)
+	if err != nil {
+		return err
+	}
+
+	return safefile.WithLock(cfg.StateDir(), func() error {
+		return safefile.WriteFile(path, data, 0644)
+	})
+}
+
+// Default returns a copy of the built-in configuration
+func Default() *Config {
+	var cfg Config
+	if err := defaultNode().Decode(&cfg); err != nil {
+		panic(err)
+	}
+	return &cfg
 }
@@ -0,0 +1,350 @@
+package config
+
+import (
+	"bytes"
+	"fmt"
+	"os"
+	"reflect"
+	"strings"
+
+	"ai-manager/internal/safefile"
+
+	"gopkg.in/yaml.v3"
+)
+
+// Document is a configuration file held as a YAML node tree, so edits keep
+// the user's comments and key order
+type Document struct {
+	path string
+	doc  yaml.Node
+}
+
+// OpenDocument reads a configuration file for editing. A missing file
+// yields an empty document that is created on Save.
+func OpenDocument(path string) (*Document, error) {
+	d := &Document{path: path}
+
+	data, err := os.ReadFile(path)
+	if err != nil && !os.IsNotExist(err) {
+		return nil, err
+	}
+	if err := d.parse(data); err != nil {
+		return nil, fmt.Errorf("%s: %w", path, err)
+	}
+	return d, nil
+}
+
+func (d *Document) parse(data []byte) error {
+	d.doc = yaml.Node{}
+	if err := yaml.Unmarshal(data, &d.doc); err != nil {
+		return err
+	}
+	if d.doc.Kind == 0 || len(d.doc.Content) == 0 {
+		d.doc = yaml.Node{
+			Kind:    yaml.DocumentNode,
+			Content: []*yaml.Node{{Kind: yaml.MappingNode, Tag: "!!map"}},
+		}
+	}
+	if d.root().Kind != yaml.MappingNode {
+		return fmt.Errorf("top level must be a mapping")
+	}
+	return nil
+}
+
+func (d *Document) root() *yaml.Node {
+	return d.doc.Content[0]
+}
+
+// Path returns the file the document was read from
+func (d *Document) Path() string {
+	return d.path
+}
+
+// Get returns the node at a dotted key path
+func (d *Document) Get(key string) (*yaml.Node, error) {
+	segments, err := resolveKey(key, d.root(), defaultNode())
+	if err != nil {
+		return nil, err
+	}
+	return lookupNode(d.root(), segments), nil
+}
+
+// Set parses value as YAML and stores it at a dotted key path, creating
+// intermediate mappings as needed
+func (d *Document) Set(key, value string) error {
+	segments, err := resolveKey(key, d.root(), defaultNode())
+	if err != nil {
+		return err
+	}
+
+	valueNode, err := parseValue(value)
+	if err != nil {
+		return fmt.Errorf("invalid value %q: %w", value, err)
+	}
+
+	node := d.root()
+	for i, seg := range segments {
+		existing := mappingValue(node, seg)
+		if i == len(segments)-1 {
+			if existing != nil {
+				// Keep comments attached to the old value
+				valueNode.HeadComment = existing.HeadComment
+				valueNode.LineComment = existing.LineComment
+				valueNode.FootComment = existing.FootComment
+				*existing = *valueNode
+			} else {
+				node.Content = append(node.Content,
+					&yaml.Node{Kind: yaml.ScalarNode, Tag: "!!str", Value: seg}, valueNode)
+			}
+			return nil
+		}
+
+		if existing == nil || existing.Kind != yaml.MappingNode {
+			child := &yaml.Node{Kind: yaml.MappingNode, Tag: "!!map"}
+			if existing != nil {
+				*existing = *child
+				child = existing
+			} else {
+				node.Content = append(node.Content,
+					&yaml.Node{Kind: yaml.ScalarNode, Tag: "!!str", Value: seg}, child)
+			}
+			existing = child
+		}
+		node = existing
+	}
+	return nil
+}
+
+// Unset removes the value at a dotted key path, reporting whether it existed
+func (d *Document) Unset(key string) (bool, error) {
+	segments, err := resolveKey(key, d.root(), defaultNode())
+	if err != nil {
+		return false, err
+	}
+
+	parent := lookupNode(d.root(), segments[:len(segments)-1])
+	if parent == nil || parent.Kind != yaml.MappingNode {
+		return false, nil
+	}
+
+	last := segments[len(segments)-1]
+	for i := 0; i+1 < len(parent.Content); i += 2 {
+		if parent.Content[i].Value == last {
+			parent.Content = append(parent.Content[:i], parent.Content[i+2:]...)
+			return true, nil
+		}
+	}
+	return false, nil
+}
+
+// Bytes encodes the document
+func (d *Document) Bytes() ([]byte, error) {
+	var buf bytes.Buffer
+	enc := yaml.NewEncoder(&buf)
+	enc.SetIndent(2)
+	if err := enc.Encode(&d.doc); err != nil {
+		return nil, err
+	}
+	if err := enc.Close(); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+// Save atomically writes the document back to its file. Callers that
+// read, modify and save should hold the ai-mgr lock throughout.
+func (d *Document) Save() error {
+	data, err := d.Bytes()
+	if err != nil {
+		return err
+	}
+	return safefile.WriteFile(d.path, data, 0644)
+}
+
+// Update syncs the document with cfg, changing only the values that differ
+// and keeping comments on everything else
+func (d *Document) Update(cfg *Config) error {
+	var src yaml.Node
+	if err := src.Encode(cfg); err != nil {
+		return err
+	}
+	syncNode(d.root(), &src)
+	return nil
+}
+
+// Get returns the effective value at a dotted key path after all layers
+// are merged
+func (c *Config) Get(key string) (*yaml.Node, error) {
+	if c.node == nil {
+		return nil, nil
+	}
+	segments, err := resolveKey(key, c.node)
+	if err != nil {
+		return nil, err
+	}
+	return lookupNode(c.node, segments), nil
+}
+
+// resolveKey splits a dotted key path into mapping keys using the config
+// schema, so map keys that contain dots (models.minimax-m2.1.name) resolve
+// to a single segment. Map keys already present under one of roots are
+// preferred.
+func resolveKey(key string, roots ...*yaml.Node) ([]string, error) {
+	if key == "" {
+		return nil, fmt.Errorf("empty key")
+	}
+
+	segments, ok := resolveParts(strings.Split(key, "."), reflect.TypeOf(Config{}), roots)
+	if !ok {
+		return nil, fmt.Errorf("unknown key: %s", key)
+	}
+	return segments, nil
+}
+
+// resolveParts matches dotted parts against type t and the given nodes
+func resolveParts(parts []string, t reflect.Type, nodes []*yaml.Node) ([]string, bool) {
+	if len(parts) == 0 {
+		return nil, true
+	}
+
+	switch t.Kind() {
+	case reflect.Struct:
+		ft, ok := yamlFields(t)[parts[0]]
+		if !ok {
+			return nil, false
+		}
+		rest, ok := resolveParts(parts[1:], ft, childNodes(nodes, parts[0]))
+		if !ok {
+			return nil, false
+		}
+		return append([]string{parts[0]}, rest...), true
+
+	case reflect.Map:
+		// An existing entry decides the split
+		for n := 1; n <= len(parts); n++ {
+			key := strings.Join(parts[:n], ".")
+			if children := childNodes(nodes, key); len(children) > 0 {
+				rest, ok := resolveParts(parts[n:], t.Elem(), children)
+				if !ok {
+					return nil, false
+				}
+				return append([]string{key}, rest...), true
+			}
+		}
+
+		// Otherwise use the shortest key whose remainder fits the element type
+		for n := 1; n <= len(parts); n++ {
+			rest, ok := resolveParts(parts[n:], t.Elem(), nil)
+			if ok {
+				return append([]string{strings.Join(parts[:n], ".")}, rest...), true
+			}
+		}
+	}
+
+	return nil, false
+}
+
+// childNodes returns the values stored under key in each mapping node
+func childNodes(nodes []*yaml.Node, key string) []*yaml.Node {
+	var children []*yaml.Node
+	for _, node := range nodes {
+		if child := mappingValue(node, key); child != nil {
+			children = append(children, child)
+		}
+	}
+	return children
+}
+
+// defaultNode returns the built-in defaults as a YAML tree
+func defaultNode() *yaml.Node {
+	var node yaml.Node
+	if err := node.Encode(defaultConfig); err != nil {
+		return nil
+	}
+	return &node
+}
+
+// lookupNode follows mapping keys from node, returning nil if any is missing
+func lookupNode(node *yaml.Node, segments []string) *yaml.Node {
+	for _, seg := range segments {
+		node = mappingValue(node, seg)
+		if node == nil {
+			return nil
+		}
+	}
+	return node
+}
+
+// parseValue parses a command-line value as a YAML node, so "3" becomes an
+// int and "[a, b]" a list
+func parseValue(value string) (*yaml.Node, error) {
+	if value == "" {
+		return &yaml.Node{Kind: yaml.ScalarNode, Tag: "!!str", Value: ""}, nil
+	}
+
+	var doc yaml.Node
+	if err := yaml.Unmarshal([]byte(value), &doc); err != nil {
+		return nil, err
+	}
+	if len(doc.Content) == 0 {
+		return &yaml.Node{Kind: yaml.ScalarNode, Tag: "!!str", Value: value}, nil
+	}
+
+	node := doc.Content[0]
+	node.Style &^= yaml.FlowStyle
+	if node.Kind == yaml.SequenceNode || node.Kind == yaml.MappingNode {
+		node.Style |= yaml.FlowStyle
+	}
+	return node, nil
+}
+
+// syncNode makes dst match src while reusing dst's nodes (and comments)
+// wherever the value is unchanged
+func syncNode(dst, src *yaml.Node) {
+	if src.Kind == yaml.DocumentNode {
+		src = src.Content[0]
+	}
+
+	if dst.Kind != yaml.MappingNode || src.Kind != yaml.MappingNode {
+		if dst.Kind == src.Kind && dst.Value == src.Value && dst.Kind == yaml.ScalarNode {
+			return
+		}
+		head, line, foot := dst.HeadComment, dst.LineComment, dst.FootComment
+		*dst = *src
+		dst.HeadComment, dst.LineComment, dst.FootComment = head, line, foot
+		return
+	}
+
+	content := make([]*yaml.Node, 0, len(src.Content))
+	for i := 0; i+1 < len(src.Content); i += 2 {
+		key, value := src.Content[i], src.Content[i+1]
+		found := false
+		for j := 0; j+1 < len(dst.Content); j += 2 {
+			if dst.Content[j].Value == key.Value {
+				syncNode(dst.Content[j+1], value)
+				content = append(content, dst.Content[j], dst.Content[j+1])
+				found = true
+				break
+			}
+		}
+		if !found {
+			content = append(content, key, value)
+		}
+	}
+
+	// Keep the user's key order: existing keys first, in their positions
+	ordered := make([]*yaml.Node, 0, len(content))
+	for j := 0; j+1 < len(dst.Content); j += 2 {
+		for i := 0; i+1 < len(content); i += 2 {
+			if content[i] == dst.Content[j] {
+				ordered = append(ordered, content[i], content[i+1])
+			}
+		}
+	}
+	for i := 0; i+1 < len(content); i += 2 {
+		if mappingValue(dst, content[i].Value) == nil {
+			ordered = append(ordered, content[i], content[i+1])
+		}
+	}
+	dst.Content = ordered
+}
@@ -0,0 +1,63 @@
+package config
+
+import (
+	"bytes"
+	"fmt"
+	"time"
+
+	"gopkg.in/yaml.v3"
+)
+
+// sectionComments explain each top-level section of a generated config file
+var sectionComments = map[string]string{
+	"version":   "Schema version, used by `ai-mgr config migrate`",
+	"home_dir":  "Where ai-mgr keeps its own state (switch history, trash, ...)",
+	"tools":     "AI tools managed by ai-mgr. Paths support ~ and $VARS.",
+	"models":    "Models for `ai-mgr switch` and `ai-mgr run`. Built-in models\nstay available even if they are not listed here; set `disabled: true` to\nhide one.",
+	"defaults":  "Default model for `ai-mgr run` and the cleanup age in days",
+	"retention": "How many days to keep temporary files before cleanup, and cleaned\nup files in the trash before they are purged",
+}
+
+// renderCommented encodes cfg as YAML with a comment above every section.
+// Tools in missing are written as disabled.
+func renderCommented(cfg *Config, missing []string) ([]byte, error) {
+	var root yaml.Node
+	if err := root.Encode(cfg); err != nil {
+		return nil, err
+	}
+
+	root.HeadComment = fmt.Sprintf("ai-mgr configuration, generated by `ai-mgr init` on %s.\n"+
+		"Only values that differ from the built-in defaults are needed; run\n"+
+		"`ai-mgr config show --origin` to see the effective configuration.",
+		time.Now().Format("2006-01-02"))
+
+	for i := 0; i+1 < len(root.Content); i += 2 {
+		key := root.Content[i]
+		if comment, ok := sectionComments[key.Value]; ok {
+			key.HeadComment = comment
+		}
+	}
+
+	if tools := mappingValue(&root, "tools"); tools != nil {
+		for _, name := range missing {
+			key := &yaml.Node{Kind: yaml.ScalarNode, Tag: "!!str", Value: name}
+			value := &yaml.Node{Kind: yaml.MappingNode, Tag: "!!map"}
+			setMappingValue(value, "enabled", "false", false)
+			enabled := mappingValue(value, "enabled")
+			enabled.Tag = "!!bool"
+			enabled.LineComment = "not found on this machine"
+			tools.Content = append(tools.Content, key, value)
+		}
+	}
+
+	var buf bytes.Buffer
+	enc := yaml.NewEncoder(&buf)
+	enc.SetIndent(2)
+	if err := enc.Encode(&root); err != nil {
+		return nil, err
+	}
+	if err := enc.Close(); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
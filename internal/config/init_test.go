@@ -0,0 +1,58 @@
+package config
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestDisabledModelStaysGone(t *testing.T) {
+	dir := t.TempDir()
+	SystemConfigPath = filepath.Join(dir, "missing.yaml")
+	t.Cleanup(func() { SystemConfigPath = "/etc/ai-manager/config.yaml" })
+	t.Chdir(dir)
+
+	cfg := Default()
+	cfg.HomeDir = dir
+	var declined string
+	for key, model := range cfg.Models {
+		if key != cfg.Defaults.Model {
+			declined = key
+			model.Disabled = true
+			cfg.Models[key] = model
+			break
+		}
+	}
+	if declined == "" {
+		t.Fatal("no built-in model besides the default")
+	}
+
+	path := filepath.Join(dir, "config.yaml")
+	if err := CreateDefaultConfig(cfg, nil, path, false); err != nil {
+		t.Fatal(err)
+	}
+
+	loaded, err := Load(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, ok := loaded.Models[declined]; ok {
+		t.Errorf("disabled model %s is back after loading", declined)
+	}
+	if _, ok := loaded.Models[loaded.Defaults.Model]; !ok {
+		t.Errorf("default model %s is missing", loaded.Defaults.Model)
+	}
+
+	// A partial entry is enough to hide a built-in model
+	data := []byte("models:\n  " + declined + ":\n    disabled: true\n")
+	if err := os.WriteFile(path, data, 0644); err != nil {
+		t.Fatal(err)
+	}
+	loaded, err = Load(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, ok := loaded.Models[declined]; ok {
+		t.Errorf("model %s disabled by a partial entry is still listed", declined)
+	}
+}
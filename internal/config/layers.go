@@ -0,0 +1,279 @@
+package config
+
+import (
+	"fmt"
+	"os"
+	"reflect"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+// Configuration layers, from lowest to highest precedence
+const (
+	LayerDefault = "default"
+	LayerSystem  = "system"
+	LayerUser    = "user"
+	LayerProject = "project"
+	LayerFlag    = "flag"
+)
+
+// SystemConfigPath is the machine-wide configuration file
+var SystemConfigPath = "/etc/ai-manager/config.yaml"
+
+// Origin records which layer supplied a configuration value
+type Origin struct {
+	Layer  string `json:"layer"`
+	File   string `json:"file,omitempty"`
+	Line   int    `json:"line,omitempty"`
+	Column int    `json:"column,omitempty"`
+}
+
+// String formats the origin as "layer file:line"
+func (o Origin) String() string {
+	if o.File == "" {
+		return o.Layer
+	}
+	return fmt.Sprintf("%s %s:%d", o.Layer, o.File, o.Line)
+}
+
+// Value is a single resolved configuration value with its origin
+type Value struct {
+	Key    string `json:"key"`
+	Value  string `json:"value"`
+	Origin Origin `json:"origin"`
+}
+
+// layerFile is a configuration file taking part in the merge. If data is
+// set it is used in place of the file's content.
+type layerFile struct {
+	name string
+	path string
+	data []byte
+}
+
+// mergeState accumulates the merged YAML tree and value origins
+type mergeState struct {
+	root    *yaml.Node
+	origins map[string]Origin
+}
+
+// loadLayers merges the default, system, user and project layers field by
+// field and map entry by map entry
+func loadLayers(layers []layerFile) (*Config, error) {
+	root := &yaml.Node{}
+	if err := root.Encode(defaultConfig); err != nil {
+		return nil, err
+	}
+
+	state := &mergeState{root: root, origins: make(map[string]Origin)}
+	var issues []Issue
+	state.record(root, "", Origin{Layer: LayerDefault})
+
+	for _, l := range layers {
+		node, err := readLayer(l)
+		if err != nil {
+			return nil, fmt.Errorf("%s: %w", l.path, err)
+		}
+		if node == nil {
+			continue
+		}
+		if node.Kind != yaml.MappingNode {
+			return nil, fmt.Errorf("%s: top level must be a mapping", l.path)
+		}
+
+		// Older files are upgraded in memory; `config migrate` rewrites them
+		if _, _, err := migrateNode(node, false); err != nil {
+			return nil, fmt.Errorf("%s: %w", l.path, err)
+		}
+
+		// Decode each layer alone first so type errors name the right file
+		if err := node.Decode(&Config{}); err != nil {
+			return nil, fmt.Errorf("%s: %w", l.path, err)
+		}
+
+		issues = append(issues, checkKeys(node, reflect.TypeOf(Config{}), "", l)...)
+		if l.name == LayerProject {
+			issues = append(issues, stripProjectSecrets(node, l)...)
+		}
+		state.merge(state.root, node, "", l)
+	}
+
+	var cfg Config
+	if err := state.root.Decode(&cfg); err != nil {
+		return nil, err
+	}
+	// Built-in models are merged into every layer, so a disabled one has to
+	// be dropped here to stay gone
+	for key, model := range cfg.Models {
+		if model.Disabled {
+			delete(cfg.Models, key)
+		}
+	}
+	cfg.origins = state.origins
+	cfg.node = state.root
+	cfg.issues = issues
+	return &cfg, nil
+}
+
+// readLayer parses a layer file into its top-level node. A missing or empty
+// file yields nil.
+func readLayer(l layerFile) (*yaml.Node, error) {
+	data := l.data
+	if data == nil {
+		var err error
+		data, err = os.ReadFile(l.path)
+		if os.IsNotExist(err) {
+			return nil, nil
+		}
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	var doc yaml.Node
+	if err := yaml.Unmarshal(data, &doc); err != nil {
+		return nil, err
+	}
+	if doc.Kind != yaml.DocumentNode || len(doc.Content) == 0 {
+		return nil, nil
+	}
+	return doc.Content[0], nil
+}
+
+// merge overlays src onto dst. Mappings are merged key by key; scalars and
+// sequences from src replace those in dst. Null values are ignored.
+func (s *mergeState) merge(dst, src *yaml.Node, prefix string, l layerFile) {
+	for i := 0; i+1 < len(src.Content); i += 2 {
+		key, value := src.Content[i], src.Content[i+1]
+		if isNull(value) {
+			continue
+		}
+		path := joinKey(prefix, key.Value)
+
+		existing := mappingValue(dst, key.Value)
+		if existing != nil && existing.Kind == yaml.MappingNode && value.Kind == yaml.MappingNode {
+			s.merge(existing, value, path, l)
+			continue
+		}
+
+		s.forget(path)
+		s.record(value, path, Origin{Layer: l.name, File: l.path, Line: value.Line, Column: value.Column})
+
+		if existing != nil {
+			*existing = *value
+		} else {
+			dst.Content = append(dst.Content, key, value)
+		}
+	}
+}
+
+// record sets the origin of every leaf under node
+func (s *mergeState) record(node *yaml.Node, path string, origin Origin) {
+	if node.Kind == yaml.DocumentNode && len(node.Content) > 0 {
+		s.record(node.Content[0], path, origin)
+		return
+	}
+	if node.Kind != yaml.MappingNode {
+		if origin.File != "" {
+			origin.Line, origin.Column = node.Line, node.Column
+		}
+		s.origins[path] = origin
+		return
+	}
+
+	for i := 0; i+1 < len(node.Content); i += 2 {
+		s.record(node.Content[i+1], joinKey(path, node.Content[i].Value), origin)
+	}
+}
+
+// forget drops the origins of a replaced subtree
+func (s *mergeState) forget(path string) {
+	delete(s.origins, path)
+	for k := range s.origins {
+		if strings.HasPrefix(k, path+".") {
+			delete(s.origins, k)
+		}
+	}
+}
+
+// SetHomeDir overrides HomeDir from the command line
+func (c *Config) SetHomeDir(dir string) {
+	c.HomeDir = dir
+	if node := mappingValue(c.node, "home_dir"); node != nil {
+		node.Value = dir
+	}
+	if c.origins != nil {
+		c.origins["home_dir"] = Origin{Layer: LayerFlag}
+	}
+}
+
+// Origin returns the layer that supplied the value at a dotted key path
+func (c *Config) Origin(key string) (Origin, bool) {
+	o, ok := c.origins[key]
+	return o, ok
+}
+
+// Values returns every resolved leaf value in file order with its origin
+func (c *Config) Values() []Value {
+	if c.node == nil {
+		return nil
+	}
+
+	values := make([]Value, 0)
+	var walk func(node *yaml.Node, path string)
+	walk = func(node *yaml.Node, path string) {
+		if node.Kind == yaml.MappingNode {
+			for i := 0; i+1 < len(node.Content); i += 2 {
+				walk(node.Content[i+1], joinKey(path, node.Content[i].Value))
+			}
+			return
+		}
+		values = append(values, Value{
+			Key:    path,
+			Value:  renderValue(node),
+			Origin: c.origins[path],
+		})
+	}
+	walk(c.node, "")
+	return values
+}
+
+// renderValue formats a leaf node for display
+func renderValue(node *yaml.Node) string {
+	if node.Kind == yaml.ScalarNode {
+		return node.Value
+	}
+
+	flow := *node
+	flow.Style = yaml.FlowStyle
+	data, err := yaml.Marshal(&flow)
+	if err != nil {
+		return ""
+	}
+	return strings.TrimSpace(string(data))
+}
+
+// mappingValue returns the value stored under key in a mapping node
+func mappingValue(node *yaml.Node, key string) *yaml.Node {
+	if node == nil || node.Kind != yaml.MappingNode {
+		return nil
+	}
+	for i := 0; i+1 < len(node.Content); i += 2 {
+		if node.Content[i].Value == key {
+			return node.Content[i+1]
+		}
+	}
+	return nil
+}
+
+func isNull(node *yaml.Node) bool {
+	return node.Kind == yaml.ScalarNode && node.Tag == "!!null"
+}
+
+func joinKey(prefix, key string) string {
+	if prefix == "" {
+		return key
+	}
+	return prefix + "." + key
+}
@@ -0,0 +1,183 @@
+package config
+
+import (
+	"bytes"
+	"fmt"
+
+	"gopkg.in/yaml.v3"
+)
+
+// CurrentVersion is the configuration schema version this build writes
+const CurrentVersion = "1.1.0"
+
+// initialVersion is assumed for files that don't declare a version
+const initialVersion = "1.0.0"
+
+// Migration upgrades a configuration document from one version to the next
+type Migration struct {
+	From        string
+	To          string
+	Description string
+	Apply       func(root *yaml.Node) error
+}
+
+// migrations are applied in order, each one picking up where the last ended
+var migrations = []Migration{
+	{
+		From:        "1.0.0",
+		To:          "1.1.0",
+		Description: "rename retention keys to their *_days form",
+		Apply:       renameRetentionKeys,
+	},
+}
+
+// MigrationResult describes the outcome of migrating a configuration file
+type MigrationResult struct {
+	From    string
+	To      string
+	Applied []Migration
+	Data    []byte
+}
+
+// Changed reports whether any migration was applied
+func (r *MigrationResult) Changed() bool {
+	return len(r.Applied) > 0
+}
+
+// Migrate upgrades configuration file content to CurrentVersion, keeping
+// comments, and stamps the new version into the document
+func Migrate(data []byte) (*MigrationResult, error) {
+	var doc yaml.Node
+	if err := yaml.Unmarshal(data, &doc); err != nil {
+		return nil, err
+	}
+
+	result := &MigrationResult{From: initialVersion, To: initialVersion, Data: data}
+	if doc.Kind != yaml.DocumentNode || len(doc.Content) == 0 {
+		return result, nil
+	}
+
+	root := doc.Content[0]
+	if root.Kind != yaml.MappingNode {
+		return nil, fmt.Errorf("top level must be a mapping")
+	}
+
+	from, applied, err := migrateNode(root, true)
+	if err != nil {
+		return nil, err
+	}
+	result.From = from
+	result.To = documentVersion(root)
+	result.Applied = applied
+	if len(applied) == 0 {
+		return result, nil
+	}
+
+	var buf bytes.Buffer
+	enc := yaml.NewEncoder(&buf)
+	enc.SetIndent(2)
+	if err := enc.Encode(&doc); err != nil {
+		return nil, err
+	}
+	if err := enc.Close(); err != nil {
+		return nil, err
+	}
+	result.Data = buf.Bytes()
+	return result, nil
+}
+
+// migrateNode applies every pending migration to a configuration mapping.
+// With stamp set, the version key is written (or added) after each step;
+// otherwise it is only updated if the document already has one.
+func migrateNode(root *yaml.Node, stamp bool) (string, []Migration, error) {
+	from := documentVersion(root)
+	version := from
+
+	var applied []Migration
+	for {
+		m, ok := migrationFrom(version)
+		if !ok {
+			break
+		}
+		if err := m.Apply(root); err != nil {
+			return from, applied, fmt.Errorf("migration %s -> %s: %w", m.From, m.To, err)
+		}
+		version = m.To
+		applied = append(applied, m)
+
+		if node := mappingValue(root, "version"); node != nil {
+			node.Value = version
+			node.Tag = "!!str"
+		} else if stamp {
+			setMappingValue(root, "version", version, true)
+		}
+	}
+
+	return from, applied, nil
+}
+
+// documentVersion returns the version declared by a configuration mapping
+func documentVersion(root *yaml.Node) string {
+	if node := mappingValue(root, "version"); node != nil && node.Value != "" {
+		return node.Value
+	}
+	return initialVersion
+}
+
+func migrationFrom(version string) (Migration, bool) {
+	for _, m := range migrations {
+		if m.From == version {
+			return m, true
+		}
+	}
+	return Migration{}, false
+}
+
+// renameRetentionKeys maps the retention keys documented in early READMEs
+// (temp_files, debug_logs, shell_snapshots) to the *_days keys in use
+func renameRetentionKeys(root *yaml.Node) error {
+	retention := mappingValue(root, "retention")
+	if retention == nil || retention.Kind != yaml.MappingNode {
+		return nil
+	}
+
+	renames := map[string]string{
+		"temp_files":      "temp_files_days",
+		"debug_logs":      "debug_logs_days",
+		"shell_snapshots": "shell_snapshots_days",
+	}
+
+	content := make([]*yaml.Node, 0, len(retention.Content))
+	for i := 0; i+1 < len(retention.Content); i += 2 {
+		key, value := retention.Content[i], retention.Content[i+1]
+		if newKey, ok := renames[key.Value]; ok {
+			// An explicit new key wins over the old one
+			if mappingValue(retention, newKey) != nil {
+				continue
+			}
+			key.Value = newKey
+		}
+		content = append(content, key, value)
+	}
+	retention.Content = content
+	return nil
+}
+
+// setMappingValue sets a scalar in a mapping node, adding the key at the
+// front or back if it is missing
+func setMappingValue(node *yaml.Node, key, value string, front bool) {
+	if existing := mappingValue(node, key); existing != nil {
+		existing.Kind = yaml.ScalarNode
+		existing.Value = value
+		existing.Content = nil
+		return
+	}
+
+	k := &yaml.Node{Kind: yaml.ScalarNode, Tag: "!!str", Value: key}
+	v := &yaml.Node{Kind: yaml.ScalarNode, Tag: "!!str", Value: value}
+	if front {
+		node.Content = append([]*yaml.Node{k, v}, node.Content...)
+	} else {
+		node.Content = append(node.Content, k, v)
+	}
+}
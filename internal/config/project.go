@@ -0,0 +1,31 @@
+package config
+
+import (
+	"os"
+	"path/filepath"
+)
+
+// ProjectConfigName is the file name of a project-level configuration
+const ProjectConfigName = ".ai-manager.yaml"
+
+// FindProjectConfig walks up from dir and returns the path of the nearest
+// project configuration, or "" if there is none
+func FindProjectConfig(dir string) string {
+	dir, err := filepath.Abs(dir)
+	if err != nil {
+		return ""
+	}
+
+	for {
+		path := filepath.Join(dir, ProjectConfigName)
+		if info, err := os.Stat(path); err == nil && !info.IsDir() {
+			return path
+		}
+
+		parent := filepath.Dir(dir)
+		if parent == dir {
+			return ""
+		}
+		dir = parent
+	}
+}
@@ -0,0 +1,112 @@
+package config
+
+import (
+	"strings"
+	"testing"
+)
+
+func init() {
+	// Adapters register the built-in tools; this package has none
+	RegisterTool("claude", Tool{Name: "Claude Code", Path: "~/.claude", Enabled: true})
+	RegisterTool("gemini", Tool{Name: "Gemini CLI", Path: "~/.gemini", Enabled: true})
+}
+
+func TestProjectKeys(t *testing.T) {
+	tests := []struct {
+		name    string
+		project string
+		fatal   []string
+	}{
+		{
+			name: "allowed",
+			project: `version: "1.1.0"
+defaults:
+  model: local
+models:
+  local:
+    name: Local
+    provider: anthropic
+retention:
+  temp_files_days: 3
+`,
+		},
+		{
+			name: "tool path",
+			project: `tools:
+  claude:
+    path: /tmp/elsewhere
+`,
+			fatal: []string{"tools.claude.path"},
+		},
+		{
+			name: "rules and home_dir",
+			project: `home_dir: /tmp/state
+tools:
+  claude:
+    rules:
+      - include: ["**"]
+        action: delete
+`,
+			fatal: []string{"home_dir", "tools.claude.rules"},
+		},
+		{
+			name: "tool overrides",
+			project: `defaults:
+  model: claude-sonnet-4
+  cleanup_days: 1
+tools:
+  claude:
+    enabled: false
+  gemini:
+    quota: 1GiB
+`,
+		},
+		{
+			name: "tool files",
+			project: `tools:
+  gemini:
+    config_path: /etc/passwd
+    data_path: /
+    temp_paths: ["/home"]
+`,
+			fatal: []string{"tools.gemini.config_path", "tools.gemini.data_path", "tools.gemini.temp_paths"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			userPath, projectPath := setupProject(t, tt.project)
+			dir, err := TrustStateDir(userPath)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if err := Allow(dir, projectPath); err != nil {
+				t.Fatal(err)
+			}
+
+			cfg, err := LoadUnchecked(userPath)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if cfg.ProjectPath != projectPath {
+				t.Fatalf("ProjectPath = %q, want %q", cfg.ProjectPath, projectPath)
+			}
+
+			var fatal []string
+			for _, issue := range Validate(cfg) {
+				if issue.Fatal && strings.HasPrefix(issue.Message, "a project config can't set") {
+					fatal = append(fatal, issue.Key)
+					if issue.File != projectPath {
+						t.Errorf("issue %s has file %q, want %q", issue.Key, issue.File, projectPath)
+					}
+				}
+			}
+			if tt.fatal == nil && HasFatal(Validate(cfg)) {
+				t.Errorf("allowed project config has fatal issues: %v", Validate(cfg))
+			}
+			if strings.Join(fatal, " ") != strings.Join(tt.fatal, " ") {
+				t.Errorf("fatal issues = %q, want %q", fatal, tt.fatal)
+			}
+		})
+	}
+}
@@ -0,0 +1,96 @@
+package config
+
+import (
+	"path"
+	"strings"
+
+	"ai-manager/internal/utils"
+)
+
+// Cleanup actions a rule can take on the files it selects
+const (
+	ActionDelete   = "delete"
+	ActionTrash    = "trash"
+	ActionCompress = "compress"
+	ActionArchive  = "archive"
+)
+
+// Rule selects files in a tool directory for cleanup. Rules are evaluated
+// in order and each file belongs to the first rule whose include globs
+// match it and whose exclude globs don't. Globs are relative to the tool
+// path and "**" matches any number of directories.
+//
+// older_than and larger_than narrow which files the rule removes;
+// max_total_size additionally removes the oldest remaining files until the
+// rest fit, and keep_last always keeps the newest N files.
+type Rule struct {
+	Name         string   `yaml:"name,omitempty"`
+	Include      []string `yaml:"include"`
+	Exclude      []string `yaml:"exclude,omitempty"`
+	OlderThan    string   `yaml:"older_than,omitempty"`
+	LargerThan   string   `yaml:"larger_than,omitempty"`
+	KeepLast     int      `yaml:"keep_last,omitempty"`
+	MaxTotalSize string   `yaml:"max_total_size,omitempty"`
+	Action       string   `yaml:"action,omitempty"`
+}
+
+// ActionOrDefault returns the rule's action, trash if none is set
+func (r Rule) ActionOrDefault() string {
+	if r.Action == "" {
+		return ActionTrash
+	}
+	return r.Action
+}
+
+func (v *validator) checkRule(key string, rule Rule) {
+	if len(rule.Include) == 0 {
+		v.fatal(key+".include", "at least one include glob is required")
+	}
+	for _, glob := range append(append([]string{}, rule.Include...), rule.Exclude...) {
+		v.checkGlob(key, glob)
+	}
+
+	if rule.OlderThan != "" {
+		if _, err := utils.ParseAge(rule.OlderThan); err != nil {
+			v.fatal(key+".older_than", "%v", err)
+		}
+	}
+	if rule.LargerThan != "" {
+		if _, err := utils.ParseSize(rule.LargerThan); err != nil {
+			v.fatal(key+".larger_than", "%v", err)
+		}
+	}
+	if rule.MaxTotalSize != "" {
+		if _, err := utils.ParseSize(rule.MaxTotalSize); err != nil {
+			v.fatal(key+".max_total_size", "%v", err)
+		}
+	}
+	if rule.KeepLast < 0 {
+		v.fatal(key+".keep_last", "must not be negative, got %d", rule.KeepLast)
+	}
+
+	// A rule with only globs would remove every matching file
+	if rule.OlderThan == "" && rule.LargerThan == "" && rule.KeepLast == 0 && rule.MaxTotalSize == "" {
+		v.fatal(key, "needs at least one of older_than, larger_than, keep_last or max_total_size")
+	}
+
+	switch rule.ActionOrDefault() {
+	case ActionDelete, ActionTrash, ActionCompress, ActionArchive:
+	default:
+		v.fatal(key+".action", "unknown action %q (want delete, trash, compress or archive)", rule.Action)
+	}
+}
+
+func (v *validator) checkGlob(key, glob string) {
+	if glob == "" || strings.HasPrefix(glob, "/") || strings.HasPrefix(glob, "~") {
+		v.fatal(key, "glob %q must be relative to the tool path", glob)
+		return
+	}
+	if clean := path.Clean(glob); clean == ".." || strings.HasPrefix(clean, "../") {
+		v.fatal(key, "glob %q escapes the tool path", glob)
+		return
+	}
+	if err := utils.ValidGlob(glob); err != nil {
+		v.fatal(key, "invalid glob %q: %v", glob, err)
+	}
+}
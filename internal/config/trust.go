@@ -0,0 +1,195 @@
+package config
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"ai-manager/internal/safefile"
+
+	"gopkg.in/yaml.v3"
+)
+
+// TrustFileName is the file in the state directory that lists the project
+// configurations the user allowed, by path and content hash. Like direnv,
+// a project file is only used once it is allowed, and again after every
+// change to it.
+const TrustFileName = "allowed-projects.json"
+
+// trustStore maps the absolute path of an allowed project config to the
+// SHA-256 of the content that was allowed
+type trustStore map[string]string
+
+func trustPath(stateDir string) string {
+	return filepath.Join(stateDir, TrustFileName)
+}
+
+func readTrust(stateDir string) (trustStore, error) {
+	data, err := os.ReadFile(trustPath(stateDir))
+	if os.IsNotExist(err) {
+		return trustStore{}, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	store := trustStore{}
+	if err := json.Unmarshal(data, &store); err != nil {
+		return nil, fmt.Errorf("failed to parse %s: %w", trustPath(stateDir), err)
+	}
+	return store, nil
+}
+
+func writeTrust(stateDir string, store trustStore) error {
+	data, err := json.MarshalIndent(store, "", "  ")
+	if err != nil {
+		return err
+	}
+	return safefile.WriteFile(trustPath(stateDir), append(data, '\n'), 0600)
+}
+
+// contentHash returns the hex SHA-256 of a project config's content
+func contentHash(data []byte) string {
+	sum := sha256.Sum256(data)
+	return hex.EncodeToString(sum[:])
+}
+
+// IsAllowed reports whether the project config at path was allowed with
+// exactly this content
+func IsAllowed(stateDir, path string, data []byte) bool {
+	store, err := readTrust(stateDir)
+	if err != nil {
+		return false
+	}
+	hash, ok := store[path]
+	return ok && hash == contentHash(data)
+}
+
+// Allow trusts the current content of the project config at path
+func Allow(stateDir, path string) error {
+	path, err := filepath.Abs(path)
+	if err != nil {
+		return err
+	}
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return err
+	}
+
+	store, err := readTrust(stateDir)
+	if err != nil {
+		return err
+	}
+	store[path] = contentHash(data)
+	return writeTrust(stateDir, store)
+}
+
+// Deny removes the project config at path from the allowed ones
+func Deny(stateDir, path string) error {
+	path, err := filepath.Abs(path)
+	if err != nil {
+		return err
+	}
+
+	store, err := readTrust(stateDir)
+	if err != nil {
+		return err
+	}
+	delete(store, path)
+	return writeTrust(stateDir, store)
+}
+
+// TrustStateDir returns the state directory that holds the allowed project
+// configs: the home_dir of the system and user configs, which a project
+// config can't change
+func TrustStateDir(configPath string) (string, error) {
+	cfg, err := loadLayers([]layerFile{
+		{name: LayerSystem, path: SystemConfigPath},
+		{name: LayerUser, path: configPath},
+	})
+	if err != nil {
+		return "", err
+	}
+	return cfg.StateDir(), nil
+}
+
+// projectEnvSuffixes and projectEnvPrefixes match the only variables a
+// project config may set: the model names, timeouts and token limits a tool
+// reads, and switches that turn features off. Anything else could change
+// what runs (NODE_OPTIONS, BASH_ENV, PYTHONPATH, LD_*, PATH), where
+// requests go (*_BASE_URL, HTTPS_PROXY) or which key is sent (*_API_KEY).
+var (
+	projectEnvSuffixes = []string{"_MODEL", "_TIMEOUT_MS", "_TOKENS"}
+	projectEnvPrefixes = []string{"DISABLE_"}
+)
+
+// sensitiveEnv reports whether a project config may not set a variable
+func sensitiveEnv(key string) bool {
+	for _, suffix := range projectEnvSuffixes {
+		if strings.HasSuffix(key, suffix) {
+			return false
+		}
+	}
+	for _, prefix := range projectEnvPrefixes {
+		if strings.HasPrefix(key, prefix) {
+			return false
+		}
+	}
+	return true
+}
+
+// stripProjectSecrets removes from a project layer's node, before it is
+// merged, each model's api_endpoint and the environment variables
+// sensitiveEnv rejects, with a warning for each. The lower layers' values stay in
+// effect. api_endpoint is exported as ANTHROPIC_BASE_URL.
+func stripProjectSecrets(node *yaml.Node, l layerFile) []Issue {
+	var issues []Issue
+	drop := func(mapping *yaml.Node, i int, key, format string, args ...interface{}) {
+		value := mapping.Content[i+1]
+		issues = append(issues, Issue{
+			Key:     key,
+			Message: fmt.Sprintf(format, args...),
+			File:    l.path,
+			Line:    value.Line,
+			Column:  value.Column,
+		})
+		mapping.Content = append(mapping.Content[:i], mapping.Content[i+2:]...)
+	}
+
+	models := mappingValue(node, "models")
+	if models == nil || models.Kind != yaml.MappingNode {
+		return nil
+	}
+	for i := 0; i+1 < len(models.Content); i += 2 {
+		name, model := models.Content[i].Value, models.Content[i+1]
+		if model.Kind != yaml.MappingNode {
+			continue
+		}
+		prefix := "models." + name
+
+		for j := 0; j+1 < len(model.Content); {
+			if model.Content[j].Value == "api_endpoint" {
+				drop(model, j, prefix+".api_endpoint", "ignored: a project config can't set the API endpoint")
+				continue
+			}
+			j += 2
+		}
+
+		env := mappingValue(model, "environment")
+		if env == nil || env.Kind != yaml.MappingNode {
+			continue
+		}
+		for j := 0; j+1 < len(env.Content); {
+			if k := env.Content[j].Value; sensitiveEnv(k) {
+				drop(env, j, prefix+".environment."+k, "ignored: a project config can't set %s", k)
+				continue
+			}
+			j += 2
+		}
+	}
+	return issues
+}
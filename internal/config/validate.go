@@ -0,0 +1,320 @@
+package config
+
+import (
+	"fmt"
+	"net/url"
+	"path/filepath"
+	"reflect"
+	"slices"
+	"sort"
+	"strings"
+
+	"ai-manager/internal/utils"
+
+	"gopkg.in/yaml.v3"
+)
+
+// Issue is a single problem found while validating the configuration
+type Issue struct {
+	Key     string `json:"key"`
+	Message string `json:"message"`
+	Fatal   bool   `json:"fatal"`
+	File    string `json:"file,omitempty"`
+	Line    int    `json:"line,omitempty"`
+	Column  int    `json:"column,omitempty"`
+}
+
+// String formats the issue as "file:line:col: key: message"
+func (i Issue) String() string {
+	var b strings.Builder
+	if i.File != "" {
+		fmt.Fprintf(&b, "%s:%d:%d: ", i.File, i.Line, i.Column)
+	}
+	if i.Key != "" {
+		b.WriteString(i.Key + ": ")
+	}
+	b.WriteString(i.Message)
+	return b.String()
+}
+
+// ValidationError is returned by Load when the configuration has fatal issues
+type ValidationError struct {
+	Issues []Issue
+}
+
+func (e *ValidationError) Error() string {
+	lines := make([]string, 0, len(e.Issues))
+	for _, i := range e.Issues {
+		if i.Fatal {
+			lines = append(lines, i.String())
+		}
+	}
+	return "invalid configuration:\n  " + strings.Join(lines, "\n  ")
+}
+
+// HasFatal reports whether any issue is fatal
+func HasFatal(issues []Issue) bool {
+	for _, i := range issues {
+		if i.Fatal {
+			return true
+		}
+	}
+	return false
+}
+
+// Validate checks the merged configuration and returns every issue found,
+// including unknown keys seen while loading the layers
+func Validate(cfg *Config) []Issue {
+	v := &validator{cfg: cfg}
+	v.issues = append(v.issues, cfg.issues...)
+
+	v.checkRetention("retention.debug_logs_days", cfg.Retention.DebugLogs)
+	v.checkRetention("retention.temp_files_days", cfg.Retention.TempFiles)
+	v.checkRetention("retention.shell_snapshots_days", cfg.Retention.ShellSnapshots)
+	v.checkRetention("retention.trash_days", cfg.Retention.Trash)
+	v.checkRetention("defaults.cleanup_days", cfg.Defaults.Cleanup)
+
+	if cfg.Defaults.Model != "" {
+		if _, ok := cfg.Models[cfg.Defaults.Model]; !ok {
+			v.fatal("defaults.model", "model %q is not defined in models", cfg.Defaults.Model)
+		}
+	}
+
+	for _, key := range sortedMapKeys(cfg.Models) {
+		v.checkEndpoint(key, cfg.Models[key])
+	}
+
+	v.checkTools()
+	v.checkProjectKeys()
+
+	sort.SliceStable(v.issues, func(a, b int) bool {
+		ia, ib := v.issues[a], v.issues[b]
+		if ia.File != ib.File {
+			return ia.File < ib.File
+		}
+		return ia.Line < ib.Line
+	})
+	return v.issues
+}
+
+// validator collects issues for a configuration
+type validator struct {
+	cfg    *Config
+	issues []Issue
+}
+
+func (v *validator) fatal(key, format string, args ...interface{}) {
+	v.add(key, true, format, args...)
+}
+
+func (v *validator) warn(key, format string, args ...interface{}) {
+	v.add(key, false, format, args...)
+}
+
+func (v *validator) add(key string, fatal bool, format string, args ...interface{}) {
+	origin := v.origin(key)
+	v.issues = append(v.issues, Issue{
+		Key:     key,
+		Message: fmt.Sprintf(format, args...),
+		Fatal:   fatal,
+		File:    origin.File,
+		Line:    origin.Line,
+		Column:  origin.Column,
+	})
+}
+
+// origin returns the origin of key, or of its nearest recorded parent for
+// values inside lists, which are recorded as a whole
+func (v *validator) origin(key string) Origin {
+	for key != "" {
+		if o, ok := v.cfg.origins[key]; ok {
+			return o
+		}
+		i := strings.LastIndexAny(key, ".[")
+		if i < 0 {
+			break
+		}
+		key = key[:i]
+	}
+	return Origin{}
+}
+
+func (v *validator) checkRetention(key string, days int) {
+	if days <= 0 {
+		v.fatal(key, "must be a positive number of days, got %d", days)
+	}
+}
+
+func (v *validator) checkEndpoint(key string, model Model) {
+	if model.APIEndpoint == "" {
+		return
+	}
+
+	field := "models." + key + ".api_endpoint"
+	u, err := url.Parse(model.APIEndpoint)
+	if err != nil {
+		v.fatal(field, "malformed URL: %v", err)
+		return
+	}
+	if u.Scheme != "http" && u.Scheme != "https" {
+		v.fatal(field, "URL must use http or https: %q", model.APIEndpoint)
+		return
+	}
+	if u.Host == "" {
+		v.fatal(field, "URL has no host: %q", model.APIEndpoint)
+	}
+}
+
+// projectDeniedKeys are the settings a project config can't change: where
+// ai-mgr keeps its state and which files and directories a tool's settings,
+// data and cleanup rules point at. Otherwise checking out a repository
+// could make switch write to, or cleanup delete, files anywhere. Tool
+// overrides such as enabled and quota, models and retention stay allowed.
+var projectDeniedKeys = []string{"path", "config_path", "data_path", "temp_paths", "rules"}
+
+// checkProjectKeys rejects the path-like values the project layer set
+func (v *validator) checkProjectKeys() {
+	for _, key := range sortedMapKeys(v.cfg.origins) {
+		if v.cfg.origins[key].Layer == LayerProject && projectDenied(key) {
+			v.fatal(key, "a project config can't set home_dir or a tool's %s", strings.Join(projectDeniedKeys, ", "))
+		}
+	}
+}
+
+// projectDenied reports whether key is home_dir or a path-like tool setting
+func projectDenied(key string) bool {
+	if key == "home_dir" {
+		return true
+	}
+	parts := strings.SplitN(key, ".", 3)
+	if len(parts) < 3 || parts[0] != "tools" {
+		return false
+	}
+	field := parts[2]
+	if i := strings.IndexAny(field, ".["); i >= 0 {
+		field = field[:i]
+	}
+	return slices.Contains(projectDeniedKeys, field)
+}
+
+func (v *validator) checkTools() {
+	paths := make(map[string]string)
+
+	for _, key := range sortedMapKeys(v.cfg.Tools) {
+		tool := v.cfg.Tools[key]
+		prefix := "tools." + key
+
+		if tool.Path == "" {
+			v.fatal(prefix+".path", "tool path is required")
+		} else if tool.Enabled {
+			clean := filepath.Clean(tool.Path)
+			if other, ok := paths[clean]; ok {
+				v.warn(prefix+".path", "same path as tools.%s: %s", other, tool.Path)
+			} else {
+				paths[clean] = key
+			}
+		}
+
+		for _, temp := range tool.TempPaths {
+			if filepath.IsAbs(temp) || strings.HasPrefix(temp, "~") {
+				v.fatal(prefix+".temp_paths", "%q must be relative to the tool path", temp)
+				continue
+			}
+			clean := filepath.Clean(temp)
+			if clean == ".." || strings.HasPrefix(clean, ".."+string(filepath.Separator)) {
+				v.fatal(prefix+".temp_paths", "%q escapes the tool path", temp)
+			}
+		}
+
+		if tool.Quota != "" {
+			if _, err := utils.ParseSize(tool.Quota); err != nil {
+				v.fatal(prefix+".quota", "%v", err)
+			}
+		}
+
+		for i, rule := range tool.Rules {
+			v.checkRule(fmt.Sprintf("%s.rules[%d]", prefix, i), rule)
+		}
+	}
+}
+
+// checkKeys reports keys in a layer that don't match any configuration field
+func checkKeys(node *yaml.Node, t reflect.Type, path string, l layerFile) []Issue {
+	for t.Kind() == reflect.Ptr {
+		t = t.Elem()
+	}
+
+	var issues []Issue
+	switch t.Kind() {
+	case reflect.Struct:
+		if node.Kind != yaml.MappingNode {
+			return nil
+		}
+		fields := yamlFields(t)
+		for i := 0; i+1 < len(node.Content); i += 2 {
+			key, value := node.Content[i], node.Content[i+1]
+			keyPath := joinKey(path, key.Value)
+			ft, ok := fields[key.Value]
+			if !ok {
+				issues = append(issues, Issue{
+					Key:     keyPath,
+					Message: "unknown key",
+					Fatal:   true,
+					File:    l.path,
+					Line:    key.Line,
+					Column:  key.Column,
+				})
+				continue
+			}
+			issues = append(issues, checkKeys(value, ft, keyPath, l)...)
+		}
+
+	case reflect.Map:
+		if node.Kind != yaml.MappingNode {
+			return nil
+		}
+		for i := 0; i+1 < len(node.Content); i += 2 {
+			keyPath := joinKey(path, node.Content[i].Value)
+			issues = append(issues, checkKeys(node.Content[i+1], t.Elem(), keyPath, l)...)
+		}
+
+	case reflect.Slice:
+		if node.Kind != yaml.SequenceNode {
+			return nil
+		}
+		for i, item := range node.Content {
+			issues = append(issues, checkKeys(item, t.Elem(), fmt.Sprintf("%s[%d]", path, i), l)...)
+		}
+	}
+
+	return issues
+}
+
+// yamlFields maps the YAML keys of a struct to their field types
+func yamlFields(t reflect.Type) map[string]reflect.Type {
+	fields := make(map[string]reflect.Type)
+	for i := 0; i < t.NumField(); i++ {
+		f := t.Field(i)
+		if f.PkgPath != "" {
+			continue
+		}
+		name, _, _ := strings.Cut(f.Tag.Get("yaml"), ",")
+		if name == "-" {
+			continue
+		}
+		if name == "" {
+			name = strings.ToLower(f.Name)
+		}
+		fields[name] = f.Type
+	}
+	return fields
+}
+
+func sortedMapKeys[V any](m map[string]V) []string {
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
+}
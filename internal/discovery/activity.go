@@ -0,0 +1,86 @@
+package discovery
+
+import (
+	"os"
+	"path/filepath"
+	"sort"
+
+	"ai-manager/internal/adapter"
+	"ai-manager/internal/models"
+)
+
+// activity sets each tool's last use and its activity per project from
+// the sessions it recorded. Projects a tool records under an ID derived
+// from their path are matched against the paths the other tools recorded
+// and the current directory; those that don't match keep their ID.
+func activity(tools []models.ToolInfo, sessions map[string][]models.Session) {
+	known := make([]string, 0)
+	seen := make(map[string]bool)
+	addKnown := func(path string) {
+		if filepath.IsAbs(path) && !seen[path] {
+			seen[path] = true
+			known = append(known, path)
+		}
+	}
+	for _, key := range sortedKeys(sessions) {
+		for _, s := range sessions[key] {
+			addKnown(s.Project)
+		}
+	}
+	if wd, err := os.Getwd(); err == nil {
+		addKnown(wd)
+	}
+
+	for i := range tools {
+		tool := &tools[i]
+		paths := make(map[string]string)
+		if m, ok := adapter.For(tool.Key).(adapter.ProjectMatcher); ok {
+			for _, path := range known {
+				paths[m.ProjectID(path)] = path
+			}
+		}
+
+		byProject := make(map[string]*models.ProjectActivity)
+		for _, s := range sessions[tool.Key] {
+			project := s.Project
+			if path, ok := paths[project]; ok {
+				project = path
+			}
+
+			a := byProject[project]
+			if a == nil {
+				a = &models.ProjectActivity{Project: project}
+				byProject[project] = a
+			}
+			a.Sessions++
+			a.Size += s.Size
+			if s.Updated.After(a.LastActive) {
+				a.LastActive = s.Updated
+			}
+			if s.Updated.After(tool.LastUsed) {
+				tool.LastUsed = s.Updated
+			}
+		}
+
+		tool.Projects = make([]models.ProjectActivity, 0, len(byProject))
+		for _, a := range byProject {
+			if filepath.IsAbs(a.Project) {
+				_, err := os.Stat(a.Project)
+				a.Missing = os.IsNotExist(err)
+			}
+			tool.Projects = append(tool.Projects, *a)
+		}
+		sort.Slice(tool.Projects, func(i, j int) bool {
+			return tool.Projects[i].LastActive.After(tool.Projects[j].LastActive)
+		})
+	}
+}
+
+func sortedKeys[V any](m map[string]V) []string {
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
+}
@@ -0,0 +1,190 @@
+package discovery
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+
+	"ai-manager/internal/adapter"
+	"ai-manager/internal/models"
+	"ai-manager/internal/utils"
+)
+
+// installMethods recognize how a binary was installed from the path it
+// resolves to. The first marker found wins, so nvm comes before npm, whose
+// node_modules it contains.
+var installMethods = []struct {
+	marker string
+	method string
+}{
+	{"/.nvm/", "nvm"},
+	{"/pipx/venvs/", "pipx"},
+	{"/.bun/", "bun"},
+	{"/node_modules/", "npm"},
+	{"/.npm-global/", "npm"},
+	{"/Cellar/", "homebrew"},
+	{"/homebrew/", "homebrew"},
+	{"/linuxbrew/", "homebrew"},
+	{"/.cargo/", "cargo"},
+	{"/nix/store/", "nix"},
+	{"/snap/", "snap"},
+	{"/Applications/", "app"},
+}
+
+// searchDir is a directory binaries are looked for in
+type searchDir struct {
+	path   string
+	onPath bool
+}
+
+// searchDirs returns the directories on PATH, in order, followed by the
+// places version managers and package managers install binaries to, which
+// may not be on PATH
+func searchDirs() []searchDir {
+	dirs := make([]searchDir, 0)
+	seen := make(map[string]bool)
+	add := func(dir string, onPath bool) {
+		if dir == "" {
+			return
+		}
+		dir = filepath.Clean(utils.ExpandPath(dir))
+		if !seen[dir] {
+			seen[dir] = true
+			dirs = append(dirs, searchDir{path: dir, onPath: onPath})
+		}
+	}
+
+	for _, dir := range filepath.SplitList(os.Getenv("PATH")) {
+		add(dir, true)
+	}
+
+	nvm := envOr("NVM_DIR", "~/.nvm")
+	versions, _ := filepath.Glob(filepath.Join(utils.ExpandPath(nvm), "versions", "node", "*", "bin"))
+	sort.Strings(versions)
+	for _, dir := range versions {
+		add(dir, false)
+	}
+	if prefix := os.Getenv("NPM_CONFIG_PREFIX"); prefix != "" {
+		add(filepath.Join(prefix, "bin"), false)
+	}
+	add("~/.npm-global/bin", false)
+	add(filepath.Join(envOr("BUN_INSTALL", "~/.bun"), "bin"), false)
+	add(envOr("PIPX_BIN_DIR", "~/.local/bin"), false)
+	return dirs
+}
+
+// envOr returns an environment variable, or def if it is unset
+func envOr(key, def string) string {
+	if v := os.Getenv(key); v != "" {
+		return v
+	}
+	return def
+}
+
+// findBinaries returns every copy of an executable in dirs. Paths that
+// resolve to the same file are one copy. The first copy on PATH is the
+// active one; the others on PATH are shadowed by it.
+func findBinaries(name string, dirs []searchDir) []models.Binary {
+	binaries := make([]models.Binary, 0)
+	seen := make(map[string]bool)
+	active := false
+
+	for _, dir := range dirs {
+		path := filepath.Join(dir.path, name)
+		info, err := os.Stat(path)
+		if err != nil || info.IsDir() || info.Mode()&0111 == 0 {
+			continue
+		}
+
+		target, err := filepath.EvalSymlinks(path)
+		if err != nil {
+			continue
+		}
+		if seen[target] {
+			continue
+		}
+		seen[target] = true
+
+		b := models.Binary{
+			Path:   path,
+			Method: installMethod(path, target),
+			OnPath: dir.onPath,
+		}
+		if target != path {
+			b.Target = target
+		}
+		if dir.onPath && !active {
+			b.Active = true
+			active = true
+		}
+		binaries = append(binaries, b)
+	}
+	return binaries
+}
+
+// shadowed reports whether more than one copy is on PATH, so the active
+// one hides the others. Copies only version managers know about don't
+// count; they are only run through the manager.
+func shadowed(binaries []models.Binary) bool {
+	n := 0
+	for _, b := range binaries {
+		if b.OnPath {
+			n++
+		}
+	}
+	return n > 1
+}
+
+// installMethod guesses how a binary was installed from where it resolves
+// to, or from where it is linked when the target says nothing. Binaries
+// that were copied or linked into place by hand are "manual".
+func installMethod(path, target string) string {
+	for _, p := range []string{target, path} {
+		p = filepath.ToSlash(p)
+		for _, m := range installMethods {
+			if strings.Contains(p, m.marker) {
+				return m.method
+			}
+		}
+	}
+
+	switch filepath.Dir(target) {
+	case "/usr/bin", "/bin", "/usr/sbin", "/sbin":
+		return "system"
+	}
+	return "manual"
+}
+
+// versions runs every binary found by a scan with --version at the same
+// time, and sets each tool's version from its active binary, or the first
+// one found when none is on PATH
+func versions(ctx context.Context, tools []models.ToolInfo) {
+	var wg sync.WaitGroup
+	for i := range tools {
+		for j := range tools[i].Binaries {
+			wg.Add(1)
+			go func(b *models.Binary) {
+				defer wg.Done()
+				v, err := adapter.VersionOf(ctx, b.Path)
+				if err != nil {
+					b.Error = err.Error()
+					return
+				}
+				b.Version = v
+			}(&tools[i].Binaries[j])
+		}
+	}
+	wg.Wait()
+
+	for i := range tools {
+		for j, b := range tools[i].Binaries {
+			if b.Active || j == 0 {
+				tools[i].Version = b.Version
+				tools[i].InstallMethod = b.Method
+			}
+		}
+	}
+}
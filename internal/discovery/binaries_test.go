@@ -0,0 +1,195 @@
+package discovery
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"ai-manager/internal/models"
+)
+
+// stub writes an executable script at path that runs body
+func stub(t *testing.T, path, body string) {
+	t.Helper()
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(path, []byte("#!/bin/sh\n"+body+"\n"), 0755); err != nil {
+		t.Fatal(err)
+	}
+}
+
+// fakeHome points HOME and the package manager variables at a temp dir and
+// returns it
+func fakeHome(t *testing.T) string {
+	t.Helper()
+	home := t.TempDir()
+	t.Setenv("HOME", home)
+	t.Setenv("NVM_DIR", "")
+	t.Setenv("NPM_CONFIG_PREFIX", "")
+	t.Setenv("BUN_INSTALL", "")
+	t.Setenv("PIPX_BIN_DIR", "")
+	return home
+}
+
+func TestFindBinaries(t *testing.T) {
+	home := fakeHome(t)
+	usrBin := filepath.Join(home, "usr", "bin")
+	localBin := filepath.Join(home, "opt", "bin")
+	noexec := filepath.Join(home, "noexec")
+	t.Setenv("PATH", strings.Join([]string{noexec, localBin, usrBin}, string(os.PathListSeparator)))
+
+	nvm := filepath.Join(home, ".nvm", "versions", "node", "v20.11.0", "bin", "claude")
+	bun := filepath.Join(home, ".bun", "bin", "claude")
+	npm := filepath.Join(home, ".npm-global", "bin", "claude")
+	stub(t, nvm, "echo 1.0.0")
+	stub(t, bun, "echo 1.0.1")
+	stub(t, npm, "echo 1.0.2")
+	stub(t, filepath.Join(usrBin, "claude"), "echo 0.9.0")
+
+	// pipx links its binaries to the package's venv
+	venv := filepath.Join(home, ".local", "pipx", "venvs", "claude", "bin", "claude")
+	stub(t, venv, "echo 0.8.0")
+	if err := os.MkdirAll(filepath.Join(home, ".local", "bin"), 0755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.Symlink(venv, filepath.Join(home, ".local", "bin", "claude")); err != nil {
+		t.Fatal(err)
+	}
+
+	// The same file linked from PATH is one copy
+	if err := os.MkdirAll(localBin, 0755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.Symlink(nvm, filepath.Join(localBin, "claude")); err != nil {
+		t.Fatal(err)
+	}
+
+	// A file that isn't executable is no copy
+	if err := os.MkdirAll(noexec, 0755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(noexec, "claude"), nil, 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	got := findBinaries("claude", searchDirs())
+	want := []models.Binary{
+		{Path: filepath.Join(localBin, "claude"), Target: nvm, Method: "nvm", OnPath: true, Active: true},
+		{Path: filepath.Join(usrBin, "claude"), Method: "manual", OnPath: true},
+		{Path: npm, Method: "npm"},
+		{Path: bun, Method: "bun"},
+		{Path: filepath.Join(home, ".local", "bin", "claude"), Target: venv, Method: "pipx"},
+	}
+	if len(got) != len(want) {
+		t.Fatalf("found %d binaries, want %d: %+v", len(got), len(want), got)
+	}
+	for i := range want {
+		if got[i] != want[i] {
+			t.Errorf("binary %d = %+v, want %+v", i, got[i], want[i])
+		}
+	}
+	if !shadowed(got) {
+		t.Error("two copies on PATH aren't shadowed")
+	}
+}
+
+func TestFindBinariesEnvDirs(t *testing.T) {
+	home := fakeHome(t)
+	t.Setenv("PATH", filepath.Join(home, "bin"))
+	t.Setenv("NVM_DIR", filepath.Join(home, "nvm"))
+	t.Setenv("NPM_CONFIG_PREFIX", filepath.Join(home, "npm"))
+	t.Setenv("BUN_INSTALL", filepath.Join(home, "bun"))
+	t.Setenv("PIPX_BIN_DIR", filepath.Join(home, "pipx"))
+
+	paths := []string{
+		filepath.Join(home, "bin", "gemini"),
+		filepath.Join(home, "nvm", "versions", "node", "v18.0.0", "bin", "gemini"),
+		filepath.Join(home, "nvm", "versions", "node", "v22.0.0", "bin", "gemini"),
+		filepath.Join(home, "npm", "bin", "gemini"),
+		filepath.Join(home, "bun", "bin", "gemini"),
+		filepath.Join(home, "pipx", "gemini"),
+	}
+	for _, p := range paths {
+		stub(t, p, "echo")
+	}
+
+	got := findBinaries("gemini", searchDirs())
+	if len(got) != len(paths) {
+		t.Fatalf("found %d binaries, want %d: %+v", len(got), len(paths), got)
+	}
+	for i, p := range paths {
+		if got[i].Path != p {
+			t.Errorf("binary %d = %s, want %s", i, got[i].Path, p)
+		}
+		if got[i].OnPath != (i == 0) || got[i].Active != (i == 0) {
+			t.Errorf("%s on PATH %v, active %v", p, got[i].OnPath, got[i].Active)
+		}
+	}
+
+	// One copy on PATH and others only a version manager knows about
+	if shadowed(got) {
+		t.Error("copies off PATH make the tool shadowed")
+	}
+}
+
+func TestVersions(t *testing.T) {
+	dir := t.TempDir()
+	ok := filepath.Join(dir, "ok")
+	old := filepath.Join(dir, "old")
+	failing := filepath.Join(dir, "failing")
+	stub(t, ok, `[ "$1" = --version ] && printf '\n  2.0.14 (Claude Code)\nmore output\n'`)
+	stub(t, old, "echo 1.0.0")
+	stub(t, failing, "echo broken >&2; exit 1")
+
+	tools := []models.ToolInfo{
+		{Key: "claude", Binaries: []models.Binary{
+			{Path: old, Method: "npm"},
+			{Path: ok, Method: "nvm", OnPath: true, Active: true},
+		}},
+		{Key: "gemini", Binaries: []models.Binary{
+			{Path: failing, Method: "manual"},
+			{Path: old, Method: "bun"},
+		}},
+	}
+	versions(context.Background(), tools)
+
+	if got := tools[0].Binaries[1].Version; got != "2.0.14 (Claude Code)" {
+		t.Errorf("version = %q, want the first line of the output", got)
+	}
+	if tools[0].Version != "2.0.14 (Claude Code)" || tools[0].InstallMethod != "nvm" {
+		t.Errorf("tool version = %q (%s), want the active binary's", tools[0].Version, tools[0].InstallMethod)
+	}
+
+	// Without an active binary the first one found counts
+	if tools[1].Binaries[0].Error == "" {
+		t.Error("no error for a failing --version")
+	}
+	if tools[1].Version != "" || tools[1].InstallMethod != "manual" {
+		t.Errorf("tool version = %q (%s), want the first binary's", tools[1].Version, tools[1].InstallMethod)
+	}
+}
+
+func TestVersionsTimeout(t *testing.T) {
+	dir := t.TempDir()
+	slow := filepath.Join(dir, "slow")
+	// The child keeps the output open after the script is killed
+	stub(t, slow, "sleep 30 & sleep 30")
+
+	ctx, cancel := context.WithTimeout(context.Background(), 200*time.Millisecond)
+	defer cancel()
+
+	tools := []models.ToolInfo{{Key: "slow", Binaries: []models.Binary{{Path: slow, OnPath: true, Active: true}}}}
+	start := time.Now()
+	versions(ctx, tools)
+
+	if elapsed := time.Since(start); elapsed > 3*time.Second {
+		t.Errorf("versions took %s after the deadline", elapsed)
+	}
+	if err := tools[0].Binaries[0].Error; !strings.Contains(err, "timed out") {
+		t.Errorf("error = %q, want a timeout", err)
+	}
+}
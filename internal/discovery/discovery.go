@@ -1,18 +1,21 @@
 package discovery
 
 import (
+	"context"
 	"os"
-	"path/filepath"
-	"strings"
 	"time"
 
+	"ai-manager/internal/adapter"
 	"ai-manager/internal/config"
 	"ai-manager/internal/models"
+	"ai-manager/internal/utils"
+	"ai-manager/internal/walker"
 )
 
 // Scanner scans the system for AI tools
 type Scanner struct {
-	cfg *config.Config
+	cfg   *config.Config
+	index *walker.Index
 }
 
 // NewScanner creates a new tool scanner
@@ -20,70 +23,96 @@ func NewScanner(cfg *config.Config) *Scanner {
 	return &Scanner{cfg: cfg}
 }
 
+// UseIndex makes the scanner measure disk usage through a usage index, so
+// only directories that changed since the last scan are read
+func (s *Scanner) UseIndex(index *walker.Index) {
+	s.index = index
+}
+
 // Scan discovers all configured AI tools
-func (s *Scanner) Scan() (*models.ScanResult, error) {
+func (s *Scanner) Scan(ctx context.Context) (*models.ScanResult, error) {
 	result := &models.ScanResult{
 		Tools:     make([]models.ToolInfo, 0),
 		Timestamp: time.Now(),
 	}
 
+	dirs := searchDirs()
+	sessions := make(map[string][]models.Session)
 	for key, tool := range s.cfg.Tools {
 		if !tool.Enabled {
 			continue
 		}
 
-		info := s.discoverTool(key, tool)
+		info := s.discoverTool(ctx, key, tool, dirs)
+		if err := ctx.Err(); err != nil {
+			return nil, err
+		}
+		if info.Found {
+			// Unreadable sessions only leave the activity out
+			sessions[key], _ = adapter.For(key).Sessions(tool)
+		}
 		result.Tools = append(result.Tools, info)
 		result.Enabled++
 	}
 
+	versions(ctx, result.Tools)
+	activity(result.Tools, sessions)
+
 	result.Total = len(result.Tools)
 	return result, nil
 }
 
-// discoverTool discovers a single tool
-func (s *Scanner) discoverTool(key string, tool config.Tool) models.ToolInfo {
+// discoverTool discovers a single tool. A tool whose binary is installed
+// but that has no directory yet is found with a warning.
+func (s *Scanner) discoverTool(ctx context.Context, key string, tool config.Tool, dirs []searchDir) models.ToolInfo {
 	info := models.ToolInfo{
+		Key:        key,
 		Name:       tool.Name,
 		Enabled:    tool.Enabled,
 		ConfigPath: tool.ConfigPath,
 		DataPath:   tool.DataPath,
 	}
 
-	// Expand paths
-	home, _ := os.UserHomeDir()
-	toolPath := expandPath(tool.Path, home)
-	configPath := expandPath(tool.ConfigPath, home)
+	a := adapter.For(key)
+	info.Binaries = findBinaries(a.Binary(), dirs)
+	info.Shadowed = shadowed(info.Binaries)
 
-	// Check if tool exists
-	if _, err := os.Stat(toolPath); os.IsNotExist(err) {
+	detected := a.Detect(tool)
+	if !detected && len(info.Binaries) == 0 {
 		info.Found = false
 		info.Status = models.StatusNotFound
 		return info
 	}
 
+	toolPath := utils.ExpandPath(tool.Path)
 	info.Found = true
 	info.Path = toolPath
 
 	// Check config file
-	if _, err := os.Stat(configPath); os.IsNotExist(err) {
+	info.ConfigPath = a.SettingsPath(tool)
+	if _, err := os.Stat(info.ConfigPath); !detected || (info.ConfigPath != "" && os.IsNotExist(err)) {
 		info.Status = models.StatusWarning
 	} else {
 		info.Status = models.StatusOK
 	}
 
 	// Calculate disk usage
-	if usage, err := models.CalculateDiskUsage(toolPath); err == nil {
+	if usage, err := s.diskUsage(ctx, toolPath); err == nil {
 		info.DiskUsage = usage
 	}
 
 	return info
 }
 
-// expandPath expands ~ and environment variables
-func expandPath(path string, home string) string {
-	if strings.HasPrefix(path, "~/") {
-		return filepath.Join(home, path[2:])
+// diskUsage measures a tool directory, through the index if there is one
+func (s *Scanner) diskUsage(ctx context.Context, path string) (models.DiskUsage, error) {
+	if s.index == nil {
+		return models.CalculateDiskUsage(ctx, path)
+	}
+
+	summary, err := s.index.Usage(ctx, path, walker.Options{})
+	if err != nil {
+		return models.DiskUsage{Path: path}, err
 	}
-	return os.ExpandEnv(path)
+	return models.DiskUsage{Path: path, SizeBytes: summary.Size, Files: summary.Files}, nil
 }
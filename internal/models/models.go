@@ -1,15 +1,19 @@
 package models
 
 import (
+	"context"
 	"fmt"
 	"os"
 	"path/filepath"
 	"strings"
 	"time"
+
+	"ai-manager/internal/walker"
 )
 
 // ToolInfo represents discovered AI tool information
 type ToolInfo struct {
+	Key        string      `json:"key"`
 	Name       string      `json:"name"`
 	Path       string      `json:"path"`
 	Found      bool        `json:"found"`
@@ -19,6 +23,38 @@ type ToolInfo struct {
 	DiskUsage  DiskUsage   `json:"disk_usage"`
 	LastUsed   time.Time   `json:"last_used"`
 	Status     ToolStatus  `json:"status"`
+
+	// Version and InstallMethod describe the copy of the tool's binary
+	// that PATH finds first
+	Version       string   `json:"version,omitempty"`
+	InstallMethod string   `json:"install_method,omitempty"`
+	Binaries      []Binary `json:"binaries,omitempty"`
+	// Shadowed is set when more than one copy is on PATH
+	Shadowed bool `json:"shadowed,omitempty"`
+
+	// Projects is the tool's activity per project, most recent first
+	Projects []ProjectActivity `json:"projects,omitempty"`
+}
+
+// ProjectActivity sums up the sessions a tool recorded for one project.
+// Missing is set when the project directory no longer exists.
+type ProjectActivity struct {
+	Project    string    `json:"project"`
+	Sessions   int       `json:"sessions"`
+	LastActive time.Time `json:"last_active"`
+	Size       int64     `json:"size"`
+	Missing    bool      `json:"missing,omitempty"`
+}
+
+// Binary is an installed copy of a tool's executable
+type Binary struct {
+	Path    string `json:"path"`
+	Target  string `json:"target,omitempty"`
+	Method  string `json:"method"`
+	Version string `json:"version,omitempty"`
+	OnPath  bool   `json:"on_path"`
+	Active  bool   `json:"active"`
+	Error   string `json:"error,omitempty"`
 }
 
 // ToolStatus represents the health status of a tool
@@ -31,6 +67,53 @@ const (
 	StatusNotFound ToolStatus = "not_found"
 )
 
+// Session is a conversation recorded by an AI tool
+type Session struct {
+	Tool    string    `json:"tool"`
+	ID      string    `json:"id"`
+	Project string    `json:"project,omitempty"`
+	Path    string    `json:"path"`
+	Size    int64     `json:"size"`
+	Updated time.Time `json:"updated"`
+}
+
+// Workspace is the storage an editor keeps for one opened folder or
+// workspace file. It is orphaned when that folder or file no longer exists.
+type Workspace struct {
+	Tool       string           `json:"tool"`
+	ID         string           `json:"id"`
+	Path       string           `json:"path"`
+	Folder     string           `json:"folder,omitempty"`
+	Remote     bool             `json:"remote,omitempty"`
+	Orphaned   bool             `json:"orphaned"`
+	Size       int64            `json:"size"`
+	Files      int              `json:"files"`
+	LastUsed   time.Time        `json:"last_used"`
+	Extensions []ExtensionUsage `json:"extensions,omitempty"`
+}
+
+// ExtensionUsage is the storage one AI extension uses in a workspace
+type ExtensionUsage struct {
+	ID    string `json:"id"`
+	Name  string `json:"name"`
+	Size  int64  `json:"size"`
+	Files int    `json:"files"`
+}
+
+// HealthCheck is the outcome of one health check of a tool
+type HealthCheck struct {
+	Check   string     `json:"check"`
+	Status  ToolStatus `json:"status"`
+	Message string     `json:"message"`
+}
+
+// ToolHealth is the result of the health checks of one tool
+type ToolHealth struct {
+	Key    string        `json:"key"`
+	Tool   string        `json:"tool"`
+	Checks []HealthCheck `json:"checks"`
+}
+
 // DiskUsage represents disk usage information
 type DiskUsage struct {
 	Path      string `json:"path"`
@@ -38,16 +121,70 @@ type DiskUsage struct {
 	Files     int    `json:"files"`
 }
 
+// ToolStats is the disk usage summary of one tool for the stats command
+type ToolStats struct {
+	Key  string `json:"key"`
+	Tool string `json:"tool"`
+	*walker.Summary
+}
+
 // CleanupResult represents the result of a cleanup operation
 type CleanupResult struct {
 	Tool      string    `json:"tool"`
 	Path      string    `json:"path"`
 	FilesDeleted int    `json:"files_deleted"`
+	FilesTrashed int    `json:"files_trashed"`
+	FilesCompressed int `json:"files_compressed"`
+	FilesSkipped int    `json:"files_skipped"`
+	FilesArchived int   `json:"files_archived"`
 	SpaceFreed int64    `json:"space_freed"`
+	SpaceSaved int64    `json:"space_saved"`
+	QuotaFreed int64    `json:"quota_freed,omitempty"`
+	Skipped   []string  `json:"skipped,omitempty"`
+	Warnings  []string  `json:"warnings,omitempty"`
 	Duration  time.Duration `json:"duration"`
 	Error     error     `json:"error,omitempty"`
 }
 
+// TrashItem describes a file moved to the ai-mgr trash by cleanup
+type TrashItem struct {
+	ID           string    `json:"id"`
+	OriginalPath string    `json:"original_path"`
+	Tool         string    `json:"tool"`
+	TrashedAt    time.Time `json:"trashed_at"`
+	Size         int64     `json:"size"`
+	ModTime      time.Time `json:"mod_time"`
+}
+
+// ArchiveEntry describes a tar.gz archive written by cleanup
+type ArchiveEntry struct {
+	Name         string         `json:"name"`
+	Path         string         `json:"path"`
+	Tool         string         `json:"tool"`
+	Created      time.Time      `json:"created"`
+	Size         int64          `json:"size"`
+	OriginalSize int64          `json:"original_size"`
+	Files        []ArchivedFile `json:"files"`
+}
+
+// ArchivedFile is a file packed into an archive
+type ArchivedFile struct {
+	Name         string    `json:"name"`
+	OriginalPath string    `json:"original_path"`
+	Size         int64     `json:"size"`
+	ModTime      time.Time `json:"mod_time"`
+}
+
+// SwitchResult represents the result of applying a model to a tool
+type SwitchResult struct {
+	Tool         string `json:"tool"`
+	SettingsPath string `json:"settings_path"`
+	Model        string `json:"model"`
+	Skipped      bool   `json:"skipped,omitempty"`
+	Reason       string `json:"reason,omitempty"`
+	Error        string `json:"error,omitempty"`
+}
+
 // ScanResult represents the result of a tool scan
 type ScanResult struct {
 	Tools     []ToolInfo  `json:"tools"`
@@ -57,32 +194,19 @@ type ScanResult struct {
 }
 
 // CalculateDiskUsage calculates disk usage for a path
-func CalculateDiskUsage(path string) (DiskUsage, error) {
-	var size int64
-	var count int
-
+func CalculateDiskUsage(ctx context.Context, path string) (DiskUsage, error) {
 	// Expand tilde
 	path = expandHome(path)
 
-	err := filepath.Walk(path, func(path string, info os.FileInfo, err error) error {
-		if err != nil {
-			return nil // Skip errors
-		}
-		if !info.IsDir() {
-			size += info.Size()
-			count++
-		}
-		return nil
-	})
-
+	summary, err := walker.Usage(ctx, path, walker.Options{})
 	if err != nil {
 		return DiskUsage{Path: path}, err
 	}
 
 	return DiskUsage{
 		Path:      path,
-		SizeBytes: size,
-		Files:     count,
+		SizeBytes: summary.Size,
+		Files:     summary.Files,
 	}, nil
 }
 
@@ -0,0 +1,195 @@
+package runner
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"os/exec"
+	"os/signal"
+	"sort"
+	"strings"
+	"syscall"
+
+	"ai-manager/internal/adapter"
+	"ai-manager/internal/config"
+	"ai-manager/internal/switcher"
+)
+
+// forwardedSignals are passed on to the launched tool
+var forwardedSignals = []os.Signal{
+	syscall.SIGINT,
+	syscall.SIGTERM,
+	syscall.SIGHUP,
+	syscall.SIGQUIT,
+	syscall.SIGWINCH,
+}
+
+// Runner launches AI tools with a model's environment injected, leaving the
+// tools' persistent settings files untouched
+type Runner struct {
+	cfg    *config.Config
+	Stdin  io.Reader
+	Stdout io.Writer
+	Stderr io.Writer
+}
+
+// NewRunner creates a new tool runner attached to the current terminal
+func NewRunner(cfg *config.Config) *Runner {
+	return &Runner{
+		cfg:    cfg,
+		Stdin:  os.Stdin,
+		Stdout: os.Stdout,
+		Stderr: os.Stderr,
+	}
+}
+
+// Run execs the tool with the model's environment and returns its exit code
+func (r *Runner) Run(toolKey, modelKey string, args []string) (int, error) {
+	tool, ok := r.cfg.Tools[toolKey]
+	if !ok {
+		return 1, fmt.Errorf("unknown tool: %s", toolKey)
+	}
+
+	model, ok := r.cfg.Models[modelKey]
+	if !ok {
+		return 1, fmt.Errorf("unknown model: %s", modelKey)
+	}
+
+	binary, err := exec.LookPath(adapter.For(toolKey).Binary())
+	if err != nil {
+		return 1, fmt.Errorf("%s: %w", tool.Name, err)
+	}
+
+	env, err := r.Environment(toolKey, modelKey, model)
+	if err != nil {
+		return 1, err
+	}
+
+	cmd := exec.Command(binary, r.Arguments(toolKey, model, args)...)
+	cmd.Env = env
+	cmd.Stdin = r.Stdin
+	cmd.Stdout = r.Stdout
+	cmd.Stderr = r.Stderr
+
+	sigs := make(chan os.Signal, 1)
+	signal.Notify(sigs, forwardedSignals...)
+
+	if err := cmd.Start(); err != nil {
+		signal.Stop(sigs)
+		return 1, err
+	}
+
+	go func() {
+		for sig := range sigs {
+			cmd.Process.Signal(sig)
+		}
+	}()
+
+	err = cmd.Wait()
+	signal.Stop(sigs)
+	close(sigs)
+	return exitCode(err)
+}
+
+// Environment returns the process environment with the model's variables
+// and API key layered over the current one
+func (r *Runner) Environment(toolKey, modelKey string, model config.Model) ([]string, error) {
+	overrides := adapter.ModelEnv(model)
+	if l, ok := adapter.For(toolKey).(adapter.Launcher); ok {
+		env, _ := l.LaunchModel(model)
+		for k, v := range env {
+			overrides[k] = v
+		}
+	}
+	overrides[switcher.EnvActiveModel] = modelKey
+
+	keyEnv := APIKeyEnv(model)
+	if keyEnv != "" {
+		key, err := ResolveAPIKey(keyEnv)
+		if err != nil {
+			// The tool may still be logged in on its own, so carry on
+			fmt.Fprintf(r.Stderr, "warning: no API key for %s: set %s or store it in the secret store (%v)\n",
+				modelKey, keyEnv, err)
+		} else {
+			overrides[keyEnv] = key
+
+			// Claude Code authenticates against Anthropic-compatible endpoints
+			// of other providers with ANTHROPIC_AUTH_TOKEN
+			if toolKey == "claude" && keyEnv != "ANTHROPIC_API_KEY" {
+				overrides["ANTHROPIC_AUTH_TOKEN"] = key
+			}
+		}
+	}
+
+	return mergeEnv(os.Environ(), overrides), nil
+}
+
+// Arguments returns the tool's command line: the flags that select the
+// model, unless the user chose one, followed by args
+func (r *Runner) Arguments(toolKey string, model config.Model, args []string) []string {
+	l, ok := adapter.For(toolKey).(adapter.Launcher)
+	if !ok {
+		return args
+	}
+	_, modelArgs := l.LaunchModel(model)
+	for _, arg := range args {
+		if arg == "-m" || arg == "--model" || strings.HasPrefix(arg, "--model=") {
+			return args
+		}
+	}
+	return append(modelArgs, args...)
+}
+
+// APIKeyEnv returns the variable holding a model's API key, derived from
+// its provider unless set explicitly (e.g. zhipu -> ZHIPU_API_KEY)
+func APIKeyEnv(model config.Model) string {
+	if model.APIKeyEnv != "" {
+		return model.APIKeyEnv
+	}
+	if model.Provider == "" {
+		return ""
+	}
+	name := strings.ToUpper(model.Provider)
+	name = strings.NewReplacer("-", "_", ".", "_", " ", "_").Replace(name)
+	return name + "_API_KEY"
+}
+
+// mergeEnv replaces or appends overrides in a KEY=VALUE environment list
+func mergeEnv(base []string, overrides map[string]string) []string {
+	env := make([]string, 0, len(base)+len(overrides))
+	for _, kv := range base {
+		key, _, _ := strings.Cut(kv, "=")
+		if _, ok := overrides[key]; ok {
+			continue
+		}
+		env = append(env, kv)
+	}
+
+	keys := make([]string, 0, len(overrides))
+	for k := range overrides {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	for _, k := range keys {
+		env = append(env, k+"="+overrides[k])
+	}
+	return env
+}
+
+// exitCode converts a Wait error into the exit code a shell would report
+func exitCode(err error) (int, error) {
+	if err == nil {
+		return 0, nil
+	}
+
+	var exitErr *exec.ExitError
+	if !errors.As(err, &exitErr) {
+		return 1, err
+	}
+
+	if status, ok := exitErr.Sys().(syscall.WaitStatus); ok && status.Signaled() {
+		return 128 + int(status.Signal()), nil
+	}
+	return exitErr.ExitCode(), nil
+}
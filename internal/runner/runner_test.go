@@ -0,0 +1,181 @@
+package runner
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+
+	"ai-manager/internal/config"
+	"ai-manager/internal/switcher"
+)
+
+// stub is a tool binary that records its arguments and environment
+const stub = `#!/bin/sh
+for arg in "$@"; do echo "$arg"; done > "$STUB_OUT.args"
+env > "$STUB_OUT.env"
+exit 3
+`
+
+func TestRun(t *testing.T) {
+	model := config.Model{
+		Name:        "Test Model",
+		Provider:    "test",
+		APIEndpoint: "https://api.test.example/v1",
+		ModelID:     "test-model-1",
+		APIKeyEnv:   "TEST_API_KEY",
+	}
+
+	tests := []struct {
+		tool     string
+		binary   string
+		args     []string
+		wantArgs []string
+		wantEnv  map[string]string
+	}{
+		{
+			tool:     "claude",
+			binary:   "claude",
+			args:     []string{"-p", "hi"},
+			wantArgs: []string{"-p", "hi"},
+			wantEnv: map[string]string{
+				"ANTHROPIC_BASE_URL":    model.APIEndpoint,
+				"ANTHROPIC_MODEL":       model.ModelID,
+				"ANTHROPIC_AUTH_TOKEN":  "secret",
+				"TEST_API_KEY":          "secret",
+				switcher.EnvActiveModel: "test",
+			},
+		},
+		{
+			tool:     "gemini",
+			binary:   "gemini",
+			wantArgs: nil,
+			wantEnv:  map[string]string{"GEMINI_MODEL": model.ModelID},
+		},
+		{
+			tool:     "opencode",
+			binary:   "opencode",
+			args:     []string{"run", "hi"},
+			wantArgs: []string{"--model", "test/test-model-1", "run", "hi"},
+		},
+		{
+			tool:     "opencode",
+			binary:   "opencode",
+			args:     []string{"--model", "other/model"},
+			wantArgs: []string{"--model", "other/model"},
+		},
+		{
+			tool:     "codex",
+			binary:   "codex",
+			wantArgs: []string{"--model", model.ModelID},
+			wantEnv:  map[string]string{"OPENAI_BASE_URL": model.APIEndpoint},
+		},
+		{
+			tool:     "qwen",
+			binary:   "qwen",
+			wantEnv:  map[string]string{"OPENAI_MODEL": model.ModelID, "OPENAI_BASE_URL": model.APIEndpoint},
+			wantArgs: nil,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.tool+" "+strings.Join(tt.args, " "), func(t *testing.T) {
+			dir := t.TempDir()
+			if err := os.WriteFile(filepath.Join(dir, tt.binary), []byte(stub), 0755); err != nil {
+				t.Fatal(err)
+			}
+			out := filepath.Join(dir, "out")
+			t.Setenv("PATH", dir+string(os.PathListSeparator)+"/bin:/usr/bin")
+			t.Setenv("STUB_OUT", out)
+			t.Setenv("TEST_API_KEY", "secret")
+
+			cfg := &config.Config{
+				Tools:  map[string]config.Tool{tt.tool: {Name: tt.tool, Enabled: true}},
+				Models: map[string]config.Model{"test": model},
+			}
+			var stderr bytes.Buffer
+			r := &Runner{cfg: cfg, Stdin: strings.NewReader(""), Stdout: os.Stdout, Stderr: &stderr}
+
+			code, err := r.Run(tt.tool, "test", tt.args)
+			if err != nil {
+				t.Fatalf("Run: %v (stderr %q)", err, stderr.String())
+			}
+			if code != 3 {
+				t.Errorf("exit code = %d, want 3", code)
+			}
+
+			if got := readLines(t, out+".args"); !reflect.DeepEqual(got, tt.wantArgs) {
+				t.Errorf("args = %q, want %q", got, tt.wantArgs)
+			}
+
+			env := make(map[string]string)
+			for _, kv := range readLines(t, out+".env") {
+				k, v, _ := strings.Cut(kv, "=")
+				env[k] = v
+			}
+			for k, want := range tt.wantEnv {
+				if env[k] != want {
+					t.Errorf("%s = %q, want %q", k, env[k], want)
+				}
+			}
+			if tt.tool != "claude" && env["ANTHROPIC_AUTH_TOKEN"] != "" {
+				t.Errorf("ANTHROPIC_AUTH_TOKEN set for %s", tt.tool)
+			}
+		})
+	}
+}
+
+func TestRunUnknown(t *testing.T) {
+	cfg := &config.Config{
+		Tools:  map[string]config.Tool{"claude": {Name: "Claude Code"}},
+		Models: map[string]config.Model{},
+	}
+	r := NewRunner(cfg)
+
+	if _, err := r.Run("nope", "test", nil); err == nil {
+		t.Error("Run with an unknown tool succeeded")
+	}
+	if _, err := r.Run("claude", "nope", nil); err == nil {
+		t.Error("Run with an unknown model succeeded")
+	}
+}
+
+func TestMergeEnv(t *testing.T) {
+	got := mergeEnv([]string{"A=1", "B=2", "C=3"}, map[string]string{"B": "x", "D": "y"})
+	want := []string{"A=1", "C=3", "B=x", "D=y"}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("mergeEnv = %q, want %q", got, want)
+	}
+}
+
+func TestAPIKeyEnv(t *testing.T) {
+	tests := []struct {
+		model config.Model
+		want  string
+	}{
+		{config.Model{Provider: "zhipu"}, "ZHIPU_API_KEY"},
+		{config.Model{Provider: "open-router.ai"}, "OPEN_ROUTER_AI_API_KEY"},
+		{config.Model{Provider: "zhipu", APIKeyEnv: "GLM_KEY"}, "GLM_KEY"},
+		{config.Model{}, ""},
+	}
+	for _, tt := range tests {
+		if got := APIKeyEnv(tt.model); got != tt.want {
+			t.Errorf("APIKeyEnv(%+v) = %q, want %q", tt.model, got, tt.want)
+		}
+	}
+}
+
+func readLines(t *testing.T, path string) []string {
+	t.Helper()
+	data, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	text := strings.TrimSuffix(string(data), "\n")
+	if text == "" {
+		return nil
+	}
+	return strings.Split(text, "\n")
+}
@@ -0,0 +1,69 @@
+package runner
+
+import (
+	"bytes"
+	"fmt"
+	"os"
+	"os/exec"
+	"runtime"
+	"strings"
+	"time"
+)
+
+// secretService is the service name API keys are stored under in the
+// system keychain (macOS) or Secret Service (Linux)
+const secretService = "ai-manager"
+
+// secretTimeout bounds how long a keychain lookup may block
+const secretTimeout = 5 * time.Second
+
+// ResolveAPIKey returns the API key stored under name, checking the
+// environment first and then the system secret store
+func ResolveAPIKey(name string) (string, error) {
+	if v := os.Getenv(name); v != "" {
+		return v, nil
+	}
+	return lookupSecret(name)
+}
+
+// lookupSecret reads a secret from the platform's secret store. Store one with
+//
+//	security add-generic-password -s ai-manager -a ZHIPU_API_KEY -w     (macOS)
+//	secret-tool store --label=ai-manager service ai-manager account ZHIPU_API_KEY  (Linux)
+func lookupSecret(name string) (string, error) {
+	var args []string
+	switch runtime.GOOS {
+	case "darwin":
+		args = []string{"security", "find-generic-password", "-s", secretService, "-a", name, "-w"}
+	case "linux":
+		args = []string{"secret-tool", "lookup", "service", secretService, "account", name}
+	default:
+		return "", fmt.Errorf("no secret store on %s", runtime.GOOS)
+	}
+
+	if _, err := exec.LookPath(args[0]); err != nil {
+		return "", fmt.Errorf("%s not found", args[0])
+	}
+
+	cmd := exec.Command(args[0], args[1:]...)
+	var out bytes.Buffer
+	cmd.Stdout = &out
+	if err := cmd.Start(); err != nil {
+		return "", err
+	}
+
+	done := make(chan error, 1)
+	go func() { done <- cmd.Wait() }()
+
+	select {
+	case err := <-done:
+		if err != nil {
+			return "", fmt.Errorf("secret %s not found", name)
+		}
+	case <-time.After(secretTimeout):
+		cmd.Process.Kill()
+		return "", fmt.Errorf("secret store lookup for %s timed out", name)
+	}
+
+	return strings.TrimSpace(out.String()), nil
+}
@@ -0,0 +1,177 @@
+package safefile
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"syscall"
+	"time"
+)
+
+// LockName is the lock file created in the ai-mgr state directory
+const LockName = "ai-mgr.lock"
+
+// lockTimeout bounds how long to wait for another ai-mgr to finish; tests
+// shorten it
+var lockTimeout = 10 * time.Second
+
+// WriteFile atomically replaces path with data. The data is written to a
+// temporary file in the same directory, synced, and renamed over the
+// original, so readers see either the old or the new content but never a
+// truncated file. An existing file keeps its mode and ownership; perm is
+// used for new files. Symlinks are followed so the link itself survives.
+func WriteFile(path string, data []byte, perm os.FileMode) error {
+	path, err := followLinks(path)
+	if err != nil {
+		return err
+	}
+
+	dir := filepath.Dir(path)
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return err
+	}
+
+	existing, err := os.Stat(path)
+	if err != nil && !os.IsNotExist(err) {
+		return err
+	}
+
+	tmp, err := os.CreateTemp(dir, "."+filepath.Base(path)+".tmp-*")
+	if err != nil {
+		return err
+	}
+	tmpName := tmp.Name()
+	defer os.Remove(tmpName) // no-op after a successful rename
+
+	if _, err := tmp.Write(data); err != nil {
+		tmp.Close()
+		return err
+	}
+
+	mode := perm
+	if existing != nil {
+		mode = existing.Mode().Perm()
+	}
+	if err := tmp.Chmod(mode); err != nil {
+		tmp.Close()
+		return err
+	}
+	if existing != nil {
+		preserveOwner(tmp, existing)
+	}
+
+	if err := tmp.Sync(); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		return err
+	}
+
+	if err := os.Rename(tmpName, path); err != nil {
+		return err
+	}
+	return syncDir(dir)
+}
+
+// followLinks resolves the symlinks at path, including one whose target
+// doesn't exist yet, so the target is written rather than the link replaced
+func followLinks(path string) (string, error) {
+	for i := 0; i < 40; i++ {
+		info, err := os.Lstat(path)
+		if err != nil || info.Mode()&os.ModeSymlink == 0 {
+			return path, nil
+		}
+		target, err := os.Readlink(path)
+		if err != nil {
+			return "", err
+		}
+		if !filepath.IsAbs(target) {
+			target = filepath.Join(filepath.Dir(path), target)
+		}
+		path = target
+	}
+	return "", fmt.Errorf("%s: too many levels of symbolic links", path)
+}
+
+// preserveOwner gives the new file the owner of the one it replaces. This
+// only works as root or when the owner is unchanged, so failures are ignored.
+func preserveOwner(f *os.File, existing os.FileInfo) {
+	st, ok := existing.Sys().(*syscall.Stat_t)
+	if !ok {
+		return
+	}
+	f.Chown(int(st.Uid), int(st.Gid))
+}
+
+// syncDir flushes a directory so a rename inside it is durable
+func syncDir(dir string) error {
+	d, err := os.Open(dir)
+	if err != nil {
+		return err
+	}
+	defer d.Close()
+
+	// Some filesystems don't support syncing directories
+	if err := d.Sync(); err != nil && !errors.Is(err, syscall.EINVAL) {
+		return err
+	}
+	return nil
+}
+
+// Lock is an exclusive advisory lock held on a lock file
+type Lock struct {
+	f *os.File
+}
+
+// Acquire takes the ai-mgr lock in stateDir, waiting while another process
+// holds it
+func Acquire(stateDir string) (*Lock, error) {
+	if err := os.MkdirAll(stateDir, 0755); err != nil {
+		return nil, err
+	}
+
+	path := filepath.Join(stateDir, LockName)
+	f, err := os.OpenFile(path, os.O_RDWR|os.O_CREATE, 0644)
+	if err != nil {
+		return nil, err
+	}
+
+	deadline := time.Now().Add(lockTimeout)
+	for {
+		err := syscall.Flock(int(f.Fd()), syscall.LOCK_EX|syscall.LOCK_NB)
+		if err == nil {
+			return &Lock{f: f}, nil
+		}
+		if !errors.Is(err, syscall.EWOULDBLOCK) {
+			f.Close()
+			return nil, err
+		}
+		if time.Now().After(deadline) {
+			f.Close()
+			return nil, fmt.Errorf("timed out waiting for %s: another ai-mgr is running", path)
+		}
+		time.Sleep(50 * time.Millisecond)
+	}
+}
+
+// Release drops the lock
+func (l *Lock) Release() error {
+	if err := syscall.Flock(int(l.f.Fd()), syscall.LOCK_UN); err != nil {
+		l.f.Close()
+		return err
+	}
+	return l.f.Close()
+}
+
+// WithLock runs fn while holding the ai-mgr lock in stateDir
+func WithLock(stateDir string, fn func() error) error {
+	lock, err := Acquire(stateDir)
+	if err != nil {
+		return err
+	}
+	defer lock.Release()
+
+	return fn()
+}
@@ -0,0 +1,303 @@
+package safefile
+
+import (
+	"bufio"
+	"io"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"syscall"
+	"testing"
+	"time"
+)
+
+func readFile(t *testing.T, path string) string {
+	t.Helper()
+	data, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return string(data)
+}
+
+// tempFiles returns the names of WriteFile's temporary files left in dir
+func tempFiles(t *testing.T, dir string) []string {
+	t.Helper()
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var names []string
+	for _, e := range entries {
+		if strings.Contains(e.Name(), ".tmp-") {
+			names = append(names, e.Name())
+		}
+	}
+	return names
+}
+
+func TestWriteFileMode(t *testing.T) {
+	dir := t.TempDir()
+
+	// New files get perm, in directories created on the way
+	path := filepath.Join(dir, "sub", "new.json")
+	if err := WriteFile(path, []byte("one"), 0640); err != nil {
+		t.Fatal(err)
+	}
+	info, err := os.Stat(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if info.Mode().Perm() != 0640 || readFile(t, path) != "one" {
+		t.Errorf("new file mode %v, content %q", info.Mode().Perm(), readFile(t, path))
+	}
+
+	// Existing files keep theirs
+	if err := os.Chmod(path, 0600); err != nil {
+		t.Fatal(err)
+	}
+	if err := WriteFile(path, []byte("two"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if info, err = os.Stat(path); err != nil {
+		t.Fatal(err)
+	}
+	if info.Mode().Perm() != 0600 || readFile(t, path) != "two" {
+		t.Errorf("rewritten file mode %v, content %q", info.Mode().Perm(), readFile(t, path))
+	}
+	if left := tempFiles(t, filepath.Dir(path)); len(left) != 0 {
+		t.Errorf("temporary files left: %q", left)
+	}
+}
+
+func TestWriteFileOwner(t *testing.T) {
+	if os.Getuid() != 0 {
+		t.Skip("changing a file's owner needs root")
+	}
+	path := filepath.Join(t.TempDir(), "settings.json")
+	if err := os.WriteFile(path, []byte("{}"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.Chown(path, 1234, 5678); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := WriteFile(path, []byte(`{"a":1}`), 0644); err != nil {
+		t.Fatal(err)
+	}
+	info, err := os.Stat(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	st := info.Sys().(*syscall.Stat_t)
+	if st.Uid != 1234 || st.Gid != 5678 {
+		t.Errorf("owner %d:%d, want 1234:5678", st.Uid, st.Gid)
+	}
+}
+
+func TestWriteFileThroughSymlink(t *testing.T) {
+	dir := t.TempDir()
+	// Dotfiles managers link settings into a repository elsewhere
+	target := filepath.Join(dir, "dotfiles", "settings.json")
+	if err := os.MkdirAll(filepath.Dir(target), 0755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(target, []byte("old"), 0600); err != nil {
+		t.Fatal(err)
+	}
+	link := filepath.Join(dir, "home", "settings.json")
+	if err := os.MkdirAll(filepath.Dir(link), 0755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.Symlink("../dotfiles/settings.json", link); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := WriteFile(link, []byte("new"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if dest, err := os.Readlink(link); err != nil || dest != "../dotfiles/settings.json" {
+		t.Errorf("link now %q, %v", dest, err)
+	}
+	if got := readFile(t, target); got != "new" {
+		t.Errorf("target content %q, want new", got)
+	}
+	if info, err := os.Stat(target); err != nil || info.Mode().Perm() != 0600 {
+		t.Errorf("target mode %v, %v, want 0600", info.Mode().Perm(), err)
+	}
+	for _, d := range []string{filepath.Dir(link), filepath.Dir(target)} {
+		if left := tempFiles(t, d); len(left) != 0 {
+			t.Errorf("temporary files left in %s: %q", d, left)
+		}
+	}
+
+	// A dangling link is written through too, creating its target
+	if err := os.Remove(target); err != nil {
+		t.Fatal(err)
+	}
+	if err := WriteFile(link, []byte("again"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if info, err := os.Lstat(link); err != nil || info.Mode()&os.ModeSymlink == 0 {
+		t.Errorf("link replaced by a file: %v", err)
+	}
+	if got := readFile(t, target); got != "again" {
+		t.Errorf("target content %q, want again", got)
+	}
+}
+
+func TestWriteFileErrorCleansUp(t *testing.T) {
+	dir := t.TempDir()
+
+	// The rename over a non-empty directory fails once the data is written
+	path := filepath.Join(dir, "settings.json")
+	if err := os.MkdirAll(filepath.Join(path, "inside"), 0755); err != nil {
+		t.Fatal(err)
+	}
+	if err := WriteFile(path, []byte("data"), 0644); err == nil {
+		t.Fatal("WriteFile over a directory succeeded")
+	}
+	if left := tempFiles(t, dir); len(left) != 0 {
+		t.Errorf("temporary files left: %q", left)
+	}
+
+	// A parent that is a file
+	parent := filepath.Join(dir, "file")
+	if err := os.WriteFile(parent, nil, 0644); err != nil {
+		t.Fatal(err)
+	}
+	if err := WriteFile(filepath.Join(parent, "x.json"), []byte("data"), 0644); err == nil {
+		t.Error("WriteFile below a file succeeded")
+	}
+}
+
+func TestWithLockGoroutines(t *testing.T) {
+	dir := t.TempDir()
+	var active, overlaps, runs int32
+	var wg sync.WaitGroup
+	for i := 0; i < 8; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			err := WithLock(dir, func() error {
+				if atomic.AddInt32(&active, 1) > 1 {
+					atomic.AddInt32(&overlaps, 1)
+				}
+				time.Sleep(5 * time.Millisecond)
+				atomic.AddInt32(&active, -1)
+				atomic.AddInt32(&runs, 1)
+				return nil
+			})
+			if err != nil {
+				t.Error(err)
+			}
+		}()
+	}
+	wg.Wait()
+	if runs != 8 || overlaps != 0 {
+		t.Errorf("%d runs, %d while another held the lock, want 8 and 0", runs, overlaps)
+	}
+}
+
+func TestWithLockTimeout(t *testing.T) {
+	saved := lockTimeout
+	lockTimeout = 200 * time.Millisecond
+	t.Cleanup(func() { lockTimeout = saved })
+
+	dir := t.TempDir()
+	held := make(chan struct{})
+	release := make(chan struct{})
+	done := make(chan error)
+	go func() {
+		done <- WithLock(dir, func() error {
+			close(held)
+			<-release
+			return nil
+		})
+	}()
+	<-held
+
+	ran := false
+	err := WithLock(dir, func() error {
+		ran = true
+		return nil
+	})
+	if err == nil || !strings.Contains(err.Error(), "another ai-mgr is running") || ran {
+		t.Errorf("WithLock while held = %v, ran %v, want a timeout", err, ran)
+	}
+
+	close(release)
+	if err := <-done; err != nil {
+		t.Fatal(err)
+	}
+	if err := WithLock(dir, func() error { ran = true; return nil }); err != nil || !ran {
+		t.Errorf("WithLock after release = %v, ran %v", err, ran)
+	}
+}
+
+// TestHoldLock is run by TestWithLockProcesses in a child process: it holds
+// the lock in $SAFEFILE_LOCK_DIR until its stdin is closed
+func TestHoldLock(t *testing.T) {
+	dir := os.Getenv("SAFEFILE_LOCK_DIR")
+	if dir == "" {
+		t.Skip("only run by TestWithLockProcesses")
+	}
+	err := WithLock(dir, func() error {
+		os.Stdout.WriteString("locked\n")
+		_, err := bufio.NewReader(os.Stdin).ReadString('\n')
+		if err != nil && err != io.EOF {
+			return err
+		}
+		return nil
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestWithLockProcesses(t *testing.T) {
+	saved := lockTimeout
+	lockTimeout = 200 * time.Millisecond
+	t.Cleanup(func() { lockTimeout = saved })
+
+	dir := t.TempDir()
+	cmd := exec.Command(os.Args[0], "-test.run", "^TestHoldLock$")
+	cmd.Env = append(os.Environ(), "SAFEFILE_LOCK_DIR="+dir)
+	stdin, err := cmd.StdinPipe()
+	if err != nil {
+		t.Fatal(err)
+	}
+	stdout, err := cmd.StdoutPipe()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := cmd.Start(); err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { cmd.Process.Kill(); cmd.Wait() })
+
+	line, err := bufio.NewReader(stdout).ReadString('\n')
+	if err != nil || line != "locked\n" {
+		t.Fatalf("child said %q, %v", line, err)
+	}
+
+	if err := WithLock(dir, func() error { return nil }); err == nil || !strings.Contains(err.Error(), "timed out") {
+		t.Errorf("WithLock while the child holds the lock = %v, want a timeout", err)
+	}
+
+	// Once the child lets go, a waiting WithLock gets the lock
+	lockTimeout = 10 * time.Second
+	acquired := make(chan error)
+	go func() { acquired <- WithLock(dir, func() error { return nil }) }()
+	time.Sleep(100 * time.Millisecond)
+	stdin.Close()
+	if err := <-acquired; err != nil {
+		t.Errorf("WithLock after the child released = %v", err)
+	}
+	if err := cmd.Wait(); err != nil {
+		t.Errorf("child: %v", err)
+	}
+}
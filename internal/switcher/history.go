@@ -0,0 +1,193 @@
+package switcher
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"time"
+
+	"ai-manager/internal/safefile"
+)
+
+// historyFile is the switch journal, stored under Config.HomeDir
+const historyFile = "switch-history.json"
+
+// maxHistory is the number of switches kept in the journal
+const maxHistory = 50
+
+// History records the active model per tool and a journal of earlier switches
+type History struct {
+	Active  map[string]string `json:"active"`
+	Entries []HistoryEntry    `json:"entries"`
+}
+
+// HistoryEntry is a single switch together with the settings files as they
+// were before it was applied
+type HistoryEntry struct {
+	ID       int               `json:"id"`
+	Time     time.Time         `json:"time"`
+	Model    string            `json:"model"`
+	Previous map[string]string `json:"previous"`
+	Files    []FileSnapshot    `json:"files"`
+}
+
+// FileSnapshot holds the exact content of a settings file before a switch
+type FileSnapshot struct {
+	Tool    string      `json:"tool"`
+	Path    string      `json:"path"`
+	Existed bool        `json:"existed"`
+	Mode    os.FileMode `json:"mode"`
+	Content []byte      `json:"content"`
+}
+
+// HistoryPath returns the journal location in a state directory
+func HistoryPath(stateDir string) string {
+	return filepath.Join(stateDir, historyFile)
+}
+
+// LoadHistory reads the switch journal, returning an empty one if missing
+func LoadHistory(stateDir string) (*History, error) {
+	h := &History{Active: make(map[string]string)}
+
+	data, err := os.ReadFile(HistoryPath(stateDir))
+	if os.IsNotExist(err) {
+		return h, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	if err := json.Unmarshal(data, h); err != nil {
+		return nil, fmt.Errorf("failed to parse switch history: %w", err)
+	}
+	if h.Active == nil {
+		h.Active = make(map[string]string)
+	}
+	return h, nil
+}
+
+// Save writes the switch journal
+func (h *History) Save(stateDir string) error {
+	data, err := json.MarshalIndent(h, "", "  ")
+	if err != nil {
+		return err
+	}
+	return safefile.WriteFile(HistoryPath(stateDir), data, 0600)
+}
+
+// push appends an entry, dropping the oldest ones beyond maxHistory
+func (h *History) push(entry HistoryEntry) {
+	entry.ID = 1
+	if n := len(h.Entries); n > 0 {
+		entry.ID = h.Entries[n-1].ID + 1
+	}
+	h.Entries = append(h.Entries, entry)
+	if len(h.Entries) > maxHistory {
+		h.Entries = h.Entries[len(h.Entries)-maxHistory:]
+	}
+}
+
+// pop removes and returns the latest entry
+func (h *History) pop() (HistoryEntry, bool) {
+	n := len(h.Entries)
+	if n == 0 {
+		return HistoryEntry{}, false
+	}
+	entry := h.Entries[n-1]
+	h.Entries = h.Entries[:n-1]
+	return entry, true
+}
+
+// snapshotFile captures a settings file before it is modified
+func snapshotFile(tool, path string) (FileSnapshot, error) {
+	snap := FileSnapshot{Tool: tool, Path: path}
+
+	info, err := os.Stat(path)
+	if os.IsNotExist(err) {
+		return snap, nil
+	}
+	if err != nil {
+		return snap, err
+	}
+
+	content, err := os.ReadFile(path)
+	if err != nil {
+		return snap, err
+	}
+
+	snap.Existed = true
+	snap.Mode = info.Mode().Perm()
+	snap.Content = content
+	return snap, nil
+}
+
+// restore puts a settings file back exactly as it was captured
+func (f FileSnapshot) restore() error {
+	if !f.Existed {
+		if err := os.Remove(f.Path); err != nil && !os.IsNotExist(err) {
+			return err
+		}
+		return nil
+	}
+
+	if err := safefile.WriteFile(f.Path, f.Content, f.Mode); err != nil {
+		return err
+	}
+	// WriteFile keeps the mode of an existing file, so set it explicitly
+	return os.Chmod(f.Path, f.Mode)
+}
+
+// Undo reverts the most recent switch, restoring every settings file it
+// touched byte for byte
+func (s *Switcher) Undo() (*HistoryEntry, error) {
+	var entry *HistoryEntry
+	err := safefile.WithLock(s.cfg.StateDir(), func() error {
+		var err error
+		entry, err = s.undoLocked()
+		return err
+	})
+	return entry, err
+}
+
+// undoLocked performs an undo while the ai-mgr lock is held
+func (s *Switcher) undoLocked() (*HistoryEntry, error) {
+	history, err := LoadHistory(s.cfg.StateDir())
+	if err != nil {
+		return nil, err
+	}
+
+	entry, ok := history.pop()
+	if !ok {
+		return nil, fmt.Errorf("no switch to undo")
+	}
+
+	for _, f := range entry.Files {
+		if err := f.restore(); err != nil {
+			return nil, fmt.Errorf("failed to restore %s: %w", f.Path, err)
+		}
+	}
+
+	history.Active = entry.Previous
+	if history.Active == nil {
+		history.Active = make(map[string]string)
+	}
+
+	if err := history.Save(s.cfg.StateDir()); err != nil {
+		return nil, err
+	}
+	return &entry, nil
+}
+
+// History returns the switch journal
+func (s *Switcher) History() (*History, error) {
+	return LoadHistory(s.cfg.StateDir())
+}
+
+func copyActive(m map[string]string) map[string]string {
+	out := make(map[string]string, len(m))
+	for k, v := range m {
+		out[k] = v
+	}
+	return out
+}
@@ -0,0 +1,101 @@
+package switcher
+
+import (
+	"fmt"
+	"strings"
+
+	"ai-manager/internal/adapter"
+	"ai-manager/internal/config"
+)
+
+// EnvActiveModel records which model the current shell has exported, so the
+// next export knows which variables to unset
+const EnvActiveModel = "AI_MGR_MODEL"
+
+// Shells lists the supported shell syntaxes
+var Shells = []string{"bash", "zsh", "fish"}
+
+// ShellEnv renders the statements that move a shell from the previously
+// exported model to modelKey. An empty modelKey only unsets the previous
+// model's variables.
+func ShellEnv(cfg *config.Config, shell, previous, modelKey string) (string, error) {
+	if !IsShell(shell) {
+		return "", fmt.Errorf("unsupported shell: %s (supported: %s)", shell, strings.Join(Shells, ", "))
+	}
+
+	desired := map[string]string{}
+	if modelKey != "" {
+		model, ok := cfg.Models[modelKey]
+		if !ok {
+			return "", fmt.Errorf("unknown model: %s", modelKey)
+		}
+		desired = adapter.ModelEnv(model)
+		desired[EnvActiveModel] = modelKey
+	}
+
+	var b strings.Builder
+	for _, k := range previousEnvKeys(cfg, previous) {
+		if _, ok := desired[k]; !ok {
+			b.WriteString(UnsetStatement(shell, k))
+		}
+	}
+	for _, k := range sortedStrings(desired) {
+		b.WriteString(ExportStatement(shell, k, desired[k]))
+	}
+
+	return b.String(), nil
+}
+
+// previousEnvKeys returns the variables exported for the previous model.
+// If that model is no longer configured, every managed key is assumed.
+func previousEnvKeys(cfg *config.Config, previous string) []string {
+	if previous == "" {
+		return nil
+	}
+
+	model, ok := cfg.Models[previous]
+	if !ok {
+		return append(ManagedEnvKeys(cfg), EnvActiveModel)
+	}
+
+	keys := sortedStrings(adapter.ModelEnv(model))
+	return append(keys, EnvActiveModel)
+}
+
+// ExportStatement renders a variable export in the given shell's syntax
+func ExportStatement(shell, key, value string) string {
+	if shell == "fish" {
+		return fmt.Sprintf("set -gx %s %s;\n", key, fishQuote(value))
+	}
+	return fmt.Sprintf("export %s=%s;\n", key, posixQuote(value))
+}
+
+// UnsetStatement renders a variable removal in the given shell's syntax
+func UnsetStatement(shell, key string) string {
+	if shell == "fish" {
+		return fmt.Sprintf("set -e %s;\n", key)
+	}
+	return fmt.Sprintf("unset %s;\n", key)
+}
+
+// posixQuote single-quotes a value for bash and zsh
+func posixQuote(s string) string {
+	return "'" + strings.ReplaceAll(s, "'", `'\''`) + "'"
+}
+
+// fishQuote single-quotes a value for fish, which only escapes \ and '
+func fishQuote(s string) string {
+	s = strings.ReplaceAll(s, `\`, `\\`)
+	s = strings.ReplaceAll(s, `'`, `\'`)
+	return "'" + s + "'"
+}
+
+// IsShell reports whether shell is a supported shell syntax
+func IsShell(shell string) bool {
+	for _, s := range Shells {
+		if s == shell {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,147 @@
+package switcher
+
+import (
+	"errors"
+	"fmt"
+	"sort"
+	"time"
+
+	"ai-manager/internal/adapter"
+	"ai-manager/internal/config"
+	"ai-manager/internal/models"
+	"ai-manager/internal/safefile"
+)
+
+// Switcher applies model configurations to AI tool settings files
+type Switcher struct {
+	cfg *config.Config
+}
+
+// NewSwitcher creates a new model switcher
+func NewSwitcher(cfg *config.Config) *Switcher {
+	return &Switcher{cfg: cfg}
+}
+
+// Switch writes the given model into every enabled tool's settings
+func (s *Switcher) Switch(modelKey string) ([]models.SwitchResult, error) {
+	var results []models.SwitchResult
+	err := safefile.WithLock(s.cfg.StateDir(), func() error {
+		var err error
+		results, err = s.switchLocked(modelKey)
+		return err
+	})
+	return results, err
+}
+
+// switchLocked performs a switch while the ai-mgr lock is held
+func (s *Switcher) switchLocked(modelKey string) ([]models.SwitchResult, error) {
+	model, ok := s.cfg.Models[modelKey]
+	if !ok {
+		return nil, fmt.Errorf("unknown model: %s", modelKey)
+	}
+
+	history, err := LoadHistory(s.cfg.StateDir())
+	if err != nil {
+		return nil, err
+	}
+
+	entry := HistoryEntry{
+		Time:     time.Now(),
+		Model:    modelKey,
+		Previous: copyActive(history.Active),
+	}
+
+	managed := ManagedEnvKeys(s.cfg)
+	results := make([]models.SwitchResult, 0)
+
+	for _, key := range sortedToolKeys(s.cfg) {
+		tool := s.cfg.Tools[key]
+		if !tool.Enabled {
+			continue
+		}
+
+		a := adapter.For(key)
+		result := models.SwitchResult{
+			Tool:         tool.Name,
+			SettingsPath: a.SettingsPath(tool),
+			Model:        modelKey,
+		}
+
+		if !a.Detect(tool) {
+			result.Skipped = true
+			result.Reason = "not installed"
+			results = append(results, result)
+			continue
+		}
+
+		snap, err := snapshotFile(key, result.SettingsPath)
+		if err != nil {
+			result.Error = err.Error()
+			results = append(results, result)
+			continue
+		}
+
+		err = a.ApplyModel(tool, model, managed)
+		switch {
+		case errors.Is(err, adapter.ErrUnsupported):
+			result.Skipped = true
+			result.Reason = err.Error()
+		case err != nil:
+			entry.Files = append(entry.Files, snap)
+			result.Error = err.Error()
+		default:
+			entry.Files = append(entry.Files, snap)
+			history.Active[key] = modelKey
+		}
+		results = append(results, result)
+	}
+
+	if len(entry.Files) > 0 {
+		history.push(entry)
+		if err := history.Save(s.cfg.StateDir()); err != nil {
+			return results, fmt.Errorf("failed to record switch history: %w", err)
+		}
+	}
+
+	return results, nil
+}
+
+// ManagedEnvKeys returns every environment variable that any configured
+// model may set. Only these keys are ever removed from a tool's settings.
+func ManagedEnvKeys(cfg *config.Config) []string {
+	seen := map[string]bool{adapter.EnvBaseURL: true, adapter.EnvModel: true}
+	for _, model := range cfg.Models {
+		for k := range model.Environment {
+			seen[k] = true
+		}
+	}
+	return sortedKeys(seen)
+}
+
+// sortedToolKeys returns the configured tool keys in a stable order
+func sortedToolKeys(cfg *config.Config) []string {
+	keys := make([]string, 0, len(cfg.Tools))
+	for k := range cfg.Tools {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+func sortedKeys(m map[string]bool) []string {
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+func sortedStrings(m map[string]string) []string {
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
+}
@@ -0,0 +1,66 @@
+package utils
+
+import (
+	"path"
+	"strings"
+)
+
+// MatchGlob reports whether a slash-separated relative path matches
+// pattern. Pattern segments follow path.Match, and a "**" segment matches
+// any number of directories, including none.
+func MatchGlob(pattern, name string) bool {
+	return matchSegments(strings.Split(pattern, "/"), strings.Split(name, "/"))
+}
+
+func matchSegments(pattern, name []string) bool {
+	for len(pattern) > 0 {
+		if pattern[0] == "**" {
+			// Collapse repeated ** and try every split point
+			for len(pattern) > 0 && pattern[0] == "**" {
+				pattern = pattern[1:]
+			}
+			if len(pattern) == 0 {
+				return true
+			}
+			for i := range name {
+				if matchSegments(pattern, name[i:]) {
+					return true
+				}
+			}
+			return false
+		}
+
+		if len(name) == 0 {
+			return false
+		}
+		if ok, _ := path.Match(pattern[0], name[0]); !ok {
+			return false
+		}
+		pattern, name = pattern[1:], name[1:]
+	}
+	return len(name) == 0
+}
+
+// ValidGlob checks a pattern for MatchGlob
+func ValidGlob(pattern string) error {
+	for _, seg := range strings.Split(pattern, "/") {
+		if _, err := path.Match(seg, ""); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// GlobBase returns the leading directories of pattern that contain no
+// wildcards, e.g. "debug/logs" for "debug/logs/**/*.txt"
+func GlobBase(pattern string) string {
+	segs := strings.Split(pattern, "/")
+	base := make([]string, 0, len(segs))
+	for _, seg := range segs[:len(segs)-1] {
+		if strings.ContainsAny(seg, `*?[\`) {
+			break
+		}
+		base = append(base, seg)
+	}
+	return strings.Join(base, "/")
+}
@@ -0,0 +1,56 @@
+package utils
+
+import "testing"
+
+func TestMatchGlob(t *testing.T) {
+	tests := []struct {
+		pattern string
+		name    string
+		want    bool
+	}{
+		{"debug/*.txt", "debug/a.txt", true},
+		{"debug/*.txt", "debug/sub/a.txt", false},
+		{"*", "a", true},
+		{"*", "a/b", false},
+
+		// ** matches any number of directories, including none
+		{"**", "a", true},
+		{"**", "a/b/c", true},
+		{"debug/**", "debug/a.txt", true},
+		{"debug/**", "debug/x/y/a.txt", true},
+		{"debug/**", "other/a.txt", false},
+		{"**/*.log", "a.log", true},
+		{"**/*.log", "x/y/a.log", true},
+		{"**/*.log", "x/y/a.txt", false},
+		{"a/**/b", "a/b", true},
+		{"a/**/b", "a/x/y/b", true},
+		{"a/**/b", "a/x/y/c", false},
+		{"a/**/**/b", "a/x/b", true},
+		{"**/cache/**", "x/cache/y/z", true},
+		{"**/cache/**", "x/caches/y", false},
+
+		// Segments follow path.Match
+		{"shell-snapshots/snapshot-?.sh", "shell-snapshots/snapshot-1.sh", true},
+		{"logs/[ab].log", "logs/c.log", false},
+	}
+	for _, tt := range tests {
+		if got := MatchGlob(tt.pattern, tt.name); got != tt.want {
+			t.Errorf("MatchGlob(%q, %q) = %v, want %v", tt.pattern, tt.name, got, tt.want)
+		}
+	}
+}
+
+func TestGlobBase(t *testing.T) {
+	tests := map[string]string{
+		"debug/logs/**/*.txt": "debug/logs",
+		"debug/**":            "debug",
+		"**/*.log":            "",
+		"*.log":               "",
+		"a/b?/c":              "a",
+	}
+	for pattern, want := range tests {
+		if got := GlobBase(pattern); got != want {
+			t.Errorf("GlobBase(%q) = %q, want %q", pattern, got, want)
+		}
+	}
+}
@@ -0,0 +1,70 @@
+package utils
+
+import (
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// sizeUnits maps size suffixes to their multipliers. Both SI (KB) and
+// binary (KiB) units are accepted.
+var sizeUnits = []struct {
+	suffix string
+	bytes  int64
+}{
+	{"KIB", 1 << 10}, {"MIB", 1 << 20}, {"GIB", 1 << 30}, {"TIB", 1 << 40},
+	{"KB", 1000}, {"MB", 1000 * 1000}, {"GB", 1000 * 1000 * 1000}, {"TB", 1000 * 1000 * 1000 * 1000},
+	{"K", 1 << 10}, {"M", 1 << 20}, {"G", 1 << 30}, {"T", 1 << 40},
+	{"B", 1},
+}
+
+// ParseSize parses a size such as "500MB", "2GiB" or "1024" (bytes)
+func ParseSize(s string) (int64, error) {
+	str := strings.ToUpper(strings.TrimSpace(s))
+	mult := int64(1)
+	for _, u := range sizeUnits {
+		if rest, ok := strings.CutSuffix(str, u.suffix); ok {
+			str, mult = strings.TrimSpace(rest), u.bytes
+			break
+		}
+	}
+
+	n, err := strconv.ParseFloat(str, 64)
+	if err != nil || !finite(n) {
+		return 0, fmt.Errorf("invalid size %q (e.g. 500MB, 2GiB)", s)
+	}
+	return int64(n * float64(mult)), nil
+}
+
+// ParseAge parses an age such as "7d", "2w", "12h" or "30m". A plain
+// number is a number of days.
+func ParseAge(s string) (time.Duration, error) {
+	str := strings.TrimSpace(s)
+	if n, err := strconv.Atoi(str); err == nil && n >= 0 {
+		return time.Duration(n) * 24 * time.Hour, nil
+	}
+
+	for suffix, unit := range map[string]time.Duration{"d": 24 * time.Hour, "w": 7 * 24 * time.Hour} {
+		if rest, ok := strings.CutSuffix(str, suffix); ok {
+			n, err := strconv.ParseFloat(rest, 64)
+			if err != nil || !finite(n) {
+				return 0, fmt.Errorf("invalid age %q (e.g. 7d, 12h)", s)
+			}
+			return time.Duration(n * float64(unit)), nil
+		}
+	}
+
+	d, err := time.ParseDuration(str)
+	if err != nil || d < 0 {
+		return 0, fmt.Errorf("invalid age %q (e.g. 7d, 12h)", s)
+	}
+	return d, nil
+}
+
+// finite reports whether n is a usable, non-negative amount. ParseFloat
+// also accepts "NaN" and "Inf".
+func finite(n float64) bool {
+	return n >= 0 && !math.IsInf(n, 0)
+}
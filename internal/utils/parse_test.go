@@ -0,0 +1,76 @@
+package utils
+
+import (
+	"testing"
+	"time"
+)
+
+func TestParseSize(t *testing.T) {
+	tests := []struct {
+		in      string
+		want    int64
+		wantErr bool
+	}{
+		{in: "0", want: 0},
+		{in: "1024", want: 1024},
+		{in: "500MB", want: 500 * 1000 * 1000},
+		{in: "2GiB", want: 2 << 30},
+		{in: "1.5GiB", want: 3 << 29},
+		{in: "1.5 gib", want: 3 << 29},
+		{in: "10k", want: 10 << 10},
+		{in: "7B", want: 7},
+		{in: "", wantErr: true},
+		{in: "B", wantErr: true},
+		{in: "-1", wantErr: true},
+		{in: "-1MB", wantErr: true},
+		{in: "12 parsecs", wantErr: true},
+		{in: "NaN", wantErr: true},
+		{in: "InfGB", wantErr: true},
+	}
+	for _, tt := range tests {
+		got, err := ParseSize(tt.in)
+		if (err != nil) != tt.wantErr {
+			t.Errorf("ParseSize(%q) error = %v, wantErr %v", tt.in, err, tt.wantErr)
+			continue
+		}
+		if got != tt.want {
+			t.Errorf("ParseSize(%q) = %d, want %d", tt.in, got, tt.want)
+		}
+	}
+}
+
+func TestParseAge(t *testing.T) {
+	day := 24 * time.Hour
+	tests := []struct {
+		in      string
+		want    time.Duration
+		wantErr bool
+	}{
+		{in: "0", want: 0},
+		{in: "7", want: 7 * day},
+		{in: "7d", want: 7 * day},
+		{in: "1.5d", want: 36 * time.Hour},
+		{in: "2w", want: 14 * day},
+		{in: "12h", want: 12 * time.Hour},
+		{in: "30m", want: 30 * time.Minute},
+		{in: " 3d ", want: 3 * day},
+		{in: "", wantErr: true},
+		{in: "-1", wantErr: true},
+		{in: "-1d", wantErr: true},
+		{in: "-12h", wantErr: true},
+		{in: "d", wantErr: true},
+		{in: "NaNd", wantErr: true},
+		{in: "Infw", wantErr: true},
+		{in: "soon", wantErr: true},
+	}
+	for _, tt := range tests {
+		got, err := ParseAge(tt.in)
+		if (err != nil) != tt.wantErr {
+			t.Errorf("ParseAge(%q) error = %v, wantErr %v", tt.in, err, tt.wantErr)
+			continue
+		}
+		if got != tt.want {
+			t.Errorf("ParseAge(%q) = %v, want %v", tt.in, got, tt.want)
+		}
+	}
+}
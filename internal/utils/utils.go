@@ -70,23 +70,6 @@ func CheckSymlink(path string) (bool, string, error) {
 	return err == nil, target, nil
 }
 
-// FileSize returns the size of a file or directory
-func FileSize(path string) (int64, error) {
-	var size int64
-
-	err := filepath.Walk(path, func(path string, info os.FileInfo, err error) error {
-		if err != nil {
-			return nil
-		}
-		if !info.IsDir() {
-			size += info.Size()
-		}
-		return nil
-	})
-
-	return size, err
-}
-
 // FormatSize formats bytes to human readable format
 func FormatSize(bytes int64) string {
 	const unit = 1024
@@ -103,23 +86,6 @@ func FormatSize(bytes int64) string {
 	return fmt.Sprintf("%.1f %cB", float64(bytes)/float64(div), "KMGTPE"[exp])
 }
 
-// CountFiles counts the number of files in a directory
-func CountFiles(path string) (int, error) {
-	count := 0
-
-	err := filepath.Walk(path, func(path string, info os.FileInfo, err error) error {
-		if err != nil {
-			return nil
-		}
-		if !info.IsDir() {
-			count++
-		}
-		return nil
-	})
-
-	return count, err
-}
-
 // IsDirEmpty checks if a directory is empty
 func IsDirEmpty(path string) (bool, error) {
 	entries, err := os.ReadDir(path)
@@ -0,0 +1,299 @@
+package walker
+
+import (
+	"bytes"
+	"context"
+	"encoding/gob"
+	"os"
+	"path/filepath"
+	"slices"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"ai-manager/internal/safefile"
+)
+
+// IndexFileName is the file in the state directory that holds the disk
+// usage index
+const IndexFileName = "usage-index.gob"
+
+// indexVersion is bumped when the index format changes; an index written
+// in another format is discarded
+const indexVersion = 2
+
+// hotAge is how recently a directory's newest file must have changed for
+// the directory to be read again even though it didn't change itself.
+// Appending to a file doesn't change its directory's modification time,
+// and the files being written to are the recent ones, like the
+// transcripts of active sessions.
+const hotAge = 7 * 24 * time.Hour
+
+// indexLargest is how many of its largest files the index keeps per
+// directory, unless a walk asks for more
+const indexLargest = 10
+
+// day is the resolution of the file ages the index keeps
+const day = 24 * time.Hour
+
+// Index caches the totals of each directory with the directory's
+// modification time, so a walk only reads directories that changed since
+// the last one. Its size grows with the number of directories, not files.
+// A file added, removed or renamed changes its directory's modification
+// time; a file that is only rewritten in place is picked up while its
+// directory has recent files, or with Reset.
+type Index struct {
+	path string
+
+	mu      sync.Mutex
+	dirs    map[string]*indexDir
+	changed bool
+}
+
+// indexDir is the totals of a directory's files at the time it was read
+type indexDir struct {
+	ModTime int64
+	Newest  int64
+	Dirs    []string
+	Files   int
+	Days    []indexDay
+	Largest []indexFile
+}
+
+// indexDay counts the files of a directory last modified on one day
+type indexDay struct {
+	Day   int64
+	Files int
+	Size  int64
+}
+
+// indexFile is one of the largest files of an indexed directory
+type indexFile struct {
+	Name    string
+	Size    int64
+	ModTime int64
+}
+
+// indexData is the file format of the index
+type indexData struct {
+	Version int
+	Dirs    map[string]*indexDir
+}
+
+// OpenIndex loads the index stored at path. A missing, damaged or outdated
+// index file gives an empty index.
+func OpenIndex(path string) *Index {
+	x := &Index{path: path, dirs: make(map[string]*indexDir)}
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return x
+	}
+	var stored indexData
+	if err := gob.NewDecoder(bytes.NewReader(data)).Decode(&stored); err != nil {
+		return x
+	}
+	if stored.Version == indexVersion && stored.Dirs != nil {
+		x.dirs = stored.Dirs
+	}
+	return x
+}
+
+// Reset drops every cached directory, so the next walks read them all
+func (x *Index) Reset() {
+	x.mu.Lock()
+	defer x.mu.Unlock()
+	x.dirs = make(map[string]*indexDir)
+	x.changed = true
+}
+
+// Save writes the index back to its file if a walk changed it
+func (x *Index) Save() error {
+	x.mu.Lock()
+	defer x.mu.Unlock()
+	if !x.changed {
+		return nil
+	}
+
+	var buf bytes.Buffer
+	if err := gob.NewEncoder(&buf).Encode(indexData{Version: indexVersion, Dirs: x.dirs}); err != nil {
+		return err
+	}
+	if err := safefile.WriteFile(x.path, buf.Bytes(), 0600); err != nil {
+		return err
+	}
+	x.changed = false
+	return nil
+}
+
+// Usage returns the same summary as the package-level Usage, reading only
+// the directories below root that changed since the index last saw them
+func (x *Index) Usage(ctx context.Context, root string, opts Options) (*Summary, error) {
+	s := newSummary(root)
+	now := time.Now()
+
+	info, err := os.Lstat(root)
+	if os.IsNotExist(err) {
+		x.forget(root, nil)
+		return s, nil
+	}
+	if err != nil {
+		return s, err
+	}
+	if !info.IsDir() {
+		s.add(File{Path: root, Size: info.Size(), ModTime: info.ModTime()}, now, opts.Largest)
+		return s, nil
+	}
+
+	largest := max(opts.Largest, indexLargest)
+	var mu sync.Mutex
+	visited := make(map[string]bool)
+
+	err = run(ctx, root, opts.workers(), func(ctx context.Context, dir string) ([]string, error) {
+		d, files := x.readDir(dir, now, largest)
+		if d == nil {
+			return nil, nil // Skip unreadable directories
+		}
+
+		mu.Lock()
+		defer mu.Unlock()
+		visited[dir] = true
+		s.Dirs += len(d.Dirs)
+		if files != nil {
+			for _, f := range files {
+				s.add(f, now, opts.Largest)
+			}
+		} else {
+			s.addDir(dir, d, now, opts.Largest)
+		}
+
+		subdirs := make([]string, len(d.Dirs))
+		for i, name := range d.Dirs {
+			subdirs[i] = filepath.Join(dir, name)
+		}
+		return subdirs, nil
+	})
+
+	// Directories removed since the last walk are only known after a full one
+	if err == nil {
+		x.forget(root, visited)
+	}
+
+	s.sortLargest()
+	return s, err
+}
+
+// readDir returns the totals of dir. When dir had to be read, because it
+// changed, has recent files or too few largest files are kept, its files
+// are returned as well; otherwise they are nil and the totals come from
+// the index.
+func (x *Index) readDir(dir string, now time.Time, largest int) (*indexDir, []File) {
+	// Stat before reading, so a change made while reading is seen next time
+	info, err := os.Lstat(dir)
+	if err != nil {
+		return nil, nil
+	}
+	mtime := info.ModTime().UnixNano()
+
+	x.mu.Lock()
+	cached := x.dirs[dir]
+	x.mu.Unlock()
+
+	if cached != nil && cached.ModTime == mtime &&
+		now.Sub(time.Unix(0, cached.Newest)) > hotAge &&
+		(len(cached.Largest) >= largest || len(cached.Largest) == cached.Files) {
+		return cached, nil
+	}
+
+	f, err := os.Open(dir)
+	if err != nil {
+		return nil, nil
+	}
+	entries, err := f.ReadDir(-1)
+	f.Close()
+	if err != nil && len(entries) == 0 {
+		return nil, nil
+	}
+
+	d := &indexDir{ModTime: mtime}
+	files := make([]File, 0, len(entries))
+	for _, e := range entries {
+		if e.IsDir() {
+			d.Dirs = append(d.Dirs, e.Name())
+			continue
+		}
+		info, err := e.Info()
+		if err != nil {
+			continue // Removed while reading
+		}
+		files = append(files, File{Path: filepath.Join(dir, e.Name()), Size: info.Size(), ModTime: info.ModTime()})
+		d.count(e.Name(), info.Size(), info.ModTime().UnixNano(), largest)
+	}
+
+	x.store(dir, d)
+	return d, files
+}
+
+// count adds a file to the directory's totals
+func (d *indexDir) count(name string, size, mtime int64, largest int) {
+	d.Files++
+	d.Newest = max(d.Newest, mtime)
+
+	n := time.Unix(0, mtime).Unix() / int64(day/time.Second)
+	i := sort.Search(len(d.Days), func(i int) bool { return d.Days[i].Day >= n })
+	if i == len(d.Days) || d.Days[i].Day != n {
+		d.Days = slices.Insert(d.Days, i, indexDay{Day: n})
+	}
+	d.Days[i].Files++
+	d.Days[i].Size += size
+
+	f := indexFile{Name: name, Size: size, ModTime: mtime}
+	if len(d.Largest) < largest {
+		d.Largest = append(d.Largest, f)
+		return
+	}
+	min := 0
+	for i := range d.Largest {
+		if d.Largest[i].Size < d.Largest[min].Size {
+			min = i
+		}
+	}
+	if size > d.Largest[min].Size {
+		d.Largest[min] = f
+	}
+}
+
+// addDir adds the totals of an indexed directory to a summary. Its files
+// are all older than hotAge, so counting their ages to the day is close
+// enough for the histogram.
+func (s *Summary) addDir(dir string, d *indexDir, now time.Time, largest int) {
+	for _, n := range d.Days {
+		mid := time.Unix(n.Day*int64(day/time.Second), 0).Add(day / 2)
+		s.count(n.Files, n.Size, now.Sub(mid))
+	}
+	for _, f := range d.Largest {
+		s.keep(File{Path: filepath.Join(dir, f.Name), Size: f.Size, ModTime: time.Unix(0, f.ModTime)}, largest)
+	}
+}
+
+func (x *Index) store(dir string, d *indexDir) {
+	x.mu.Lock()
+	defer x.mu.Unlock()
+	x.dirs[dir] = d
+	x.changed = true
+}
+
+// forget drops the directories at and below root that a walk didn't visit
+func (x *Index) forget(root string, visited map[string]bool) {
+	x.mu.Lock()
+	defer x.mu.Unlock()
+
+	prefix := root + string(filepath.Separator)
+	for dir := range x.dirs {
+		if (dir == root || strings.HasPrefix(dir, prefix)) && !visited[dir] {
+			delete(x.dirs, dir)
+			x.changed = true
+		}
+	}
+}
@@ -0,0 +1,168 @@
+package walker
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+	"time"
+)
+
+// age sets the modification time of path and of its directory to d ago
+func age(t *testing.T, path string, d time.Duration) {
+	t.Helper()
+	mtime := time.Now().Add(-d)
+	for _, p := range []string{path, filepath.Dir(path)} {
+		if err := os.Chtimes(p, mtime, mtime); err != nil {
+			t.Fatal(err)
+		}
+	}
+}
+
+func writeFile(t *testing.T, path string, size int, old time.Duration) {
+	t.Helper()
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(path, make([]byte, size), 0644); err != nil {
+		t.Fatal(err)
+	}
+	age(t, path, old)
+}
+
+func TestIndexMatchesUsage(t *testing.T) {
+	root := t.TempDir()
+	// Ages are days away from the bounds of the age buckets, since the
+	// index counts them to the day
+	for i := 0; i < 30; i++ {
+		writeFile(t, filepath.Join(root, "old", fmt.Sprintf("%02d.log", i)), 100*(i+1), time.Duration(12+i*5)*day)
+	}
+	writeFile(t, filepath.Join(root, "new", "session.jsonl"), 500, time.Hour)
+	writeFile(t, filepath.Join(root, "top.txt"), 7, 40*day)
+
+	opts := Options{Largest: 5}
+	want, err := Usage(context.Background(), root, opts)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	path := filepath.Join(t.TempDir(), IndexFileName)
+	x := OpenIndex(path)
+	for run := 0; run < 2; run++ {
+		got, err := x.Usage(context.Background(), root, opts)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !reflect.DeepEqual(got, want) {
+			t.Errorf("run %d: index usage = %+v, want %+v", run, got, want)
+		}
+		if err := x.Save(); err != nil {
+			t.Fatal(err)
+		}
+		x = OpenIndex(path)
+	}
+
+	// The index holds totals, not every file
+	d := x.dirs[filepath.Join(root, "old")]
+	if d == nil {
+		t.Fatal("old directory is not indexed")
+	}
+	if d.Files != 30 || len(d.Largest) != indexLargest {
+		t.Errorf("indexed %d files with %d largest, want 30 with %d", d.Files, len(d.Largest), indexLargest)
+	}
+	if len(d.Days) != 30 {
+		t.Errorf("indexed %d days, want 30", len(d.Days))
+	}
+}
+
+func TestIndexReadsChangedDirs(t *testing.T) {
+	root := t.TempDir()
+	old := filepath.Join(root, "old", "a.log")
+	hot := filepath.Join(root, "hot", "session.jsonl")
+	writeFile(t, old, 100, 30*day)
+	writeFile(t, hot, 100, time.Hour)
+
+	x := OpenIndex(filepath.Join(t.TempDir(), IndexFileName))
+	usage := func() int64 {
+		t.Helper()
+		s, err := x.Usage(context.Background(), root, Options{})
+		if err != nil {
+			t.Fatal(err)
+		}
+		return s.Size
+	}
+	if got := usage(); got != 200 {
+		t.Fatalf("size = %d, want 200", got)
+	}
+
+	// Appending to a recent file doesn't change its directory, but the
+	// directory is read again while it has recent files
+	if err := os.WriteFile(hot, make([]byte, 150), 0644); err != nil {
+		t.Fatal(err)
+	}
+	age(t, hot, time.Hour)
+	if got := usage(); got != 250 {
+		t.Errorf("size after appending to a recent file = %d, want 250", got)
+	}
+
+	// An old file rewritten in place is only seen after a reset
+	info, err := os.Stat(old)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(old, make([]byte, 300), 0644); err != nil {
+		t.Fatal(err)
+	}
+	for _, p := range []string{old, filepath.Dir(old)} {
+		if err := os.Chtimes(p, info.ModTime(), info.ModTime()); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if got := usage(); got != 250 {
+		t.Errorf("size after rewriting an old file = %d, want the cached 250", got)
+	}
+	x.Reset()
+	if got := usage(); got != 450 {
+		t.Errorf("size after reset = %d, want 450", got)
+	}
+
+	// Adding a file changes its directory
+	writeFile(t, filepath.Join(root, "old", "b.log"), 50, 0)
+	if got := usage(); got != 500 {
+		t.Errorf("size after adding a file = %d, want 500", got)
+	}
+
+	// Removed directories are dropped from the index
+	if err := os.RemoveAll(filepath.Join(root, "hot")); err != nil {
+		t.Fatal(err)
+	}
+	if got := usage(); got != 350 {
+		t.Errorf("size after removing a directory = %d, want 350", got)
+	}
+	if _, ok := x.dirs[filepath.Join(root, "hot")]; ok {
+		t.Error("removed directory is still indexed")
+	}
+}
+
+func TestIndexMoreLargest(t *testing.T) {
+	root := t.TempDir()
+	for i := 0; i < 2*indexLargest; i++ {
+		writeFile(t, filepath.Join(root, fmt.Sprintf("%02d", i)), i+1, 30*day)
+	}
+
+	x := OpenIndex(filepath.Join(t.TempDir(), IndexFileName))
+	if _, err := x.Usage(context.Background(), root, Options{}); err != nil {
+		t.Fatal(err)
+	}
+
+	// More largest files than the index keeps reads the directory again
+	s, err := x.Usage(context.Background(), root, Options{Largest: indexLargest + 5})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(s.Largest) != indexLargest+5 || s.Largest[len(s.Largest)-1].Size != indexLargest-4 {
+		t.Errorf("largest = %+v, want the %d largest", s.Largest, indexLargest+5)
+	}
+}
@@ -0,0 +1,133 @@
+package walker
+
+import (
+	"context"
+	"io/fs"
+	"os"
+	"sort"
+	"sync"
+	"time"
+)
+
+// File is a file found by a walk
+type File struct {
+	Path    string    `json:"path"`
+	Size    int64     `json:"size"`
+	ModTime time.Time `json:"mod_time"`
+}
+
+// AgeBucket counts the files last modified within an age range
+type AgeBucket struct {
+	Label  string        `json:"label"`
+	MaxAge time.Duration `json:"max_age"`
+	Files  int           `json:"files"`
+	Size   int64         `json:"size"`
+}
+
+// ageBuckets are the ranges of the age histogram; the last one is open
+var ageBuckets = []AgeBucket{
+	{Label: "< 1 day", MaxAge: 24 * time.Hour},
+	{Label: "1-7 days", MaxAge: 7 * 24 * time.Hour},
+	{Label: "7-30 days", MaxAge: 30 * 24 * time.Hour},
+	{Label: "30-90 days", MaxAge: 90 * 24 * time.Hour},
+	{Label: "> 90 days"},
+}
+
+// Summary describes the files below a directory
+type Summary struct {
+	Path    string      `json:"path"`
+	Size    int64       `json:"size"`
+	Files   int         `json:"files"`
+	Dirs    int         `json:"dirs"`
+	Ages    []AgeBucket `json:"ages"`
+	Largest []File      `json:"largest,omitempty"`
+}
+
+// Usage walks root once and returns its total size, file and directory
+// counts, an age histogram and the opts.Largest largest files. A missing
+// root has no usage.
+func Usage(ctx context.Context, root string, opts Options) (*Summary, error) {
+	s := newSummary(root)
+	now := time.Now()
+	var mu sync.Mutex
+
+	err := Walk(ctx, root, opts, func(path string, d fs.DirEntry) error {
+		if d.IsDir() {
+			mu.Lock()
+			s.Dirs++
+			mu.Unlock()
+			return nil
+		}
+
+		info, err := d.Info()
+		if err != nil {
+			return nil // Removed while walking
+		}
+
+		mu.Lock()
+		defer mu.Unlock()
+		s.add(File{Path: path, Size: info.Size(), ModTime: info.ModTime()}, now, opts.Largest)
+		return nil
+	})
+	if os.IsNotExist(err) {
+		return s, nil
+	}
+
+	s.sortLargest()
+	return s, err
+}
+
+// newSummary returns an empty summary of root
+func newSummary(root string) *Summary {
+	s := &Summary{Path: root, Ages: make([]AgeBucket, len(ageBuckets))}
+	copy(s.Ages, ageBuckets)
+	return s
+}
+
+// sortLargest orders the largest files by size, largest first
+func (s *Summary) sortLargest() {
+	sort.Slice(s.Largest, func(i, j int) bool {
+		return s.Largest[i].Size > s.Largest[j].Size
+	})
+}
+
+// add counts one file
+func (s *Summary) add(f File, now time.Time, largest int) {
+	s.count(1, f.Size, now.Sub(f.ModTime))
+	s.keep(f, largest)
+}
+
+// count adds files of the given total size and age
+func (s *Summary) count(files int, size int64, age time.Duration) {
+	s.Size += size
+	s.Files += files
+	for i := range s.Ages {
+		if s.Ages[i].MaxAge == 0 || age < s.Ages[i].MaxAge {
+			s.Ages[i].Files += files
+			s.Ages[i].Size += size
+			break
+		}
+	}
+}
+
+// keep offers a file to the largest files
+func (s *Summary) keep(f File, largest int) {
+	if largest <= 0 {
+		return
+	}
+	if len(s.Largest) < largest {
+		s.Largest = append(s.Largest, f)
+		return
+	}
+
+	// Replace the smallest kept file; largest is small, so a scan is fine
+	min := 0
+	for i := range s.Largest {
+		if s.Largest[i].Size < s.Largest[min].Size {
+			min = i
+		}
+	}
+	if f.Size > s.Largest[min].Size {
+		s.Largest[min] = f
+	}
+}
@@ -0,0 +1,190 @@
+package walker
+
+import (
+	"context"
+	"errors"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"runtime"
+	"sync"
+)
+
+// maxWorkers bounds the default number of directories read at once
+const maxWorkers = 16
+
+// Options control a walk
+type Options struct {
+	// Workers is the number of directories read concurrently. Zero means
+	// twice the number of CPUs, at most 16.
+	Workers int
+
+	// Largest is the number of largest files Usage keeps
+	Largest int
+}
+
+func (o Options) workers() int {
+	if o.Workers > 0 {
+		return o.Workers
+	}
+	n := 2 * runtime.GOMAXPROCS(0)
+	if n > maxWorkers {
+		n = maxWorkers
+	}
+	return n
+}
+
+// WalkFunc is called for every entry below root. It is called from several
+// goroutines at once, in no particular order. Returning filepath.SkipDir
+// for a directory skips it, and for a file skips the rest of its directory;
+// filepath.SkipAll ends the walk; any other error stops the walk and is
+// returned by Walk.
+type WalkFunc func(path string, d fs.DirEntry) error
+
+// Walk calls fn for every file and directory below root, reading
+// directories with a bounded pool of workers. Like filepath.WalkDir it uses
+// the directory entries' types and only stats a file when fn asks for its
+// Info. Symbolic links are reported but not followed, and unreadable
+// directories are skipped. The walk stops early when ctx is cancelled, and
+// then returns ctx.Err(). If root is not a directory, fn is called for root
+// itself.
+func Walk(ctx context.Context, root string, opts Options, fn WalkFunc) error {
+	info, err := os.Lstat(root)
+	if err != nil {
+		return err
+	}
+	if !info.IsDir() {
+		err := fn(root, fs.FileInfoToDirEntry(info))
+		if errors.Is(err, filepath.SkipDir) || errors.Is(err, filepath.SkipAll) {
+			return nil
+		}
+		return err
+	}
+
+	w := &walk{fn: fn}
+	return run(ctx, root, opts.workers(), w.readDir)
+}
+
+// visitFunc handles one directory and returns the subdirectories to visit
+type visitFunc func(ctx context.Context, dir string) ([]string, error)
+
+// run visits root and every directory visit returns, with workers
+// directories handled at once
+func run(ctx context.Context, root string, workers int, visit visitFunc) error {
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	p := &pool{ctx: ctx, visit: visit, queue: []string{root}}
+	p.cond = sync.NewCond(&p.mu)
+
+	// Wake idle workers when the walk is cancelled
+	go func() {
+		<-ctx.Done()
+		p.mu.Lock()
+		p.cond.Broadcast()
+		p.mu.Unlock()
+	}()
+
+	var wg sync.WaitGroup
+	for i := 0; i < workers; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			p.work()
+		}()
+	}
+	wg.Wait()
+
+	if p.err != nil {
+		if errors.Is(p.err, filepath.SkipAll) {
+			return nil
+		}
+		return p.err
+	}
+	return ctx.Err()
+}
+
+// pool is the state shared by the workers of one walk
+type pool struct {
+	ctx   context.Context
+	visit visitFunc
+
+	mu     sync.Mutex
+	cond   *sync.Cond
+	queue  []string
+	active int
+	err    error
+}
+
+// work visits queued directories until none are left or the walk stops
+func (p *pool) work() {
+	for {
+		p.mu.Lock()
+		for len(p.queue) == 0 && p.active > 0 && p.err == nil && p.ctx.Err() == nil {
+			p.cond.Wait()
+		}
+		if len(p.queue) == 0 || p.err != nil || p.ctx.Err() != nil {
+			p.cond.Broadcast()
+			p.mu.Unlock()
+			return
+		}
+
+		// Depth first keeps the queue short on wide trees
+		dir := p.queue[len(p.queue)-1]
+		p.queue = p.queue[:len(p.queue)-1]
+		p.active++
+		p.mu.Unlock()
+
+		subdirs, err := p.visit(p.ctx, dir)
+
+		p.mu.Lock()
+		p.active--
+		if err != nil && p.err == nil {
+			p.err = err
+		}
+		p.queue = append(p.queue, subdirs...)
+		p.cond.Broadcast()
+		p.mu.Unlock()
+	}
+}
+
+// walk calls a WalkFunc for the entries of each directory
+type walk struct {
+	fn WalkFunc
+}
+
+// readDir calls fn for the entries of dir and returns its subdirectories
+func (w *walk) readDir(ctx context.Context, dir string) ([]string, error) {
+	f, err := os.Open(dir)
+	if err != nil {
+		return nil, nil // Skip unreadable directories
+	}
+	entries, err := f.ReadDir(-1)
+	f.Close()
+	if err != nil && len(entries) == 0 {
+		return nil, nil
+	}
+
+	var subdirs []string
+	for i, e := range entries {
+		if i%256 == 0 && ctx.Err() != nil {
+			return nil, nil
+		}
+
+		path := filepath.Join(dir, e.Name())
+		err := w.fn(path, e)
+		switch {
+		case err == nil:
+			if e.IsDir() {
+				subdirs = append(subdirs, path)
+			}
+		case errors.Is(err, filepath.SkipDir):
+			if !e.IsDir() {
+				return subdirs, nil
+			}
+		default:
+			return nil, err
+		}
+	}
+	return subdirs, nil
+}
@@ -0,0 +1,215 @@
+package walker
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"reflect"
+	"runtime"
+	"sort"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+// makeTree creates a tree width directories wide and depth deep, with
+// files files in every directory, and returns its root
+func makeTree(tb testing.TB, depth, width, files int) string {
+	tb.Helper()
+	root := tb.TempDir()
+	var fill func(dir string, level int)
+	fill = func(dir string, level int) {
+		for i := 0; i < files; i++ {
+			if err := os.WriteFile(filepath.Join(dir, fmt.Sprintf("f%d.txt", i)), []byte("data"), 0644); err != nil {
+				tb.Fatal(err)
+			}
+		}
+		if level == depth {
+			return
+		}
+		for i := 0; i < width; i++ {
+			sub := filepath.Join(dir, fmt.Sprintf("d%d", i))
+			if err := os.Mkdir(sub, 0755); err != nil {
+				tb.Fatal(err)
+			}
+			fill(sub, level+1)
+		}
+	}
+	fill(root, 0)
+	return root
+}
+
+// serialWalk lists the tree below root with filepath.WalkDir
+func serialWalk(root string) ([]string, error) {
+	var paths []string
+	err := filepath.WalkDir(root, func(path string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return nil
+		}
+		if path != root {
+			paths = append(paths, path)
+		}
+		return nil
+	})
+	return paths, err
+}
+
+func TestWalk(t *testing.T) {
+	root := makeTree(t, 3, 3, 4)
+
+	var mu sync.Mutex
+	var got []string
+	err := Walk(context.Background(), root, Options{Workers: 4}, func(path string, d fs.DirEntry) error {
+		mu.Lock()
+		got = append(got, path)
+		mu.Unlock()
+		return nil
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	want, err := serialWalk(root)
+	if err != nil {
+		t.Fatal(err)
+	}
+	sort.Strings(got)
+	sort.Strings(want)
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("Walk visited %d entries, filepath.WalkDir %d", len(got), len(want))
+	}
+}
+
+func TestWalkSkipDir(t *testing.T) {
+	root := makeTree(t, 2, 2, 1)
+
+	skipped := filepath.Join(root, "d0")
+	var visited atomic.Int64
+	err := Walk(context.Background(), root, Options{}, func(path string, d fs.DirEntry) error {
+		if path == skipped {
+			return filepath.SkipDir
+		}
+		if strings.HasPrefix(path, skipped+string(filepath.Separator)) {
+			t.Errorf("visited %s in a skipped directory", path)
+		}
+		visited.Add(1)
+		return nil
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	// root/f0.txt, and d1 with its file and two subdirectories of one file
+	if visited.Load() != 7 {
+		t.Errorf("visited %d entries, want 7", visited.Load())
+	}
+}
+
+func TestWalkError(t *testing.T) {
+	root := makeTree(t, 2, 3, 3)
+	stop := errors.New("stop")
+
+	err := Walk(context.Background(), root, Options{Workers: 4}, func(path string, d fs.DirEntry) error {
+		if d.Name() == "f1.txt" {
+			return stop
+		}
+		return nil
+	})
+	if !errors.Is(err, stop) {
+		t.Errorf("Walk = %v, want %v", err, stop)
+	}
+}
+
+func TestWalkCancel(t *testing.T) {
+	root := makeTree(t, 3, 4, 4)
+	before := runtime.NumGoroutine()
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	var seen atomic.Int64
+	err := Walk(ctx, root, Options{Workers: 8}, func(path string, d fs.DirEntry) error {
+		if seen.Add(1) == 50 {
+			cancel()
+		}
+		return nil
+	})
+	if !errors.Is(err, context.Canceled) {
+		t.Errorf("Walk = %v, want context.Canceled", err)
+	}
+
+	all, _ := serialWalk(root)
+	if n := seen.Load(); n >= int64(len(all)) {
+		t.Errorf("visited all %d entries after cancelling", n)
+	}
+
+	// Every worker and the cancel watcher must have exited
+	deadline := time.Now().Add(2 * time.Second)
+	for runtime.NumGoroutine() > before {
+		if time.Now().After(deadline) {
+			buf := make([]byte, 1<<16)
+			t.Fatalf("%d goroutines left running, %d before the walk:\n%s",
+				runtime.NumGoroutine(), before, buf[:runtime.Stack(buf, true)])
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+}
+
+func BenchmarkWalk(b *testing.B) {
+	benchmarkWalk(b, makeTree(b, 3, 8, 20))
+}
+
+// BenchmarkWalkMillion walks about a million files: 1,111 directories of
+// 900 files. Creating them takes minutes and around 4GB of disk, a block
+// for each file, so it only runs with AI_MGR_BENCH_1M=1:
+//
+//	AI_MGR_BENCH_1M=1 go test ./internal/walker -run '^$' -bench Million -benchtime 3x
+func BenchmarkWalkMillion(b *testing.B) {
+	if os.Getenv("AI_MGR_BENCH_1M") != "1" {
+		b.Skip("set AI_MGR_BENCH_1M=1 to walk a million files")
+	}
+	benchmarkWalk(b, makeTree(b, 3, 10, 900))
+}
+
+// benchmarkWalk compares Walk with filepath.WalkDir over the tree at root,
+// both reading every file's info as Usage does
+func benchmarkWalk(b *testing.B, root string) {
+	b.Run("concurrent", func(b *testing.B) {
+		for i := 0; i < b.N; i++ {
+			err := Walk(context.Background(), root, Options{}, func(path string, d fs.DirEntry) error {
+				_, err := d.Info()
+				return err
+			})
+			if err != nil {
+				b.Fatal(err)
+			}
+		}
+	})
+
+	b.Run("serial", func(b *testing.B) {
+		for i := 0; i < b.N; i++ {
+			err := filepath.WalkDir(root, func(path string, d fs.DirEntry, err error) error {
+				if err != nil {
+					return err
+				}
+				_, err = d.Info()
+				return err
+			})
+			if err != nil {
+				b.Fatal(err)
+			}
+		}
+	})
+
+	b.Run("usage", func(b *testing.B) {
+		for i := 0; i < b.N; i++ {
+			if _, err := Usage(context.Background(), root, Options{}); err != nil {
+				b.Fatal(err)
+			}
+		}
+	})
+}
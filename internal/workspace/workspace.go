@@ -0,0 +1,213 @@
+package workspace
+
+import (
+	"context"
+	"encoding/json"
+	"io/fs"
+	"net/url"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+
+	"ai-manager/internal/adapter"
+	"ai-manager/internal/config"
+	"ai-manager/internal/models"
+	"ai-manager/internal/walker"
+)
+
+// AIExtensions names the AI extensions whose storage is broken down for
+// each workspace, by lower-case extension ID. Extensions keep their
+// workspace state in a folder named after their ID.
+var AIExtensions = map[string]string{
+	"github.copilot":             "GitHub Copilot",
+	"github.copilot-chat":        "GitHub Copilot Chat",
+	"saoudrizwan.claude-dev":     "Cline",
+	"rooveterinaryinc.roo-cline": "Roo Code",
+	"continue.continue":          "Continue",
+	"anthropic.claude-code":      "Claude Code",
+}
+
+// workspaceFile is the workspace.json in each workspace's folder. It holds
+// the URI of the opened folder or of the .code-workspace file.
+type workspaceFile struct {
+	Folder    string `json:"folder"`
+	Workspace string `json:"workspace"`
+}
+
+// List returns the workspaces of every enabled editor, newest first
+func List(ctx context.Context, cfg *config.Config) ([]models.Workspace, error) {
+	keys := make([]string, 0, len(cfg.Tools))
+	for k := range cfg.Tools {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+
+	workspaces := make([]models.Workspace, 0)
+	for _, key := range keys {
+		tool := cfg.Tools[key]
+		store, ok := adapter.For(key).(adapter.WorkspaceStore)
+		if !tool.Enabled || !ok {
+			continue
+		}
+		dir := store.WorkspaceStorage(tool)
+		if dir == "" {
+			continue
+		}
+
+		found, err := Scan(ctx, key, dir)
+		if err != nil {
+			return workspaces, err
+		}
+		workspaces = append(workspaces, found...)
+	}
+
+	sort.SliceStable(workspaces, func(i, j int) bool {
+		return workspaces[i].LastUsed.After(workspaces[j].LastUsed)
+	})
+	return workspaces, nil
+}
+
+// Scan reads the workspaces in a workspaceStorage directory. Each one is
+// resolved through its workspace.json, and its size is broken down by the
+// AI extensions that keep state in it.
+func Scan(ctx context.Context, tool, dir string) ([]models.Workspace, error) {
+	entries, err := os.ReadDir(dir)
+	if os.IsNotExist(err) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	byID := make(map[string]*models.Workspace, len(entries))
+	for _, e := range entries {
+		if !e.IsDir() {
+			continue
+		}
+		ws := &models.Workspace{Tool: tool, ID: e.Name(), Path: filepath.Join(dir, e.Name())}
+		if info, err := e.Info(); err == nil {
+			ws.LastUsed = info.ModTime()
+		}
+		resolve(ws)
+		byID[e.Name()] = ws
+	}
+
+	// One walk for the whole directory, attributing each file to its
+	// workspace and extension
+	var mu sync.Mutex
+	exts := make(map[string]map[string]*models.ExtensionUsage)
+	err = walker.Walk(ctx, dir, walker.Options{}, func(path string, d fs.DirEntry) error {
+		if d.IsDir() {
+			return nil
+		}
+		rel, err := filepath.Rel(dir, path)
+		if err != nil {
+			return nil
+		}
+		parts := strings.Split(filepath.ToSlash(rel), "/")
+		info, err := d.Info()
+		if err != nil {
+			return nil // Removed while walking
+		}
+
+		mu.Lock()
+		defer mu.Unlock()
+		ws := byID[parts[0]]
+		if ws == nil {
+			return nil
+		}
+		ws.Size += info.Size()
+		ws.Files++
+		if info.ModTime().After(ws.LastUsed) {
+			ws.LastUsed = info.ModTime()
+		}
+
+		if len(parts) < 3 {
+			return nil
+		}
+		id := strings.ToLower(parts[1])
+		name, ok := AIExtensions[id]
+		if !ok {
+			return nil
+		}
+		if exts[ws.ID] == nil {
+			exts[ws.ID] = make(map[string]*models.ExtensionUsage)
+		}
+		usage := exts[ws.ID][id]
+		if usage == nil {
+			usage = &models.ExtensionUsage{ID: parts[1], Name: name}
+			exts[ws.ID][id] = usage
+		}
+		usage.Size += info.Size()
+		usage.Files++
+		return nil
+	})
+	if err != nil && !os.IsNotExist(err) {
+		return nil, err
+	}
+
+	workspaces := make([]models.Workspace, 0, len(byID))
+	for id, ws := range byID {
+		for _, usage := range exts[id] {
+			ws.Extensions = append(ws.Extensions, *usage)
+		}
+		sort.Slice(ws.Extensions, func(i, j int) bool {
+			return ws.Extensions[i].Size > ws.Extensions[j].Size
+		})
+		workspaces = append(workspaces, *ws)
+	}
+	sort.Slice(workspaces, func(i, j int) bool {
+		return workspaces[i].ID < workspaces[j].ID
+	})
+	return workspaces, nil
+}
+
+// resolve reads a workspace's workspace.json to find the folder it belongs
+// to. Storage without one, such as that of empty windows, is never
+// orphaned, and neither is that of remote folders, which can't be checked.
+func resolve(ws *models.Workspace) {
+	data, err := os.ReadFile(filepath.Join(ws.Path, "workspace.json"))
+	if err != nil {
+		return
+	}
+	var wf workspaceFile
+	if err := json.Unmarshal(data, &wf); err != nil {
+		return
+	}
+
+	uri := wf.Folder
+	if uri == "" {
+		uri = wf.Workspace
+	}
+	if uri == "" {
+		return
+	}
+
+	folder, local := LocalPath(uri)
+	ws.Folder = folder
+	ws.Remote = !local
+	if local {
+		_, err := os.Stat(folder)
+		ws.Orphaned = os.IsNotExist(err)
+	}
+}
+
+// LocalPath returns the path of a file:// URI. Other URIs, such as those
+// of remote or virtual folders, are returned unchanged and not local.
+func LocalPath(uri string) (string, bool) {
+	u, err := url.Parse(uri)
+	if err != nil || u.Scheme != "file" {
+		return uri, false
+	}
+	return filepath.FromSlash(u.Path), true
+}
+
+// StillOrphaned checks again that a workspace's folder is missing, just
+// before its storage is removed
+func StillOrphaned(ws models.Workspace) bool {
+	current := models.Workspace{Path: ws.Path}
+	resolve(&current)
+	return current.Orphaned && current.Folder == ws.Folder
+}
@@ -0,0 +1,191 @@
+package workspace
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"ai-manager/internal/models"
+)
+
+func writeTestFile(t *testing.T, path, data string) {
+	t.Helper()
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(path, []byte(data), 0644); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestLocalPath(t *testing.T) {
+	tests := []struct {
+		uri   string
+		path  string
+		local bool
+	}{
+		{"file:///home/me/app", "/home/me/app", true},
+		{"file:///home/me/my%20app", "/home/me/my app", true},
+		{"file:///home/me/%C3%A9t%C3%A9/50%25", "/home/me/été/50%", true},
+		{"file:///home/me/team.code-workspace", "/home/me/team.code-workspace", true},
+		{"vscode-remote://ssh-remote%2Bbox/home/me/app", "vscode-remote://ssh-remote%2Bbox/home/me/app", false},
+		{"vscode-vfs://github/org/repo", "vscode-vfs://github/org/repo", false},
+		{"/home/me/app", "/home/me/app", false},
+		{"file://%zz", "file://%zz", false},
+	}
+	for _, tt := range tests {
+		path, local := LocalPath(tt.uri)
+		if path != filepath.FromSlash(tt.path) || local != tt.local {
+			t.Errorf("LocalPath(%q) = %q, %v, want %q, %v", tt.uri, path, local, tt.path, tt.local)
+		}
+	}
+}
+
+// storageFixture creates a workspaceStorage directory with a workspace for
+// each workspace.json given by ID, "" for none, next to a projects
+// directory holding the folder "my app" and team.code-workspace.
+// {projects} in a workspace.json stands for the projects directory's URI.
+func storageFixture(t *testing.T, workspaces map[string]string) (storage, projects string) {
+	t.Helper()
+	dir := t.TempDir()
+	storage = filepath.Join(dir, "workspaceStorage")
+	projects = filepath.Join(dir, "projects")
+	if err := os.MkdirAll(filepath.Join(projects, "my app"), 0755); err != nil {
+		t.Fatal(err)
+	}
+	writeTestFile(t, filepath.Join(projects, "team.code-workspace"), `{"folders":[]}`)
+
+	uri := "file://" + filepath.ToSlash(projects)
+	for id, data := range workspaces {
+		if err := os.MkdirAll(filepath.Join(storage, id), 0755); err != nil {
+			t.Fatal(err)
+		}
+		if data != "" {
+			writeTestFile(t, filepath.Join(storage, id, "workspace.json"), strings.ReplaceAll(data, "{projects}", uri))
+		}
+	}
+	return storage, projects
+}
+
+func TestScanResolvesWorkspaces(t *testing.T) {
+	storage, projects := storageFixture(t, map[string]string{
+		"folder":         `{"folder":"{projects}/my%20app"}`,
+		"folder-gone":    `{"folder":"{projects}/old%20app"}`,
+		"workspace":      `{"workspace":"{projects}/team.code-workspace"}`,
+		"workspace-gone": `{"workspace":"{projects}/old.code-workspace"}`,
+		"remote":         `{"folder":"vscode-remote://ssh-remote%2Bbox/srv/gone"}`,
+		"empty-window":   "",
+		"damaged":        `{"folder":`,
+	})
+	writeTestFile(t, filepath.Join(storage, "folder", "state.vscdb"), "12345")
+	writeTestFile(t, filepath.Join(storage, "folder", "GitHub.copilot-chat", "index.db"), "1234567890")
+	writeTestFile(t, filepath.Join(storage, "folder", "other.extension", "x"), "123")
+
+	workspaces, err := Scan(context.Background(), "vscode", storage)
+	if err != nil {
+		t.Fatal(err)
+	}
+	byID := make(map[string]models.Workspace)
+	for _, ws := range workspaces {
+		byID[ws.ID] = ws
+	}
+
+	tests := map[string]struct {
+		folder           string
+		remote, orphaned bool
+	}{
+		"folder":         {folder: filepath.Join(projects, "my app")},
+		"folder-gone":    {folder: filepath.Join(projects, "old app"), orphaned: true},
+		"workspace":      {folder: filepath.Join(projects, "team.code-workspace")},
+		"workspace-gone": {folder: filepath.Join(projects, "old.code-workspace"), orphaned: true},
+		"remote":         {folder: "vscode-remote://ssh-remote%2Bbox/srv/gone", remote: true},
+		"empty-window":   {},
+		"damaged":        {},
+	}
+	if len(workspaces) != len(tests) {
+		t.Errorf("Scan found %d workspaces, want %d", len(workspaces), len(tests))
+	}
+	for id, want := range tests {
+		ws, ok := byID[id]
+		if !ok {
+			t.Errorf("%s not found", id)
+			continue
+		}
+		if ws.Tool != "vscode" || ws.Path != filepath.Join(storage, id) || ws.Folder != want.folder ||
+			ws.Remote != want.remote || ws.Orphaned != want.orphaned {
+			t.Errorf("%s = %+v, want folder %q, remote %v, orphaned %v", id, ws, want.folder, want.remote, want.orphaned)
+		}
+	}
+
+	ws := byID["folder"]
+	size := int64(len(`{"folder":"file://`+filepath.ToSlash(projects)+`/my%20app"}`)) + 5 + 10 + 3
+	if ws.Files != 4 || ws.Size != size {
+		t.Errorf("folder has %d files of %d bytes, want 4 of %d", ws.Files, ws.Size, size)
+	}
+	if len(ws.Extensions) != 1 || ws.Extensions[0] != (models.ExtensionUsage{ID: "GitHub.copilot-chat", Name: "GitHub Copilot Chat", Size: 10, Files: 1}) {
+		t.Errorf("extensions = %+v, want only GitHub Copilot Chat", ws.Extensions)
+	}
+}
+
+func TestScanMissingStorage(t *testing.T) {
+	workspaces, err := Scan(context.Background(), "cursor", filepath.Join(t.TempDir(), "workspaceStorage"))
+	if err != nil || len(workspaces) != 0 {
+		t.Errorf("Scan = %+v, %v, want nothing", workspaces, err)
+	}
+}
+
+func TestStillOrphaned(t *testing.T) {
+	storage, projects := storageFixture(t, map[string]string{
+		"gone":   `{"folder":"{projects}/old%20app"}`,
+		"live":   `{"folder":"{projects}/my%20app"}`,
+		"remote": `{"folder":"vscode-remote://wsl%2Bubuntu/gone"}`,
+	})
+	workspaces, err := Scan(context.Background(), "vscode", storage)
+	if err != nil {
+		t.Fatal(err)
+	}
+	byID := make(map[string]models.Workspace)
+	for _, ws := range workspaces {
+		byID[ws.ID] = ws
+	}
+	gone := byID["gone"]
+	if !StillOrphaned(gone) {
+		t.Fatal("StillOrphaned is false for a missing folder")
+	}
+	for _, id := range []string{"live", "remote"} {
+		if StillOrphaned(byID[id]) {
+			t.Errorf("StillOrphaned(%s) is true", id)
+		}
+	}
+
+	// Reopened in another folder, also missing, since the scan
+	writeTestFile(t, filepath.Join(gone.Path, "workspace.json"), `{"folder":"file:///nowhere/else"}`)
+	if StillOrphaned(gone) {
+		t.Error("StillOrphaned is true after workspace.json changed")
+	}
+
+	// Back to the original folder, until it is recreated
+	writeTestFile(t, filepath.Join(gone.Path, "workspace.json"), `{"folder":"file://`+filepath.ToSlash(projects)+`/old%20app"}`)
+	if !StillOrphaned(gone) {
+		t.Fatal("StillOrphaned is false with the original workspace.json")
+	}
+	if err := os.Mkdir(filepath.Join(projects, "old app"), 0755); err != nil {
+		t.Fatal(err)
+	}
+	if StillOrphaned(gone) {
+		t.Error("StillOrphaned is true after the folder was recreated")
+	}
+
+	// Without workspace.json nothing is known about the folder
+	if err := os.Remove(filepath.Join(projects, "old app")); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.Remove(filepath.Join(gone.Path, "workspace.json")); err != nil {
+		t.Fatal(err)
+	}
+	if StillOrphaned(gone) {
+		t.Error("StillOrphaned is true without workspace.json")
+	}
+}